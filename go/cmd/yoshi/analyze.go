@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-extractor/metrics"
+	"github-extractor/models"
+	"github-extractor/server"
+	"github-extractor/source"
+)
+
+// Statuses of a row
+const (
+	StatusAnalyzed    = "analyzed"     // Extracted, measured and classified
+	StatusNotEligible = "not_eligible" // Classified as a simple project without extraction
+	StatusFailed      = "failed"       // Retried by the next run
+)
+
+// Row is the outcome of the analysis of one repository
+type Row struct {
+	Host   string `json:"host" parquet:"host"`
+	Owner  string `json:"owner" parquet:"owner"`
+	Repo   string `json:"repo" parquet:"repo"`
+	Status string `json:"status" parquet:"status"`
+	Reason string `json:"reason,omitempty" parquet:"reason,optional"` // Why the repository is not eligible
+	Error  string `json:"error,omitempty" parquet:"error,optional"`
+
+	Category      string  `json:"category,omitempty" parquet:"category,optional"`
+	Formality     float64 `json:"formality" parquet:"formality"`
+	Geodispersion float64 `json:"geodispersion" parquet:"geodispersion"`
+	Longevity     float64 `json:"longevity" parquet:"longevity"`
+	Cohesion      float64 `json:"cohesion" parquet:"cohesion"`
+	Engagement    float64 `json:"engagement" parquet:"engagement"`
+	Structure     float64 `json:"structure" parquet:"structure"`
+
+	// Sizes of the extracted repository
+	Stars                int `json:"stars" parquet:"stars"`
+	Commits              int `json:"commits" parquet:"commits"`
+	Contributors         int `json:"contributors" parquet:"contributors"`
+	SelectedContributors int `json:"selected_contributors" parquet:"selected_contributors"`
+
+	AsOf       string `json:"as_of,omitempty" parquet:"as_of,optional"` // RFC 3339 snapshot date, empty for the present
+	AnalyzedAt string `json:"analyzed_at" parquet:"analyzed_at"`        // RFC 3339
+}
+
+// repositoryKey identifies a repository across runs
+func repositoryKey(host, owner, repo string) string {
+	return strings.ToLower(repositoryHost(host) + "/" + owner + "/" + repo)
+}
+
+// repositoryHost normalizes host, an empty one meaning github.com
+func repositoryHost(host string) string {
+	if host = source.NormalizeHost(host); host == "" {
+		return source.DefaultHost
+	}
+	return host
+}
+
+// analyzer runs the eligibility check, extraction, metrics and classification of a
+// repository, like the /process endpoint
+type analyzer struct {
+	service    *server.Service
+	processor  server.Processor
+	params     server.EligibilityParams
+	selection  models.SelectionPolicy
+	thresholds metrics.Thresholds
+}
+
+func (a *analyzer) analyze(ctx context.Context, host, owner, repo string) Row {
+	host = repositoryHost(host)
+	row := Row{Host: host, Owner: owner, Repo: repo}
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		row.AsOf = asOf.Format(time.RFC3339)
+	}
+
+	res := a.service.CheckAndProcessRepository(source.WithSelection(ctx, a.selection), host, owner, repo, a.params)
+	switch {
+	case res.Err != nil:
+		return row.failed(res.Err.Error())
+	case !res.Eligible:
+		row.Status = StatusNotEligible
+		row.Reason = res.Reason
+		row.Category = metrics.SimpleProject
+		row.AnalyzedAt = time.Now().UTC().Format(time.RFC3339)
+		return row
+	case res.Info.Error != "":
+		return row.failed(fmt.Sprintf("extraction failed: %s", res.Info.Error))
+	}
+
+	info := res.Info
+	row.Stars = info.Stars
+	row.Commits = info.Commits
+	row.Contributors = info.TotalContributorsCount
+	row.SelectedContributors = info.SelectedContributorsCount
+
+	result, err := a.processor.ProcessRepository(ctx, info)
+	if err != nil {
+		return row.failed(fmt.Sprintf("processing failed: %v", err))
+	}
+
+	row.Status = StatusAnalyzed
+	row.Category = metrics.Classify(result, a.thresholds).Category
+	row.Formality = result.Formality
+	row.Geodispersion = result.Geodispersion
+	row.Longevity = result.Longevity
+	row.Cohesion = result.Cohesion
+	row.Engagement = result.Engagement
+	row.Structure = result.Structure
+	row.AnalyzedAt = time.Now().UTC().Format(time.RFC3339)
+	return row
+}
+
+// failed marks the row as failed with the given error
+func (r Row) failed(msg string) Row {
+	r.Status = StatusFailed
+	r.Error = msg
+	r.AnalyzedAt = time.Now().UTC().Format(time.RFC3339)
+	return r
+}
@@ -0,0 +1,322 @@
+// Command yoshi analyzes a list of repositories without running the server. It checks
+// the eligibility of every repository, extracts the eligible ones, computes their metrics
+// and classifies their community, then writes one row per repository as CSV, JSON Lines
+// or Parquet.
+//
+// Usage:
+//
+//	yoshi [flags] [repositories.csv]
+//
+// The repositories are read from the CSV file, or from stdin when it is omitted, in the
+// format of the /extract/batch endpoint: "owner,repo" or "owner/repo" rows with an
+// optional header. Tokens, hosts, the metrics processor and the classification
+// thresholds are configured with the same environment variables as the server.
+//
+// With -progress, every analyzed repository is appended to a progress file as soon as it
+// is done. Running the same command again after an interruption skips the repositories
+// the file already holds and writes the complete output once the rest is done.
+// Repositories that failed are retried. The file starts with the parameters of the run,
+// and a run with other parameters refuses to resume from it.
+//
+// With -as-of, every repository is analyzed as it was at that date, like the as_of field
+// of the server requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github-extractor/config"
+	"github-extractor/models"
+	"github-extractor/server"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "yoshi:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the command line flags
+type options struct {
+	input         string
+	output        string
+	format        string
+	progress      string
+	host          string
+	minCommits    int
+	days          int
+	minActive     int
+	workers       int
+	selection     string
+	selectionN    int
+	percentile    float64
+	selectionDays int
+	asOf          string
+	engagement    bool
+}
+
+func parseFlags() options {
+	var o options
+	flag.StringVar(&o.output, "o", "-", "output file, - for stdout")
+	flag.StringVar(&o.format, "format", "", "output format: csv, jsonl or parquet (default from the output file extension, else csv)")
+	flag.StringVar(&o.progress, "progress", "", "progress file to resume an interrupted run from")
+	flag.StringVar(&o.host, "host", "", "host of the repositories, defaults to github.com")
+	flag.IntVar(&o.minCommits, "min-commits", 1, "minimum total number of commits")
+	flag.IntVar(&o.days, "days", 1000, "window of -min-active in days")
+	flag.IntVar(&o.minActive, "min-active", 1, "minimum number of contributors active in the last -days days")
+	flag.IntVar(&o.workers, "workers", 0, "repositories extracted at the same time (default WORKERS)")
+	flag.StringVar(&o.selection, "selection", "", "contributor selection strategy: all, top, sqrt, percentile or active (default sqrt plus the last 90 days)")
+	flag.IntVar(&o.selectionN, "selection-n", 0, "contributors kept by the top strategy")
+	flag.Float64Var(&o.percentile, "selection-percentile", 0, "contribution percentile kept by the percentile strategy")
+	flag.IntVar(&o.selectionDays, "selection-days", 0, "also select the contributors active in the last N days (default 90 for the active strategy, else none)")
+	flag.StringVar(&o.asOf, "as-of", "", "analyze the repositories as they were at this RFC 3339 timestamp or YYYY-MM-DD date")
+	flag.BoolVar(&o.engagement, "engagement", false, "read the recent threads the engagement and structure scores are computed from")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: yoshi [flags] [repositories.csv]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	o.input = flag.Arg(0)
+	return o
+}
+
+func run() error {
+	opts := parseFlags()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		return fmt.Errorf("expected at most one input file")
+	}
+
+	format, err := parseFormat(opts.format, opts.output)
+	if err != nil {
+		return err
+	}
+	if opts.minCommits <= 0 || opts.days <= 0 || opts.minActive <= 0 {
+		return fmt.Errorf("-min-commits, -days and -min-active must be greater than 0")
+	}
+	params := server.EligibilityParams{MinCommits: opts.minCommits, Days: opts.days, MinActive: opts.minActive}
+
+	selection := source.DefaultSelection
+	if opts.selection != "" {
+		selection = models.SelectionPolicy{
+			Strategy:   opts.selection,
+			N:          opts.selectionN,
+			Percentile: opts.percentile,
+			Days:       opts.selectionDays,
+		}
+		if selection.Strategy == source.SelectActive && selection.Days == 0 {
+			selection.Days = source.RecentDays
+		}
+		if err := source.ValidateSelection(selection); err != nil {
+			return err
+		}
+	}
+
+	var asOf time.Time
+	if opts.asOf != "" {
+		if asOf, err = source.ParseAsOf(opts.asOf); err != nil {
+			return err
+		}
+		if asOf.After(time.Now()) {
+			return fmt.Errorf("-as-of must not be in the future")
+		}
+		if opts.engagement {
+			return fmt.Errorf("-engagement cannot be combined with -as-of: threads are only available as they are now")
+		}
+	}
+
+	// Logs go to stderr, stdout may carry the results
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	_, logLevel := config.LoadLoggingConfig()
+	if level, err := logrus.ParseLevel(strings.ToLower(logLevel)); err == nil {
+		logger.SetLevel(level)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.CloneDir != "" && !asOf.IsZero() {
+		return fmt.Errorf("-as-of cannot be combined with clone mode (GIT_CLONE_DIR)")
+	}
+	if opts.workers > 0 {
+		cfg.Workers = opts.workers
+	}
+	reqs, err := readRepositories(opts.input, opts.host)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		return fmt.Errorf("no repositories provided")
+	}
+
+	// Rows of the repositories a previous run with the same parameters finished
+	done := make(map[string]Row)
+	var progress *progressFile
+	if opts.progress != "" {
+		parameters := runParameters{
+			Host:       repositoryHost(opts.host),
+			MinCommits: params.MinCommits,
+			Days:       params.Days,
+			MinActive:  params.MinActive,
+			Selection:  selection,
+			Engagement: opts.engagement,
+			Thresholds: cfg.Thresholds,
+		}
+		if !asOf.IsZero() {
+			parameters.AsOf = asOf.UTC().Format(time.RFC3339)
+		}
+		if progress, done, err = openProgress(opts.progress, parameters); err != nil {
+			return err
+		}
+		defer progress.Close()
+	}
+
+	sources, err := server.NewSources(cfg, logger)
+	if err != nil {
+		return err
+	}
+	service, err := server.NewService(sources, cfg.Workers, logger)
+	if err != nil {
+		return err
+	}
+	processor, closeProcessor, err := server.NewProcessor(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeProcessor()
+
+	ctx, stop := signal.NotifyContext(source.WithEngagement(source.WithAsOf(context.Background(), asOf), opts.engagement), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &analyzer{
+		service:    service,
+		processor:  processor,
+		params:     params,
+		selection:  selection,
+		thresholds: cfg.Thresholds,
+	}
+
+	rows, err := a.analyzeAll(ctx, reqs, done, progress, logger)
+	if err != nil {
+		if ctx.Err() != nil && progress != nil {
+			return fmt.Errorf("%w; run again with -progress %s to continue", err, opts.progress)
+		}
+		return err
+	}
+
+	return writeOutput(opts.output, format, rows)
+}
+
+// analyzeAll analyzes the repositories of reqs that done does not hold, appending every
+// one that did not fail to progress when set, and returns the rows of all of them in the
+// order of reqs
+func (a *analyzer) analyzeAll(ctx context.Context, reqs []server.ExtractRequest, done map[string]Row, progress *progressFile, logger *logrus.Logger) ([]Row, error) {
+	rows := make([]Row, len(reqs))
+	pending := 0
+	for i, req := range reqs {
+		if row, ok := done[repositoryKey(req.Host, req.Owner, req.Repo)]; ok {
+			rows[i] = row
+			continue
+		}
+		pending++
+	}
+	if skipped := len(reqs) - pending; skipped > 0 {
+		logger.Infof("Resuming: %d of %d repositories already analyzed", skipped, len(reqs))
+	}
+
+	var (
+		mu       sync.Mutex
+		finished int
+		wg       sync.WaitGroup
+		saveErr  error
+	)
+	for i, req := range reqs {
+		if _, ok := done[repositoryKey(req.Host, req.Owner, req.Repo)]; ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, req server.ExtractRequest) {
+			defer wg.Done()
+
+			row := a.analyze(ctx, req.Host, req.Owner, req.Repo)
+			if ctx.Err() != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			rows[idx] = row
+			finished++
+			if row.Status == StatusFailed {
+				logger.Warnf("[%d/%d] %s/%s failed: %s", finished, pending, row.Owner, row.Repo, row.Error)
+			} else {
+				logger.Infof("[%d/%d] %s/%s: %s", finished, pending, row.Owner, row.Repo, row.Category)
+			}
+
+			// Failed repositories are left out of the progress file so a rerun retries them
+			if progress != nil && row.Status != StatusFailed {
+				if err := progress.Append(row); err != nil && saveErr == nil {
+					saveErr = err
+					logger.Errorf("Error saving progress: %v", err)
+				}
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("interrupted after %d of %d repositories", finished, pending)
+	}
+	if saveErr != nil {
+		return nil, fmt.Errorf("save progress: %w", saveErr)
+	}
+	return rows, nil
+}
+
+// readRepositories reads the repository list from path, or stdin when path is empty or "-"
+func readRepositories(path, host string) ([]server.ExtractRequest, error) {
+	var r io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return server.ParseBatchCSV(r, server.ExtractRequest{Host: host})
+}
+
+// writeOutput writes the rows to path, or stdout when path is "-"
+func writeOutput(path string, format Format, rows []Row) error {
+	if path == "-" {
+		return format.Write(os.Stdout, rows)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := format.Write(f, rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
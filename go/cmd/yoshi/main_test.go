@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+	"github-extractor/server"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReadRepositories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.csv")
+	if err := os.WriteFile(path, []byte("owner,repo\nacme,widget\nacme/gadget\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reqs, err := readRepositories(path, "gitlab.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 || reqs[0].Repo != "widget" || reqs[1].Repo != "gadget" || reqs[1].Host != "gitlab.com" {
+		t.Errorf("file: %+v, want acme/widget and acme/gadget on gitlab.com", reqs)
+	}
+
+	// Without a file, or with "-", the list comes from stdin
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	saved := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = saved })
+	reqs, err = readRepositories("-", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 || reqs[0].Owner != "acme" || reqs[0].Host != "" {
+		t.Errorf("stdin: %+v, want acme/widget and acme/gadget", reqs)
+	}
+
+	if _, err := readRepositories(filepath.Join(t.TempDir(), "missing.csv"), ""); err == nil {
+		t.Error("missing file read")
+	}
+}
+
+// smallRepository has 20 daily commits by alice
+func smallRepository(repo string, now time.Time) githubtest.Repository {
+	r := githubtest.Repository{
+		Owner:        "acme",
+		Repo:         repo,
+		CreatedAt:    now.AddDate(-1, 0, 0),
+		UpdatedAt:    now,
+		Contributors: []githubtest.Contributor{{Login: "alice", Contributions: 20}},
+	}
+	for i := 0; i < 20; i++ {
+		r.Commits = append(r.Commits, githubtest.Commit{SHA: fmt.Sprintf("%040x", i), AuthorLogin: "alice", Date: now.AddDate(0, 0, -i)})
+	}
+	return r
+}
+
+// stubProcessor returns the same metrics for every repository
+type stubProcessor struct{}
+
+func (stubProcessor) ProcessRepository(ctx context.Context, info models.RepositoryInfo) (*grpcclient.ProcessResult, error) {
+	return &grpcclient.ProcessResult{Formality: 0.5}, nil
+}
+
+// Repositories the progress file holds are not analyzed again, and the others are
+// appended to it
+func TestAnalyzeAllSkipsDone(t *testing.T) {
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	now := time.Now()
+	fake.AddRepository(smallRepository("widget", now))
+	fake.AddRepository(smallRepository("gadget", now))
+	fake.AddUser(githubtest.User{Login: "alice", Location: "Lisbon"})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := server.NewService(source.NewRegistry(client), 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &analyzer{
+		service:   service,
+		processor: stubProcessor{},
+		params:    server.EligibilityParams{MinCommits: 1, Days: 1000, MinActive: 1},
+		selection: source.DefaultSelection,
+	}
+
+	path := filepath.Join(t.TempDir(), "study.progress")
+	progress, _, err := openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := Row{Host: "github.com", Owner: "acme", Repo: "widget", Status: StatusAnalyzed, Formality: 0.25}
+	if err := progress.Append(previous); err != nil {
+		t.Fatal(err)
+	}
+	progress.Close()
+
+	progress, done, err := openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := []server.ExtractRequest{{Owner: "acme", Repo: "widget"}, {Owner: "acme", Repo: "gadget"}}
+	rows, err := a.analyzeAll(context.Background(), reqs, done, progress, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rows[0] != previous {
+		t.Errorf("acme/widget = %+v, want the row of the previous run", rows[0])
+	}
+	if n := fake.RequestCount("/repos/acme/widget"); n != 0 {
+		t.Errorf("%d requests for acme/widget, want none", n)
+	}
+	if rows[1].Status != StatusAnalyzed || rows[1].Formality != 0.5 || rows[1].Commits != 20 {
+		t.Errorf("acme/gadget = %+v, want it analyzed", rows[1])
+	}
+
+	progress.Close()
+	progress, done, err = openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress.Close()
+	if len(done) != 2 {
+		t.Errorf("progress file holds %d rows, want 2", len(done))
+	}
+}
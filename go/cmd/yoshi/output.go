@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format is an output format of the analysis results
+type Format string
+
+const (
+	CSV     Format = "csv"
+	JSONL   Format = "jsonl"
+	Parquet Format = "parquet"
+)
+
+// parseFormat returns the named format or, when name is empty, the one matching the
+// extension of the output file, defaulting to CSV
+func parseFormat(name, output string) (Format, error) {
+	if name == "" {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".jsonl", ".ndjson", ".json":
+			return JSONL, nil
+		case ".parquet":
+			return Parquet, nil
+		default:
+			return CSV, nil
+		}
+	}
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, JSONL, Parquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q: must be csv, jsonl or parquet", name)
+	}
+}
+
+// csvHeader names the CSV columns, in the order of csvRecord
+var csvHeader = []string{
+	"host", "owner", "repo", "status", "reason", "error", "category",
+	"formality", "geodispersion", "longevity", "cohesion", "engagement", "structure",
+	"stars", "commits", "contributors", "selected_contributors", "as_of", "analyzed_at",
+}
+
+func csvRecord(r Row) []string {
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		r.Host, r.Owner, r.Repo, r.Status, r.Reason, r.Error, r.Category,
+		float(r.Formality), float(r.Geodispersion), float(r.Longevity), float(r.Cohesion), float(r.Engagement), float(r.Structure),
+		strconv.Itoa(r.Stars), strconv.Itoa(r.Commits), strconv.Itoa(r.Contributors), strconv.Itoa(r.SelectedContributors), r.AsOf, r.AnalyzedAt,
+	}
+}
+
+// Write writes the rows to w in format f
+func (f Format) Write(w io.Writer, rows []Row) error {
+	switch f {
+	case JSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case Parquet:
+		pw := parquet.NewGenericWriter[Row](w)
+		if _, err := pw.Write(rows); err != nil {
+			return err
+		}
+		return pw.Close()
+
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write(csvRecord(r)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name, output string
+		want         Format
+		wantErr      bool
+	}{
+		{"", "-", CSV, false},
+		{"", "results.csv", CSV, false},
+		{"", "results.JSONL", JSONL, false},
+		{"", "results.ndjson", JSONL, false},
+		{"", "results.parquet", Parquet, false},
+		{"jsonl", "results.parquet", JSONL, false},
+		{"xml", "-", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseFormat(tt.name, tt.output)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseFormat(%q, %q) = %q, %v; want %q", tt.name, tt.output, got, err, tt.want)
+		}
+	}
+}
+
+func testRows() []Row {
+	return []Row{
+		{Host: "github.com", Owner: "acme", Repo: "widget", Status: StatusAnalyzed, Category: "community_of_practice",
+			Formality: 0.5, Geodispersion: 1.25, Longevity: 0.75, Stars: 42, Commits: 150, Contributors: 4, SelectedContributors: 3,
+			AnalyzedAt: "2025-03-01T12:00:00Z"},
+		{Host: "gitlab.com", Owner: "acme", Repo: "gadget", Status: StatusNotEligible, Reason: "fewer than 100 commits",
+			Category: "simple_project", AsOf: "2024-01-01T00:00:00Z", AnalyzedAt: "2025-03-01T12:00:01Z"},
+		{Host: "github.com", Owner: "acme", Repo: "missing", Status: StatusFailed, Error: "Not Found", AnalyzedAt: "2025-03-01T12:00:02Z"},
+	}
+}
+
+// Every format reads back as the rows it was given
+func TestFormatWrite(t *testing.T) {
+	rows := testRows()
+
+	var buf bytes.Buffer
+	if err := CSV.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(rows)+1 || !reflect.DeepEqual(records[0], csvHeader) {
+		t.Fatalf("CSV = %v, want the header and %d rows", records, len(rows))
+	}
+	for i, r := range rows {
+		if !reflect.DeepEqual(records[i+1], csvRecord(r)) {
+			t.Errorf("CSV row %d = %v, want %v", i, records[i+1], csvRecord(r))
+		}
+	}
+	if got := records[1][7]; got != "0.5" {
+		t.Errorf("CSV formality = %q, want 0.5", got)
+	}
+
+	buf.Reset()
+	if err := JSONL.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&buf)
+	for i, want := range rows {
+		var got Row
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("JSON line %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("JSON line %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if dec.More() {
+		t.Error("extra JSON lines")
+	}
+
+	buf.Reset()
+	if err := Parquet.Write(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parquet.Read[Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("Parquet rows = %+v, want %+v", got, rows)
+	}
+}
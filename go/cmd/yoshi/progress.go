@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github-extractor/metrics"
+	"github-extractor/models"
+)
+
+// runParameters are the settings that decide the row of a repository. The progress file
+// starts with them, so a run only resumes the rows of a run with the same ones.
+type runParameters struct {
+	Host       string                 `json:"host"`
+	MinCommits int                    `json:"min_commits"`
+	Days       int                    `json:"days"`
+	MinActive  int                    `json:"min_active"`
+	Selection  models.SelectionPolicy `json:"selection"`
+	AsOf       string                 `json:"as_of,omitempty"` // RFC 3339, empty for the present
+	Engagement bool                   `json:"engagement"`
+	Thresholds metrics.Thresholds     `json:"thresholds"`
+}
+
+// progressHeader is the first line of a progress file
+type progressHeader struct {
+	Parameters *runParameters `json:"parameters"`
+}
+
+// progressFile appends the rows of finished repositories as JSON lines, synced to disk
+// one by one so an interrupted run loses at most the repository being written
+type progressFile struct {
+	f *os.File
+}
+
+// openProgress opens the progress file at path, creating it with a header line holding
+// params if needed, and returns the rows it already holds by repository key. A non-empty
+// file without that header, or written with other parameters, is refused. A row cut short
+// by an interruption is dropped.
+func openProgress(path string, params runParameters) (*progressFile, map[string]Row, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open progress file: %w", err)
+	}
+	want, err := json.Marshal(progressHeader{Parameters: &params})
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	done := make(map[string]Row)
+	var valid int64 // Length of the complete lines read so far
+	reader := bufio.NewReader(f)
+	line, err := reader.ReadBytes('\n')
+	if len(line) > 0 {
+		// Only the rows after a complete header are ever dropped
+		var header progressHeader
+		if err != nil || json.Unmarshal(line, &header) != nil || header.Parameters == nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("progress file %s does not start with the parameters of its run; remove it to start over", path)
+		}
+		got, _ := json.Marshal(header)
+		if string(got) != string(want) {
+			f.Close()
+			return nil, nil, fmt.Errorf("progress file %s was written with other parameters: %s, now %s; rerun with the same flags and environment or remove it to start over", path, got, want)
+		}
+		valid = int64(len(line))
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				break // EOF, possibly after a partial last line
+			}
+			var row Row
+			if json.Unmarshal(line, &row) != nil {
+				break
+			}
+			done[repositoryKey(row.Host, row.Owner, row.Repo)] = row
+			valid += int64(len(line))
+		}
+	} else if err != io.EOF {
+		f.Close()
+		return nil, nil, fmt.Errorf("read progress file: %w", err)
+	}
+
+	// Drop whatever follows the last complete line, then append after it
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("truncate progress file: %w", err)
+	}
+	if _, err := f.Seek(valid, 0); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("seek progress file: %w", err)
+	}
+	p := &progressFile{f: f}
+	if valid == 0 {
+		if err := p.write(want); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("write progress file: %w", err)
+		}
+	}
+	return p, done, nil
+}
+
+// Append records a finished repository
+func (p *progressFile) Append(row Row) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return p.write(data)
+}
+
+// write appends a line and syncs it to disk
+func (p *progressFile) write(line []byte) error {
+	if _, err := p.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return p.f.Sync()
+}
+
+func (p *progressFile) Close() error {
+	return p.f.Close()
+}
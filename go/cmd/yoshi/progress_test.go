@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github-extractor/source"
+)
+
+func testParameters() runParameters {
+	return runParameters{Host: "github.com", MinCommits: 1, Days: 1000, MinActive: 1, Selection: source.DefaultSelection}
+}
+
+// Rows survive a reopen, and a line cut short by an interruption is dropped
+func TestProgressResumes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "study.progress")
+	p, done, err := openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 0 {
+		t.Fatalf("new progress file holds %d rows", len(done))
+	}
+	if err := p.Append(Row{Host: "github.com", Owner: "acme", Repo: "widget", Status: StatusAnalyzed}); err != nil {
+		t.Fatal(err)
+	}
+	p.f.WriteString(`{"host":"github.com","owner":"acme","re`)
+	p.Close()
+
+	p, done, err = openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	if row, ok := done[repositoryKey("", "Acme", "Widget")]; !ok || row.Status != StatusAnalyzed {
+		t.Errorf("done = %v, want acme/widget", done)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"parameters":`) {
+		t.Errorf("progress file = %q, want the parameters and one row", data)
+	}
+}
+
+func TestProgressRefusesOtherParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "study.progress")
+	p, _, err := openProgress(path, testParameters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	other := testParameters()
+	other.AsOf = "2024-01-01T00:00:00Z"
+	if _, _, err := openProgress(path, other); err == nil || !strings.Contains(err.Error(), "other parameters") {
+		t.Errorf("error = %v, want the parameters to differ", err)
+	}
+
+}
+
+// A file that does not start with a complete header is refused and left as it is
+func TestProgressRefusesUnreadableHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"rows without parameters", `{"host":"github.com","owner":"acme","repo":"widget","status":"analyzed"}` + "\n"},
+		{"header cut short", `{"parameters":{"host":"github.com","min_co`},
+		{"other file", "owner,repo\nacme,widget"},
+		{"other file without newline", "acme/widget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "study.progress")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := openProgress(path, testParameters()); err == nil {
+				t.Error("progress file resumed")
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.content {
+				t.Errorf("file = %q after the refusal, want it untouched", data)
+			}
+		})
+	}
+}
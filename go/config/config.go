@@ -3,15 +3,48 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-extractor/metrics"
 )
 
 const (
-	TokenEnvVar = "YOSHI_GH_TOKEN" // Environment variable name for GitHub token
-	DefaultPort = "6001"           // Default HTTP server port
+	TokenEnvVar  = "YOSHI_GH_TOKEN"  // Environment variable name for GitHub token
+	TokensEnvVar = "YOSHI_GH_TOKENS" // Comma-separated list of additional pooled GitHub tokens
+	DefaultPort  = "6001"            // Default HTTP server port
 
 	// gRPC
 	DefaultGRPCAddress = "localhost:50051" // Default gRPC processor service address
 
+	// Where metrics are computed: "grpc" (Python processor service) or "inprocess"
+	DefaultMetrics = "grpc"
+	// Location and culture datasets of the in-process geodispersion calculator
+	DefaultMetricsDatasets = "../python/datasets"
+
+	// Community classification thresholds, the GUI defaults
+	DefaultThresholdGeodispersion = 0.25
+	DefaultThresholdFormality     = 0.50
+	DefaultThresholdLongevity     = 0.40
+	DefaultThresholdCohesion      = 0.40
+
+	// GitHub API used for pull requests, profiles and follow edges: "rest" or "graphql"
+	DefaultGitHubAPI = "rest"
+
+	// Other hosts repositories can be extracted from, selected with the request host
+	DefaultGitLabURL = "https://gitlab.com"
+	DefaultGiteaURL  = "https://codeberg.org" // Gitea or Forgejo instance
+
+	// Maximum number of repositories extracted at the same time
+	DefaultWorkers = 8
+
+	// Result store
+	DefaultStorePath = "./yoshi.db" // Embedded database holding past analyses
+
+	// GitHub response cache, off unless GH_CACHE_DIR names a directory
+	DefaultCacheDir = ""
+
 	// Logging
 	DefaultLogFile  = "./gh-extractor.log"
 	DefaultLogLevel = "info"
@@ -19,19 +52,55 @@ const (
 
 // Application configuration
 type Config struct {
-	GitHubToken string
-	Port        string
-	LogFile     string
-	LogLevel    string
-	GRPCAddress string
+	GitHubTokens    []string
+	GitHubApp       *GitHubApp // Nil unless the server authenticates as a GitHub App
+	GitHubAPI       string
+	GitHubCABundle  string // Extra trusted CAs for github.com, e.g. behind a TLS-inspecting proxy
+	GitHubProxy     string
+	Enterprise      *Enterprise // Nil unless a GitHub Enterprise Server instance is configured
+	GitLabURL       string
+	GitLabToken     string
+	GiteaURL        string
+	GiteaToken      string
+	Port            string
+	LogFile         string
+	LogLevel        string
+	GRPCAddress     string
+	Metrics         string
+	MetricsDatasets string
+	Thresholds      metrics.Thresholds
+	Workers         int
+	StorePath       string
+	CacheDir        string    // Empty when the response cache is disabled
+	CloneDir        string    // Empty when clone mode is disabled
+	RecordDir       string    // Directory GitHub API traffic is recorded into; empty disables recording
+	ReplayDir       string    // Directory GitHub API traffic is replayed from; empty disables replay
+	ReplayAt        time.Time // Replay the recordings made by then; zero replays the latest
 }
 
 // Load configuration from environment and returns Config or error
 func LoadConfig() (*Config, error) {
-	// Set GitHub token. Return error if not present
-	token := getEnv(TokenEnvVar, "")
-	if token == "" {
-		return nil, fmt.Errorf("environment variable %s is not set", TokenEnvVar)
+	// Set GitHub tokens and app. Return error if neither is present
+	tokens := splitList(getEnv(TokenEnvVar, "") + "," + getEnv(TokensEnvVar, ""))
+	app, err := loadGitHubApp("GH_APP_")
+	if err != nil {
+		return nil, err
+	}
+
+	// Record and replay archives; replaying needs no credentials
+	recordDir := getEnv("GH_RECORD_DIR", "")
+	replayDir := getEnv("GH_REPLAY_DIR", "")
+	if recordDir != "" && replayDir != "" {
+		return nil, fmt.Errorf("GH_RECORD_DIR and GH_REPLAY_DIR cannot be set together")
+	}
+	var replayAt time.Time
+	if v := os.Getenv("GH_REPLAY_AT"); v != "" {
+		if replayAt, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid GH_REPLAY_AT value %q: must be an RFC 3339 timestamp", v)
+		}
+	}
+	if len(tokens) == 0 && app == nil && replayDir == "" {
+		return nil, fmt.Errorf("environment variable %s, %s or GH_APP_ID is not set", TokenEnvVar, TokensEnvVar)
 	}
 
 	// Set server port
@@ -40,26 +109,214 @@ func LoadConfig() (*Config, error) {
 	// gRPC address
 	grpcAddr := getEnv("GRPC_ADDRESS", DefaultGRPCAddress)
 
+	// Metrics computation
+	metrics := strings.ToLower(getEnv("METRICS", DefaultMetrics))
+	if metrics != "grpc" && metrics != "inprocess" {
+		return nil, fmt.Errorf("invalid METRICS value %q: must be grpc or inprocess", metrics)
+	}
+
+	// Community classification thresholds, overridable per request
+	thresholds, err := loadThresholds()
+	if err != nil {
+		return nil, err
+	}
+
+	// Extraction backend
+	githubAPI := strings.ToLower(getEnv("GH_API", DefaultGitHubAPI))
+	if githubAPI != "rest" && githubAPI != "graphql" {
+		return nil, fmt.Errorf("invalid GH_API value %q: must be rest or graphql", githubAPI)
+	}
+
+	// GitHub Enterprise Server, extracted from alongside github.com
+	enterprise, err := loadEnterprise()
+	if err != nil {
+		return nil, err
+	}
+
+	// Other hosts; their tokens are optional
+	gitlabURL := getEnv("GITLAB_URL", DefaultGitLabURL)
+	giteaURL := getEnv("GITEA_URL", DefaultGiteaURL)
+
+	// Worker pool
+	workers, err := strconv.Atoi(getEnv("WORKERS", strconv.Itoa(DefaultWorkers)))
+	if err != nil || workers < 1 {
+		return nil, fmt.Errorf("invalid WORKERS value %q: must be a positive integer", os.Getenv("WORKERS"))
+	}
+
+	// Result store
+	storePath := getEnv("STORE_PATH", DefaultStorePath)
+
+	// GitHub response cache, off unless a directory is given
+	cacheDir := getEnv("GH_CACHE_DIR", DefaultCacheDir)
+	if cacheDir == "off" {
+		cacheDir = ""
+	}
+
+	// Clone mode, off unless a directory is given
+	cloneDir := getEnv("GIT_CLONE_DIR", "")
+
 	// Logging
 	logFile := getEnv("LOG_FILE", DefaultLogFile)
 	logLevel := getEnv("LOG_LEVEL", DefaultLogLevel)
 
 	// If everything went alright, return correct values
 	return &Config{
-		GitHubToken: token,
-		Port:        port,
-		GRPCAddress: grpcAddr,
-		LogFile:     logFile,
-		LogLevel:    logLevel,
+		GitHubTokens:    tokens,
+		GitHubApp:       app,
+		GitHubAPI:       githubAPI,
+		GitHubCABundle:  os.Getenv("GH_CA_BUNDLE"),
+		GitHubProxy:     os.Getenv("GH_PROXY"),
+		Enterprise:      enterprise,
+		GitLabURL:       gitlabURL,
+		GitLabToken:     os.Getenv("GITLAB_TOKEN"),
+		GiteaURL:        giteaURL,
+		GiteaToken:      os.Getenv("GITEA_TOKEN"),
+		Port:            port,
+		GRPCAddress:     grpcAddr,
+		Metrics:         metrics,
+		MetricsDatasets: getEnv("METRICS_DATASETS", DefaultMetricsDatasets),
+		Thresholds:      thresholds,
+		Workers:         workers,
+		StorePath:       storePath,
+		CacheDir:        cacheDir,
+		CloneDir:        cloneDir,
+		RecordDir:       recordDir,
+		ReplayDir:       replayDir,
+		ReplayAt:        replayAt,
+		LogFile:         logFile,
+		LogLevel:        logLevel,
 	}, nil
 }
 
+// loadThresholds reads the THRESHOLD_* variables, each within the range of its metric
+func loadThresholds() (metrics.Thresholds, error) {
+	var t metrics.Thresholds
+	for _, th := range []struct {
+		env    string
+		def    float64
+		target *float64
+	}{
+		{"THRESHOLD_GEODISPERSION", DefaultThresholdGeodispersion, &t.Geodispersion},
+		{"THRESHOLD_FORMALITY", DefaultThresholdFormality, &t.Formality},
+		{"THRESHOLD_LONGEVITY", DefaultThresholdLongevity, &t.Longevity},
+		{"THRESHOLD_COHESION", DefaultThresholdCohesion, &t.Cohesion},
+	} {
+		*th.target = th.def
+		raw := os.Getenv(th.env)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return metrics.Thresholds{}, fmt.Errorf("invalid %s value %q: must be a number", th.env, raw)
+		}
+		*th.target = v
+	}
+	if err := t.Validate(); err != nil {
+		return metrics.Thresholds{}, fmt.Errorf("invalid THRESHOLD_* configuration: %w", err)
+	}
+	return t, nil
+}
+
+// Enterprise configures a GitHub Enterprise Server instance
+type Enterprise struct {
+	URL        string // Instance or REST API URL, e.g. https://ghe.example.com
+	UploadURL  string // Defaults to <URL>/api/uploads/
+	GraphQLURL string // Defaults to <URL>/api/graphql
+	Tokens     []string
+	App        *GitHubApp
+	CABundle   string
+	Proxy      string
+}
+
+// loadEnterprise reads the GHE_* variables, returning nil when GHE_URL is not set
+func loadEnterprise() (*Enterprise, error) {
+	url := getEnv("GHE_URL", "")
+	if url == "" {
+		return nil, nil
+	}
+	tokens := splitList(getEnv("GHE_TOKENS", ""))
+	app, err := loadGitHubApp("GHE_APP_")
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 && app == nil {
+		return nil, fmt.Errorf("environment variable GHE_TOKENS or GHE_APP_ID is required when GHE_URL is set")
+	}
+	return &Enterprise{
+		URL:        url,
+		UploadURL:  os.Getenv("GHE_UPLOAD_URL"),
+		GraphQLURL: os.Getenv("GHE_GRAPHQL_URL"),
+		Tokens:     tokens,
+		App:        app,
+		CABundle:   os.Getenv("GHE_CA_BUNDLE"),
+		Proxy:      os.Getenv("GHE_PROXY"),
+	}, nil
+}
+
+// GitHubApp holds the credentials of a GitHub App installation
+type GitHubApp struct {
+	ID             int64
+	PrivateKey     []byte // PEM-encoded
+	InstallationID int64  // 0 when the app has a single installation to use
+}
+
+// loadGitHubApp reads <prefix>ID, <prefix>PRIVATE_KEY (the PEM itself) or
+// <prefix>PRIVATE_KEY_PATH and <prefix>INSTALLATION_ID, returning nil when no app ID is set
+func loadGitHubApp(prefix string) (*GitHubApp, error) {
+	rawID := getEnv(prefix+"ID", "")
+	if rawID == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("invalid %sID value %q: must be a positive integer", prefix, rawID)
+	}
+
+	key := []byte(getEnv(prefix+"PRIVATE_KEY", ""))
+	if path := getEnv(prefix+"PRIVATE_KEY_PATH", ""); len(key) == 0 && path != "" {
+		if key, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read %sPRIVATE_KEY_PATH: %w", prefix, err)
+		}
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("environment variable %sPRIVATE_KEY or %sPRIVATE_KEY_PATH is required with %sID", prefix, prefix, prefix)
+	}
+
+	var installationID int64
+	if raw := getEnv(prefix+"INSTALLATION_ID", ""); raw != "" {
+		if installationID, err = strconv.ParseInt(raw, 10, 64); err != nil || installationID <= 0 {
+			return nil, fmt.Errorf("invalid %sINSTALLATION_ID value %q: must be a positive integer", prefix, raw)
+		}
+	}
+
+	return &GitHubApp{ID: id, PrivateKey: key, InstallationID: installationID}, nil
+}
+
 // LoadLoggingConfig returns only logging-related values so the logger can be
 // initialized before validating required app config.
 func LoadLoggingConfig() (logFile, logLevel string) {
 	return getEnv("LOG_FILE", DefaultLogFile), getEnv("LOG_LEVEL", DefaultLogLevel)
 }
 
+// splitList splits a comma-separated value, dropping blanks and duplicates
+func splitList(value string) []string {
+	var items []string
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		items = append(items, item)
+	}
+	return items
+}
+
 // Utility function to set an environment variable or its default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
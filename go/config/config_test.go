@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    float64 // Geodispersion threshold
+		wantErr string
+	}{
+		{"defaults", nil, DefaultThresholdGeodispersion, ""},
+		{"geodispersion up to 2", map[string]string{"THRESHOLD_GEODISPERSION": "1.5"}, 1.5, ""},
+		{"geodispersion above 2", map[string]string{"THRESHOLD_GEODISPERSION": "2.5"}, 0, "geodispersion threshold must be between 0 and 2"},
+		{"formality above 1", map[string]string{"THRESHOLD_FORMALITY": "1.5"}, 0, "formality threshold must be between 0 and 1"},
+		{"not a number", map[string]string{"THRESHOLD_COHESION": "high"}, 0, `invalid THRESHOLD_COHESION value "high"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{"THRESHOLD_GEODISPERSION", "THRESHOLD_FORMALITY", "THRESHOLD_LONGEVITY", "THRESHOLD_COHESION"} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, err := loadThresholds()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Geodispersion != tt.want || got.Formality != DefaultThresholdFormality {
+				t.Errorf("thresholds = %+v, want geodispersion %g", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,4 +1,5 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
+// Package docs GENERATED BY SWAG; DO NOT EDIT
+// This file was generated by swaggo/swag
 package docs
 
 import "github.com/swaggo/swag"
@@ -15,6 +16,287 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/analyses/{owner}/{repo}": {
+            "get": {
+                "description": "Lists the stored analyses of a repository, newest first, without the repository snapshots.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analyses"
+                ],
+                "summary": "List past analyses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository owner",
+                        "name": "owner",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository name",
+                        "name": "repo",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.AnalysisSummary"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/analyses/{owner}/{repo}/{id}": {
+            "get": {
+                "description": "Returns a stored analysis with its repository snapshot, metrics and parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analyses"
+                ],
+                "summary": "Get a past analysis",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository owner",
+                        "name": "owner",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository name",
+                        "name": "repo",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Analysis ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Analysis"
+                        }
+                    },
+                    "404": {
+                        "description": "Analysis not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "analyses"
+                ],
+                "summary": "Delete a past analysis",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository owner",
+                        "name": "owner",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository name",
+                        "name": "repo",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Analysis ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": "Analysis not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/analyses/{owner}/{repo}/{id}/graph": {
+            "get": {
+                "description": "Exports who follows whom among the selected contributors as GraphML, GEXF or DOT. Nodes carry the name, company and location of contributors whose profile has them.",
+                "produces": [
+                    "text/xml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "analyses"
+                ],
+                "summary": "Export the follow graph of a past analysis",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Repository owner",
+                        "name": "owner",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository name",
+                        "name": "repo",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Repository host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Analysis ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "graphml (default), gexf or dot",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Graph file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Analysis not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/evolution": {
+            "post": {
+                "description": "Extracts a repository once, then computes formality, geodispersion, longevity and cohesion and classifies the community at the end of every month, quarter, half or year since its first commit, from the weekly contributor statistics, pull requests and contributors active by each date.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "repository"
+                ],
+                "summary": "Community evolution",
+                "parameters": [
+                    {
+                        "description": "Repository evolution request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.EvolutionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.EvolutionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.EvolutionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.EvolutionResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/extract": {
             "post": {
                 "description": "Extracts detailed information about a GitHub repository including commits, milestones, and contributors.",
@@ -67,6 +349,131 @@ const docTemplate = `{
                 }
             }
         },
+        "/extract/batch": {
+            "post": {
+                "description": "Queues the eligibility check and extraction of every repository of an uploaded CSV (multipart field \"file\" or text/csv body) or a JSON array of extraction requests, and returns the batch immediately. Poll GET /extract/batch/{id} for the results. CSV host, thresholds, contributor selection and snapshot date are read from the host, min_commits, days, min_active, selection, selection_n, selection_percentile, selection_days and as_of form or query values.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "repository"
+                ],
+                "summary": "Start a batch extraction",
+                "parameters": [
+                    {
+                        "description": "Repositories to extract",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.ExtractRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many pending jobs",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/extract/batch/{id}": {
+            "get": {
+                "description": "Returns the status of a batch extraction and the results of the repositories finished so far, in input order.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "repository"
+                ],
+                "summary": "Get batch status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a queued or running batch extraction; the repositories already finished keep their results.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "repository"
+                ],
+                "summary": "Cancel a batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Batch already finished",
+                        "schema": {
+                            "$ref": "#/definitions/server.BatchResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/health": {
             "get": {
                 "description": "Checks if the server is running and returns the number of cores.",
@@ -88,265 +495,1608 @@ const docTemplate = `{
                 }
             }
         },
-        "/remaining": {
-            "get": {
-                "description": "Gives the number of the remaining GitHub API requests available",
+        "/jobs": {
+            "post": {
+                "description": "Queues the eligibility check, extraction and metrics processing of a repository and returns the job immediately.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "remaining"
+                    "jobs"
+                ],
+                "summary": "Start a process job",
+                "parameters": [
+                    {
+                        "description": "Repository process request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ExtractRequest"
+                        }
+                    }
                 ],
-                "summary": "Get remaining requests",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/server.ExtractResponseLimits"
+                            "$ref": "#/definitions/server.Job"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/server.ExtractResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
-                    }
-                }
-            }
-        }
-    },
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Too many pending jobs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "Returns the status, stage, progress and, once completed, the result of a job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get job status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a queued or running job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel a job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Job already finished",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/events": {
+            "get": {
+                "description": "Streams the job progress as server-sent events. Each \"progress\" event carries a JobEvent; the final \"done\" event carries the finished job.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Stream job progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.JobEvent"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/process": {
+            "post": {
+                "description": "Extracts repository data, computes formality, geodispersion, longevity, cohesion, engagement and structure, classifies the community and breaks each metric down into its sub-components.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "repository"
+                ],
+                "summary": "Process repository metrics",
+                "parameters": [
+                    {
+                        "description": "Repository process request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.ExtractRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ProcessHandlerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ProcessHandlerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Repository not eligible",
+                        "schema": {
+                            "$ref": "#/definitions/server.ProcessHandlerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ProcessHandlerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/remaining": {
+            "get": {
+                "description": "Gives the number of the remaining GitHub API requests available, in total and per pooled token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "remaining"
+                ],
+                "summary": "Get remaining requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GitHub or GitHub Enterprise host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ExtractResponseLimits"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/server.ExtractResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Reports the response cache hits and misses and the time spent throttled by rate limits since startup.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "remaining"
+                ],
+                "summary": "Get client statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GitHub or GitHub Enterprise host, defaults to github.com",
+                        "name": "host",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown host",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
-        "models.CommitInfo": {
+        "github.CacheStats": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "hit_ratio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "description": "Revalidated with a 304 and served from disk",
+                    "type": "integer"
+                },
+                "misses": {
+                    "description": "Fetched in full from GitHub",
+                    "type": "integer"
+                },
+                "stored": {
+                    "description": "Responses written to the cache",
+                    "type": "integer"
+                }
+            }
+        },
+        "github.RateStatus": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "reset": {
+                    "type": "string"
+                }
+            }
+        },
+        "github.ThrottleStats": {
+            "type": "object",
+            "properties": {
+                "gave_up": {
+                    "description": "Throttled responses returned to the caller",
+                    "type": "integer"
+                },
+                "primary": {
+                    "description": "Responses rejected because the quota was exhausted",
+                    "type": "integer"
+                },
+                "retries": {
+                    "description": "Requests sent again after waiting",
+                    "type": "integer"
+                },
+                "secondary": {
+                    "description": "Secondary rate limit and abuse detection responses",
+                    "type": "integer"
+                },
+                "seconds": {
+                    "description": "Total time spent waiting",
+                    "type": "number"
+                }
+            }
+        },
+        "github.TokenStatus": {
+            "type": "object",
+            "properties": {
+                "core": {
+                    "$ref": "#/definitions/github.RateStatus"
+                },
+                "graphql": {
+                    "$ref": "#/definitions/github.RateStatus"
+                },
+                "search": {
+                    "$ref": "#/definitions/github.RateStatus"
+                },
+                "token": {
+                    "description": "Masked, only the last characters are shown",
+                    "type": "string"
+                }
+            }
+        },
+        "grpcclient.Breakdown": {
+            "type": "object",
+            "properties": {
+                "cohesion": {
+                    "$ref": "#/definitions/grpcclient.CohesionBreakdown"
+                },
+                "engagement": {
+                    "$ref": "#/definitions/grpcclient.EngagementBreakdown"
+                },
+                "formality": {
+                    "$ref": "#/definitions/grpcclient.FormalityBreakdown"
+                },
+                "geodispersion": {
+                    "$ref": "#/definitions/grpcclient.GeodispersionBreakdown"
+                },
+                "longevity": {
+                    "$ref": "#/definitions/grpcclient.LongevityBreakdown"
+                },
+                "structure": {
+                    "$ref": "#/definitions/grpcclient.StructureBreakdown"
+                }
+            }
+        },
+        "grpcclient.CohesionBreakdown": {
+            "type": "object",
+            "properties": {
+                "contributors": {
+                    "type": "integer"
+                },
+                "estimated_edges": {
+                    "type": "number"
+                },
+                "followers": {
+                    "description": "Sum of in-degrees, each capped at contributors - 1",
+                    "type": "integer"
+                },
+                "following": {
+                    "description": "Sum of out-degrees, each capped at contributors - 1",
+                    "type": "integer"
+                },
+                "possible_edges": {
+                    "type": "integer"
+                }
+            }
+        },
+        "grpcclient.EngagementBreakdown": {
+            "type": "object",
+            "properties": {
+                "appreciation": {
+                    "type": "number"
+                },
+                "intensity": {
+                    "type": "number"
+                },
+                "participants": {
+                    "description": "Contributors who opened or responded to a thread",
+                    "type": "integer"
+                },
+                "participation": {
+                    "type": "number"
+                },
+                "posts": {
+                    "description": "Threads and comments that can receive reactions",
+                    "type": "integer"
+                },
+                "reactions": {
+                    "type": "integer"
+                },
+                "responded_threads": {
+                    "type": "integer"
+                },
+                "responders": {
+                    "description": "Participants who responded to another's thread",
+                    "type": "integer"
+                },
+                "response_rate": {
+                    "type": "number"
+                },
+                "responses": {
+                    "description": "Responses to other participants' threads",
+                    "type": "integer"
+                },
+                "threads": {
+                    "type": "integer"
+                }
+            }
+        },
+        "grpcclient.FormalityBreakdown": {
+            "type": "object",
+            "properties": {
+                "max_score": {
+                    "description": "Sum of all weights",
+                    "type": "number"
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "present": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "Sum of the weights of the present flags",
+                    "type": "number"
+                }
+            }
+        },
+        "grpcclient.GeodispersionBreakdown": {
+            "type": "object",
+            "properties": {
+                "contributors": {
+                    "type": "integer"
+                },
+                "cultural": {
+                    "description": "Normalized cultural standard deviation",
+                    "type": "number"
+                },
+                "cultural_pairs": {
+                    "description": "Pairs whose countries both have Hofstede scores",
+                    "type": "integer"
+                },
+                "cultural_std_dev": {
+                    "type": "number"
+                },
+                "geographic": {
+                    "description": "Normalized geographic standard deviation",
+                    "type": "number"
+                },
+                "geographic_pairs": {
+                    "type": "integer"
+                },
+                "geographic_std_dev_km": {
+                    "type": "number"
+                },
+                "matched_locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/grpcclient.MatchedLocation"
+                    }
+                },
+                "unmatched": {
+                    "description": "Contributors without a location or with an unmatched one",
+                    "type": "integer"
+                }
+            }
+        },
+        "grpcclient.LongevityBreakdown": {
+            "type": "object",
+            "properties": {
+                "active_weeks": {
+                    "description": "Weeks with commits in the year before ReferenceDate",
+                    "type": "integer"
+                },
+                "closed_prs": {
+                    "description": "Merged PRs included",
+                    "type": "integer"
+                },
+                "commits": {
+                    "type": "integer"
+                },
+                "commits_last_year": {
+                    "type": "integer"
+                },
+                "committers": {
+                    "description": "Contributors with at least one commit",
+                    "type": "integer"
+                },
+                "contributor_retention": {
+                    "type": "number"
+                },
+                "contributors": {
+                    "type": "integer"
+                },
+                "development_distribution": {
+                    "type": "number"
+                },
+                "gini": {
+                    "type": "number"
+                },
+                "long_term_contributors": {
+                    "description": "Active for more than 365 days",
+                    "type": "integer"
+                },
+                "merged_prs": {
+                    "type": "integer"
+                },
+                "pr_acceptance_rate": {
+                    "type": "number"
+                },
+                "reference_date": {
+                    "description": "Latest commit, ISO 8601",
+                    "type": "string"
+                },
+                "technical_pulse": {
+                    "type": "number"
+                }
+            }
+        },
+        "grpcclient.MatchedLocation": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                }
+            }
+        },
+        "grpcclient.ProcessResult": {
+            "type": "object",
+            "properties": {
+                "breakdown": {
+                    "description": "Breakdown explains the metrics; nil when the processor did not provide it",
+                    "$ref": "#/definitions/grpcclient.Breakdown"
+                },
+                "cohesion": {
+                    "type": "number"
+                },
+                "engagement": {
+                    "type": "number"
+                },
+                "formality": {
+                    "type": "number"
+                },
+                "geodispersion": {
+                    "type": "number"
+                },
+                "longevity": {
+                    "type": "number"
+                },
+                "structure": {
+                    "type": "number"
+                }
+            }
+        },
+        "grpcclient.StructureBreakdown": {
+            "type": "object",
+            "properties": {
+                "clustering": {
+                    "type": "number"
+                },
+                "connected_triples": {
+                    "type": "integer"
+                },
+                "connectedness": {
+                    "type": "number"
+                },
+                "edges": {
+                    "description": "Pairs of participants that responded to each other",
+                    "type": "integer"
+                },
+                "largest_component": {
+                    "type": "integer"
+                },
+                "participants": {
+                    "type": "integer"
+                },
+                "triangles": {
+                    "type": "integer"
+                }
+            }
+        },
+        "metrics.DecisionStep": {
+            "type": "object",
+            "properties": {
+                "branch": {
+                    "description": "Where the level leads",
+                    "type": "string"
+                },
+                "level": {
+                    "description": "\"low\" or \"high\"",
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "metrics.Thresholds": {
+            "type": "object",
+            "properties": {
+                "cohesion": {
+                    "type": "number"
+                },
+                "formality": {
+                    "type": "number"
+                },
+                "geodispersion": {
+                    "type": "number"
+                },
+                "longevity": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CollaborationEdge": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "Commenter or reviewer",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Author of the threads",
+                    "type": "string"
+                },
+                "weight": {
+                    "description": "Number of responses",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContributorDetail": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string"
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "blog": {
+                    "type": "string"
+                },
+                "company": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error holds an error message when retrieval for this user failed",
+                    "type": "string"
+                },
+                "follower_following_ratio": {
+                    "description": "FollowerFollowingRatio is followers/following within the same repository community and is 0 when following is 0.",
+                    "type": "number"
+                },
+                "followers": {
+                    "description": "Followers counts how many contributors in the same extracted repository community follow this user.",
+                    "type": "integer"
+                },
+                "following": {
+                    "description": "Following counts how many contributors in the same extracted repository community this user follows.",
+                    "type": "integer"
+                },
+                "html_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "node_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContributorEngagement": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "description": "Comments on issue and pull request conversations",
+                    "type": "integer"
+                },
+                "discussion_comments": {
+                    "description": "Comments and replies in discussions",
+                    "type": "integer"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "reactions_received": {
+                    "description": "Reactions on the contributor's threads and comments",
+                    "type": "integer"
+                },
+                "review_comments": {
+                    "description": "Inline comments on pull request diffs",
+                    "type": "integer"
+                },
+                "reviews": {
+                    "description": "Pull request reviews",
+                    "type": "integer"
+                },
+                "threads": {
+                    "description": "Threads opened",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContributorSelection": {
+            "type": "object",
+            "properties": {
+                "logins": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "policy": {
+                    "$ref": "#/definitions/models.SelectionPolicy"
+                }
+            }
+        },
+        "models.ContributorStats": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "GitHub login",
+                    "type": "string"
+                },
+                "first_commit": {
+                    "description": "Date of first commit (derived from weeks)",
+                    "type": "string"
+                },
+                "last_commit": {
+                    "description": "Date of last commit (derived from weeks)",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total number of commits",
+                    "type": "integer"
+                },
+                "weeks": {
+                    "description": "Weekly activity",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Week"
+                    }
+                }
+            }
+        },
+        "models.Engagement": {
+            "type": "object",
+            "properties": {
+                "collaboration": {
+                    "description": "Who responded to whom",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollaborationEdge"
+                    }
+                },
+                "contributors": {
+                    "description": "Everyone who opened or responded to a thread",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContributorEngagement"
+                    }
+                },
+                "responded_threads": {
+                    "description": "Threads someone other than the author responded to",
+                    "type": "integer"
+                },
+                "threads": {
+                    "description": "Issues, pull requests and discussions inspected",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FollowEdge": {
+            "type": "object",
+            "properties": {
+                "followed": {
+                    "type": "string"
+                },
+                "follower": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FollowGraph": {
+            "type": "object",
+            "properties": {
+                "edges": {
+                    "description": "Follow relations between members",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FollowEdge"
+                    }
+                },
+                "members": {
+                    "description": "Logins whose following lists were read",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.MilestoneInfo": {
+            "type": "object",
+            "properties": {
+                "closed_at": {
+                    "description": "Nil while open, and on hosts that do not report it",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PullRequestInfo": {
+            "type": "object",
+            "properties": {
+                "closed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "merged_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RepositoryInfo": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "description": "Snapshot date the activity data is limited to; nil for the present",
+                    "type": "string"
+                },
+                "commits": {
+                    "type": "integer"
+                },
+                "contributor_stats": {
+                    "description": "Aggregated stats from stats/contributors",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContributorStats"
+                    }
+                },
+                "contributors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContributorDetail"
+                    }
+                },
+                "contributors_with_location_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "default_branch": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "engagement": {
+                    "description": "Discussion activity and collaboration graph",
+                    "$ref": "#/definitions/models.Engagement"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "follow_graph": {
+                    "description": "Who follows whom among the selected contributors",
+                    "$ref": "#/definitions/models.FollowGraph"
+                },
+                "forks": {
+                    "type": "integer"
+                },
+                "has_code_of_conduct": {
+                    "type": "boolean"
+                },
+                "has_contributing_guidelines": {
+                    "type": "boolean"
+                },
+                "has_description": {
+                    "type": "boolean"
+                },
+                "has_issues": {
+                    "type": "boolean"
+                },
+                "has_issues_template": {
+                    "type": "boolean"
+                },
+                "has_license": {
+                    "type": "boolean"
+                },
+                "has_milestones": {
+                    "type": "boolean"
+                },
+                "has_pull_request_template": {
+                    "type": "boolean"
+                },
+                "has_readme": {
+                    "type": "boolean"
+                },
+                "has_security_policy": {
+                    "type": "boolean"
+                },
+                "has_wiki": {
+                    "type": "boolean"
+                },
+                "has_wiki_page": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "license": {
+                    "type": "string"
+                },
+                "milestone_dates": {
+                    "description": "The milestones counted in Milestones, so snapshots can recount them",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MilestoneInfo"
+                    }
+                },
+                "milestones": {
+                    "type": "integer"
+                },
+                "non_anonymous_contributors_count": {
+                    "type": "integer"
+                },
+                "open_issues": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "pull_requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PullRequestInfo"
+                    }
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "selected_contributors_count": {
+                    "type": "integer"
+                },
+                "selection": {
+                    "description": "How the profiled contributors were chosen",
+                    "$ref": "#/definitions/models.ContributorSelection"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stars": {
+                    "type": "integer"
+                },
+                "total_contributors_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "watchers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SelectionPolicy": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Days also selects everyone who committed in the last Days days; 0 adds nobody.\nWith \"active\" they are the only contributors selected.",
+                    "type": "integer"
+                },
+                "n": {
+                    "description": "Contributors kept by \"top\"",
+                    "type": "integer"
+                },
+                "percentile": {
+                    "description": "Percentile of the contribution counts \"percentile\" keeps contributors at or above",
+                    "type": "number"
+                },
+                "strategy": {
+                    "description": "all, top, sqrt, percentile or active",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Week": {
+            "type": "object",
+            "properties": {
+                "additions": {
+                    "description": "Lines added",
+                    "type": "integer"
+                },
+                "commits": {
+                    "description": "Number of commits",
+                    "type": "integer"
+                },
+                "deletions": {
+                    "description": "Lines deleted",
+                    "type": "integer"
+                },
+                "week": {
+                    "description": "Unix timestamp for start of week",
+                    "type": "integer"
+                }
+            }
+        },
+        "server.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "done": {
+                    "description": "Repositories finished so far",
+                    "type": "integer"
+                },
+                "eligible": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.BatchResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.BatchResult": {
             "type": "object",
             "properties": {
-                "author_email": {
+                "done": {
+                    "description": "Whether the repository has been checked and extracted",
+                    "type": "boolean"
+                },
+                "eligible": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "host": {
                     "type": "string"
                 },
-                "author_name": {
+                "owner": {
                     "type": "string"
                 },
-                "date": {
+                "reason": {
+                    "description": "Why the repository is not eligible",
                     "type": "string"
                 },
-                "sha": {
+                "repo": {
                     "type": "string"
+                },
+                "repository": {
+                    "$ref": "#/definitions/models.RepositoryInfo"
                 }
             }
         },
-        "models.ContributorDetail": {
+        "server.EvolutionPoint": {
             "type": "object",
             "properties": {
-                "avatar_url": {
+                "as_of": {
                     "type": "string"
                 },
-                "bio": {
+                "category": {
+                    "description": "Null when Incomplete",
                     "type": "string"
                 },
-                "blog": {
-                    "type": "string"
+                "cohesion": {
+                    "type": "number"
                 },
-                "company": {
-                    "type": "string"
+                "commits": {
+                    "description": "Commits of the weekly statistics by AsOf",
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
+                "contributors": {
+                    "description": "Authors with commits by AsOf",
+                    "type": "integer"
                 },
-                "email": {
-                    "type": "string"
+                "formality": {
+                    "type": "number"
                 },
-                "error": {
-                    "description": "Error holds an error message when retrieval for this user failed",
-                    "type": "string"
+                "geodispersion": {
+                    "type": "number"
                 },
-                "html_url": {
+                "incomplete": {
+                    "description": "Incomplete is set when AsOf predates the oldest pull request of the extraction\nand the extraction stopped at its limit, so the pull requests of the point and the\nlongevity they weigh in are unknown",
+                    "type": "boolean"
+                },
+                "longevity": {
+                    "description": "Null when Incomplete",
+                    "type": "number"
+                },
+                "pull_requests": {
+                    "description": "Pull requests closed by AsOf",
+                    "type": "integer"
+                }
+            }
+        },
+        "server.EvolutionRequest": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "description": "AsOf extracts the repository as it was at that date: later commits, pull requests\nand milestones are left out and the eligibility and activity windows end there",
                     "type": "string"
                 },
-                "id": {
+                "days": {
                     "type": "integer"
                 },
-                "location": {
-                    "type": "string"
+                "engagement": {
+                    "description": "Engagement reads the recent issue, pull request and discussion threads the\nengagement and structure scores are computed from; they are 0 without it",
+                    "type": "boolean"
                 },
-                "login": {
+                "host": {
+                    "description": "Host the repository lives on, e.g. gitlab.com; defaults to github.com",
                     "type": "string"
                 },
-                "name": {
+                "interval": {
+                    "description": "Interval between two points: month, quarter (default), half or year",
                     "type": "string"
                 },
-                "node_id": {
-                    "type": "string"
+                "min_active": {
+                    "type": "integer"
                 },
-                "type": {
+                "min_commits": {
+                    "type": "integer"
+                },
+                "owner": {
                     "type": "string"
                 },
-                "updated_at": {
+                "repo": {
                     "type": "string"
+                },
+                "selection": {
+                    "description": "Selection chooses the contributors whose profiles are fetched; defaults to the top\nsqrt(total) contributors plus those active in the last 90 days",
+                    "$ref": "#/definitions/server.SelectionRequest"
+                },
+                "thresholds": {
+                    "description": "Thresholds overrides the deployment's community classification thresholds",
+                    "$ref": "#/definitions/server.ThresholdOverrides"
                 }
             }
         },
-        "models.PullRequestInfo": {
+        "server.EvolutionResponse": {
             "type": "object",
             "properties": {
-                "merged_at": {
+                "error": {
                     "type": "string"
                 },
-                "number": {
-                    "type": "integer"
+                "interval": {
+                    "type": "string"
                 },
-                "status": {
+                "owner": {
+                    "type": "string"
+                },
+                "points": {
+                    "description": "Oldest first; the last one is the extraction date",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.EvolutionPoint"
+                    }
+                },
+                "repo": {
                     "type": "string"
+                },
+                "simple_project": {
+                    "type": "boolean"
+                },
+                "thresholds": {
+                    "$ref": "#/definitions/metrics.Thresholds"
                 }
             }
         },
-        "models.RepositoryInfo": {
+        "server.ExtractRequest": {
             "type": "object",
             "properties": {
-                "commits": {
+                "as_of": {
+                    "description": "AsOf extracts the repository as it was at that date: later commits, pull requests\nand milestones are left out and the eligibility and activity windows end there",
+                    "type": "string"
+                },
+                "days": {
                     "type": "integer"
                 },
-                "commits_list": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CommitInfo"
-                    }
+                "engagement": {
+                    "description": "Engagement reads the recent issue, pull request and discussion threads the\nengagement and structure scores are computed from; they are 0 without it",
+                    "type": "boolean"
                 },
-                "contributors": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ContributorDetail"
-                    }
+                "host": {
+                    "description": "Host the repository lives on, e.g. gitlab.com; defaults to github.com",
+                    "type": "string"
                 },
-                "contributors_with_location_count": {
+                "min_active": {
                     "type": "integer"
                 },
-                "created_at": {
+                "min_commits": {
+                    "type": "integer"
+                },
+                "owner": {
                     "type": "string"
                 },
-                "default_branch": {
+                "repo": {
                     "type": "string"
                 },
-                "description": {
+                "selection": {
+                    "description": "Selection chooses the contributors whose profiles are fetched; defaults to the top\nsqrt(total) contributors plus those active in the last 90 days",
+                    "$ref": "#/definitions/server.SelectionRequest"
+                },
+                "thresholds": {
+                    "description": "Thresholds overrides the deployment's community classification thresholds",
+                    "$ref": "#/definitions/server.ThresholdOverrides"
+                }
+            }
+        },
+        "server.ExtractResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
+                "repository": {
+                    "$ref": "#/definitions/models.RepositoryInfo"
+                }
+            }
+        },
+        "server.ExtractResponseLimits": {
+            "type": "object",
+            "properties": {
                 "error": {
                     "type": "string"
                 },
-                "forks": {
+                "remaining": {
+                    "description": "Core requests left across all tokens",
                     "type": "integer"
                 },
-                "has_code_of_conduct": {
-                    "type": "boolean"
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github.TokenStatus"
+                    }
+                }
+            }
+        },
+        "server.Job": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
                 },
-                "has_contributing_guidelines": {
-                    "type": "boolean"
+                "error": {
+                    "type": "string"
                 },
-                "has_description": {
-                    "type": "boolean"
+                "finished_at": {
+                    "type": "string"
                 },
-                "has_issues": {
-                    "type": "boolean"
+                "host": {
+                    "type": "string"
                 },
-                "has_issues_template": {
-                    "type": "boolean"
+                "id": {
+                    "type": "string"
                 },
-                "has_license": {
-                    "type": "boolean"
+                "owner": {
+                    "type": "string"
                 },
-                "has_milestones": {
-                    "type": "boolean"
+                "progress": {
+                    "description": "Fraction of completed stages, from 0 to 1",
+                    "type": "number"
                 },
-                "has_pull_request_template": {
-                    "type": "boolean"
+                "repo": {
+                    "type": "string"
                 },
-                "has_readme": {
-                    "type": "boolean"
+                "result": {
+                    "$ref": "#/definitions/server.ProcessHandlerResponse"
                 },
-                "has_security_policy": {
-                    "type": "boolean"
+                "stage": {
+                    "type": "string"
                 },
-                "has_wiki": {
-                    "type": "boolean"
+                "started_at": {
+                    "type": "string"
                 },
-                "has_wiki_page": {
-                    "type": "boolean"
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.JobEvent": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "integer"
                 },
-                "language": {
+                "message": {
                     "type": "string"
                 },
-                "license": {
+                "stage": {
                     "type": "string"
                 },
-                "milestones": {
-                    "type": "integer"
+                "time": {
+                    "type": "string"
                 },
-                "non_anonymous_contributors_count": {
+                "total": {
                     "type": "integer"
+                }
+            }
+        },
+        "server.ProcessHandlerResponse": {
+            "type": "object",
+            "properties": {
+                "analysis_id": {
+                    "description": "ID of the stored analysis, see /analyses",
+                    "type": "string"
                 },
-                "open_issues": {
-                    "type": "integer"
+                "breakdown": {
+                    "description": "Breakdown holds the sub-components of the metrics and the inputs they used",
+                    "$ref": "#/definitions/grpcclient.Breakdown"
                 },
-                "owner": {
+                "category": {
                     "type": "string"
                 },
-                "pull_requests": {
+                "cohesion": {
+                    "type": "number"
+                },
+                "decision_path": {
+                    "description": "DecisionPath lists the decision tree nodes that led to Category",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.PullRequestInfo"
+                        "$ref": "#/definitions/metrics.DecisionStep"
                     }
                 },
-                "repo": {
+                "engagement": {
+                    "description": "Engagement and Structure are the original YOSHI dimensions the decision tree does not use",
+                    "type": "number"
+                },
+                "error": {
                     "type": "string"
                 },
-                "selected_contributors_count": {
-                    "type": "integer"
+                "formality": {
+                    "type": "number"
                 },
-                "size": {
-                    "type": "integer"
+                "geodispersion": {
+                    "type": "number"
                 },
-                "stars": {
+                "longevity": {
+                    "type": "number"
+                },
+                "simple_project": {
+                    "type": "boolean"
+                },
+                "structure": {
+                    "type": "number"
+                },
+                "thresholds": {
+                    "description": "Thresholds the repository was classified with",
+                    "$ref": "#/definitions/metrics.Thresholds"
+                }
+            }
+        },
+        "server.SelectionRequest": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Days also selects everyone who committed in the last Days days. Unset adds nobody,\nexcept for \"active\", whose window it is and which defaults to 90 days.",
                     "type": "integer"
                 },
-                "total_contributors_count": {
+                "n": {
+                    "description": "Contributors kept by \"top\"",
                     "type": "integer"
                 },
-                "updated_at": {
+                "percentile": {
+                    "description": "Percentile of the contribution counts \"percentile\" keeps contributors at or above",
+                    "type": "number"
+                },
+                "strategy": {
+                    "description": "all, top, sqrt, percentile or active",
                     "type": "string"
+                }
+            }
+        },
+        "server.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/github.CacheStats"
                 },
-                "watchers": {
-                    "type": "integer"
+                "throttled": {
+                    "$ref": "#/definitions/github.ThrottleStats"
                 }
             }
         },
-        "server.ExtractRequest": {
+        "server.ThresholdOverrides": {
             "type": "object",
             "properties": {
-                "owner": {
-                    "type": "string"
+                "cohesion": {
+                    "type": "number"
                 },
-                "repo": {
-                    "type": "string"
+                "formality": {
+                    "type": "number"
+                },
+                "geodispersion": {
+                    "type": "number"
+                },
+                "longevity": {
+                    "type": "number"
                 }
             }
         },
-        "server.ExtractResponse": {
+        "store.Analysis": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
+                    "type": "string"
+                },
+                "host": {
+                    "description": "Empty for github.com",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "description": "Request parameters the analysis was run with",
+                    "type": "object"
+                },
+                "repo": {
                     "type": "string"
                 },
                 "repository": {
                     "$ref": "#/definitions/models.RepositoryInfo"
+                },
+                "result": {
+                    "$ref": "#/definitions/grpcclient.ProcessResult"
                 }
             }
         },
-        "server.ExtractResponseLimits": {
+        "store.AnalysisSummary": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
                     "type": "string"
                 },
-                "remaining": {
-                    "type": "integer"
+                "host": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "type": "object"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/grpcclient.ProcessResult"
                 }
             }
         }
@@ -363,8 +2113,6 @@ var SwaggerInfo = &swag.Spec{
 	Description:      "This is a server to extract detailed information from GitHub repositories.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
-	// LeftDelim:        "{{",
-	// RightDelim:       "}}",
 }
 
 func init() {
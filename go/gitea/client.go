@@ -0,0 +1,296 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// DefaultURL is the address of Codeberg, the largest public Forgejo instance
+const DefaultURL = "https://codeberg.org"
+
+const (
+	// pageLimit is the default maximum page size of Gitea and Forgejo instances
+	pageLimit = 50
+	// maxStatsCommits caps the commit scan contributors and statistics are derived from
+	maxStatsCommits = 10000
+	// scanTTL is how long a commit scan is reused by the calls of one extraction
+	scanTTL = 5 * time.Minute
+)
+
+// Client extracts repositories from a Gitea or Forgejo instance through its REST API (v1).
+// Neither has a contributor statistics endpoint, so contributors and their weekly
+// activity are derived from a scan of the most recent maxStatsCommits commits.
+type Client struct {
+	baseURL *url.URL
+	token   string
+	http    *http.Client
+	logger  *logrus.Logger
+
+	mu    sync.Mutex
+	scans map[string]*commitScan
+}
+
+// commitScan is a commit listing shared by the concurrent calls of an extraction
+type commitScan struct {
+	done    chan struct{}
+	at      time.Time
+	commits []source.Commit
+	err     error
+}
+
+// NewClient creates a client for the instance at baseURL (DefaultURL when empty).
+// The token is optional; without it only public repositories can be read.
+func NewClient(baseURL, token string, logger *logrus.Logger) (*Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Gitea URL %q", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		token:   token,
+		http: &http.Client{
+			Timeout: 120 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConnsPerHost: 20,
+			},
+		},
+		logger: logger,
+		scans:  make(map[string]*commitScan),
+	}, nil
+}
+
+// Host returns the hostname of the instance
+func (c *Client) Host() string {
+	return c.baseURL.Host
+}
+
+// get fetches an API path (relative to /api/v1) into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) (http.Header, error) {
+	u := c.baseURL.String() + "/api/v1/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "token "+c.token)
+	}
+	return source.GetJSON(ctx, c.http, u, header, out)
+}
+
+// repoPath returns the API path of owner/repo
+func repoPath(owner, repo string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// totalCount reads the X-Total-Count header set on paginated responses
+func totalCount(header http.Header, fallback int) int {
+	if n, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		return n
+	}
+	return fallback
+}
+
+type repository struct {
+	Description   string    `json:"description"`
+	Stars         int       `json:"stars_count"`
+	Forks         int       `json:"forks_count"`
+	Watchers      int       `json:"watchers_count"`
+	OpenIssues    int       `json:"open_issues_count"`
+	Size          int       `json:"size"` // KB
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	DefaultBranch string    `json:"default_branch"`
+	HasIssues     bool      `json:"has_issues"`
+	HasWiki       bool      `json:"has_wiki"`
+	Licenses      []string  `json:"licenses"` // Detected since Gitea 1.22
+}
+
+type contentEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// GetRepositoryInfo fetches a repository and its community in the shape GitHub extractions have
+func (c *Client) GetRepositoryInfo(ctx context.Context, owner, repo string) models.RepositoryInfo {
+	info := models.RepositoryInfo{
+		Owner: owner,
+		Repo:  repo,
+	}
+
+	var r repository
+	if _, err := c.get(ctx, repoPath(owner, repo), nil, &r); err != nil {
+		info.Error = fmt.Sprintf("Failed to fetch repository: %v", err)
+		return info
+	}
+
+	info.Description = r.Description
+	info.Stars = r.Stars
+	info.Forks = r.Forks
+	info.Watchers = r.Watchers
+	info.OpenIssues = r.OpenIssues
+	info.Size = r.Size
+	info.CreatedAt = r.CreatedAt
+	info.UpdatedAt = r.UpdatedAt
+	info.DefaultBranch = r.DefaultBranch
+	info.HasIssues = r.HasIssues
+	info.HasWiki = r.HasWiki
+	if len(r.Licenses) > 0 {
+		info.License = r.Licenses[0]
+	}
+	if err := source.MarkAsOf(ctx, &info); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.HasDescription = info.Description != ""
+	info.HasLicense = info.License != ""
+	info.HasWikiPage = info.HasWiki
+	info.Language = c.primaryLanguage(ctx, owner, repo)
+	c.checkCommunityFiles(ctx, owner, repo, &info)
+
+	commits, err := c.commitCount(ctx, owner, repo)
+	if err != nil {
+		info.Error = fmt.Sprintf("Failed to fetch commits: %v", err)
+	} else {
+		info.Commits = commits
+	}
+
+	milestones, err := c.milestones(ctx, owner, repo)
+	if err != nil {
+		if info.Error == "" {
+			info.Error = fmt.Sprintf("Failed to fetch milestones: %v", err)
+		}
+	} else {
+		source.SetMilestones(&info, milestones)
+	}
+
+	source.FillCommunity(ctx, c, &info)
+	return info
+}
+
+// primaryLanguage returns the language with the most bytes in the repository
+func (c *Client) primaryLanguage(ctx context.Context, owner, repo string) string {
+	var languages map[string]int64
+	if _, err := c.get(ctx, repoPath(owner, repo)+"/languages", nil, &languages); err != nil {
+		return ""
+	}
+	best, size := "", int64(-1)
+	for lang, n := range languages {
+		if n > size || (n == size && lang < best) {
+			best, size = lang, n
+		}
+	}
+	return best
+}
+
+// checkCommunityFiles looks for community files in the repository root and in the
+// .gitea and .github directories, which both hosts read templates from
+func (c *Client) checkCommunityFiles(ctx context.Context, owner, repo string, info *models.RepositoryInfo) {
+	names := make(map[string]bool)
+	for _, dir := range []string{"", ".gitea", ".github", ".forgejo"} {
+		path := repoPath(owner, repo) + "/contents"
+		if dir != "" {
+			path += "/" + dir
+		}
+		var entries []contentEntry
+		if _, err := c.get(ctx, path, nil, &entries); err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := strings.ToLower(e.Name)
+			if i := strings.LastIndex(name, "."); i > 0 && e.Type == "file" {
+				name = name[:i]
+			}
+			names[name] = true
+		}
+	}
+
+	info.HasReadme = names["readme"]
+	info.HasContributingGuidelines = names["contributing"]
+	info.HasCodeOfConduct = names["code_of_conduct"] || names["code-of-conduct"]
+	info.HasSecurityPolicy = names["security"]
+	info.HasIssuesTemplate = names["issue_template"]
+	info.HasPullRequestTemplate = names["pull_request_template"]
+}
+
+// commitCount returns the number of commits on the default branch, up to the snapshot
+// date attached to ctx
+func (c *Client) commitCount(ctx context.Context, owner, repo string) (int, error) {
+	var commits []struct{}
+	query := url.Values{"limit": {"1"}, "stat": {"false"}, "verification": {"false"}, "files": {"false"}}
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		query.Set("until", asOf.Format(time.RFC3339))
+	}
+	header, err := c.get(ctx, repoPath(owner, repo)+"/commits", query, &commits)
+	if err != nil {
+		return 0, err
+	}
+	return totalCount(header, len(commits)), nil
+}
+
+// milestones lists the milestones, open and closed, created by the snapshot date attached
+// to ctx. Milestones cannot be listed up to a date, so every page is read.
+func (c *Client) milestones(ctx context.Context, owner, repo string) ([]models.MilestoneInfo, error) {
+	list := []models.MilestoneInfo{}
+	for page := 1; ; page++ {
+		var milestones []struct {
+			CreatedAt time.Time  `json:"created_at"`
+			ClosedAt  *time.Time `json:"closed_at"`
+		}
+		query := url.Values{"state": {"all"}, "limit": {strconv.Itoa(pageLimit)}, "page": {strconv.Itoa(page)}}
+		if _, err := c.get(ctx, repoPath(owner, repo)+"/milestones", query, &milestones); err != nil {
+			return nil, err
+		}
+		for _, m := range milestones {
+			list = append(list, models.MilestoneInfo{CreatedAt: m.CreatedAt, ClosedAt: m.ClosedAt})
+		}
+		if len(milestones) < pageLimit {
+			return source.MilestonesAsOf(list, source.AsOfFrom(ctx)), nil
+		}
+	}
+}
+
+// CheckRepoEligibility applies the commit count and active contributor prechecks
+func (c *Client) CheckRepoEligibility(ctx context.Context, owner, repo string, minCommits, days, minActive int) (bool, string, error) {
+	commitCount, err := c.commitCount(ctx, owner, repo)
+	if err != nil {
+		return false, "", fmt.Errorf("error counting commits: %w", err)
+	}
+	if commitCount < minCommits {
+		return false, fmt.Sprintf("repository has fewer than %d commits (found %d)", minCommits, commitCount), nil
+	}
+
+	since := source.Now(ctx).AddDate(0, 0, -days)
+	seen := make(map[string]struct{})
+	err = c.listCommits(ctx, owner, repo, since, false, func(cm source.Commit) bool {
+		seen[cm.Identity()] = struct{}{}
+		return len(seen) < minActive
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("error checking active contributors: %w", err)
+	}
+	if len(seen) < minActive {
+		return false, fmt.Sprintf("fewer than %d active contributors in the last %d days (found %d)", minActive, days, len(seen)), nil
+	}
+
+	return true, "", nil
+}
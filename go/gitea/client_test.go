@@ -0,0 +1,298 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github-extractor/source"
+)
+
+type fakeCommit struct {
+	email, login string
+	date         time.Time
+}
+
+// fakeGitea serves the parts of the Gitea API v1 the client reads for acme/widget. Pages
+// hold at most pageLimit items, as on a default instance.
+type fakeGitea struct {
+	commits       []fakeCommit // Newest first
+	ignoreFilters bool         // Ignore since and until, as instances before 1.19 do
+
+	mu          sync.Mutex
+	commitPages int // Commit listings served, not counting the commit count
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const repo = "/api/v1/repos/acme/widget"
+	query := r.URL.Query()
+	switch r.URL.Path {
+	case repo:
+		writeJSON(w, map[string]interface{}{
+			"description":       "Widgets",
+			"stars_count":       42,
+			"forks_count":       7,
+			"watchers_count":    5,
+			"open_issues_count": 3,
+			"created_at":        "2020-01-01T00:00:00Z",
+			"updated_at":        "2025-01-01T00:00:00Z",
+			"default_branch":    "main",
+			"has_issues":        true,
+			"licenses":          []string{"MIT"},
+		})
+
+	case repo + "/languages":
+		writeJSON(w, map[string]int64{"Go": 8000, "Shell": 2000})
+
+	case repo + "/contents":
+		writeJSON(w, []contentEntry{{"README.md", "file"}, {".gitea", "dir"}, {".github", "dir"}})
+	case repo + "/contents/.gitea":
+		writeJSON(w, []contentEntry{{"issue_template", "dir"}})
+	case repo + "/contents/.github":
+		writeJSON(w, []contentEntry{{"PULL_REQUEST_TEMPLATE.md", "file"}})
+	case repo + "/contents/.forgejo":
+		writeJSON(w, []contentEntry{{"CODE_OF_CONDUCT.md", "file"}})
+
+	case repo + "/commits":
+		var items []interface{}
+		for _, c := range f.commits {
+			if !f.ignoreFilters {
+				if until, err := time.Parse(time.RFC3339, query.Get("until")); err == nil && c.date.After(until) {
+					continue
+				}
+				if since, err := time.Parse(time.RFC3339, query.Get("since")); err == nil && c.date.Before(since) {
+					continue
+				}
+			}
+			item := map[string]interface{}{
+				"commit": map[string]interface{}{
+					"author": map[string]string{"name": strings.Split(c.email, "@")[0], "email": c.email, "date": c.date.Format(time.RFC3339)},
+				},
+				"stats": map[string]int{"additions": 10, "deletions": 2},
+			}
+			if c.login != "" {
+				item["author"] = map[string]string{"login": c.login}
+			}
+			items = append(items, item)
+		}
+		if query.Get("limit") != "1" {
+			f.mu.Lock()
+			f.commitPages++
+			f.mu.Unlock()
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+		page(w, r, items)
+
+	case repo + "/milestones":
+		if query.Get("state") != "all" {
+			http.Error(w, "want state=all", http.StatusBadRequest)
+			return
+		}
+		page(w, r, []interface{}{
+			map[string]interface{}{"created_at": "2021-01-01T00:00:00Z", "closed_at": "2021-06-01T00:00:00Z"},
+			map[string]interface{}{"created_at": "2022-01-01T00:00:00Z", "closed_at": "2023-06-01T00:00:00Z"},
+			map[string]interface{}{"created_at": "2023-01-01T00:00:00Z"},
+		})
+
+	case repo + "/pulls":
+		// Older instances list oldest first
+		page(w, r, []interface{}{
+			map[string]interface{}{"number": 1, "created_at": "2024-01-01T00:00:00Z", "closed_at": "2024-01-03T00:00:00Z", "merged_at": "2024-01-03T00:00:00Z"},
+			map[string]interface{}{"number": 2, "created_at": "2024-02-01T00:00:00Z", "closed_at": "2024-02-02T00:00:00Z"},
+		})
+
+	case "/api/v1/users/alice":
+		writeJSON(w, map[string]interface{}{"id": 1, "login": "alice", "full_name": "Alice", "location": "Lisbon"})
+	case "/api/v1/users/alice/following":
+		page(w, r, []interface{}{})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// page writes the page of items the page and limit parameters ask for
+func page(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	limit := pageLimit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n < limit {
+		limit = n
+	}
+	p, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	p = max(p, 1)
+	start := min((p-1)*limit, len(items))
+	end := min(start+limit, len(items))
+	if items == nil {
+		items = []interface{}{}
+	}
+	writeJSON(w, items[start:end])
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// fakeCommits returns n commits by alice, whose email is linked to an account, then m by
+// bob, whose email is not; one a day going back from newest
+func fakeCommits(newest time.Time, n, m int) []fakeCommit {
+	var commits []fakeCommit
+	for i := 0; i < n+m; i++ {
+		c := fakeCommit{email: "alice@example.com", login: "alice", date: newest.AddDate(0, 0, -i)}
+		if i >= n {
+			c = fakeCommit{email: "bob@example.com", date: newest.AddDate(0, 0, -i)}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+func newTestClient(t *testing.T, fake *fakeGitea) *Client {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := NewClient(srv.URL, "", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestTotalCount(t *testing.T) {
+	tests := []struct {
+		header   string
+		fallback int
+		want     int
+	}{
+		{"120", 1, 120},
+		{"", 1, 1},
+		{"many", 0, 0},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.header != "" {
+			header.Set("X-Total-Count", tt.header)
+		}
+		if got := totalCount(header, tt.fallback); got != tt.want {
+			t.Errorf("totalCount(%q, %d) = %d, want %d", tt.header, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestGetRepositoryInfo(t *testing.T) {
+	// 60 commits span a full page and a short one
+	fake := &fakeGitea{commits: fakeCommits(time.Now().UTC().Truncate(time.Hour), 40, 20)}
+	c := newTestClient(t, fake)
+
+	info := c.GetRepositoryInfo(context.Background(), "acme", "widget")
+	if info.Error != "" {
+		t.Fatalf("error = %s", info.Error)
+	}
+
+	if info.Description != "Widgets" || info.Language != "Go" || info.License != "MIT" || info.Stars != 42 || info.Watchers != 5 {
+		t.Errorf("details = %q %q %q %d stars %d watchers", info.Description, info.Language, info.License, info.Stars, info.Watchers)
+	}
+	if !info.HasReadme || !info.HasIssuesTemplate || !info.HasPullRequestTemplate || !info.HasCodeOfConduct || info.HasContributingGuidelines || info.HasSecurityPolicy {
+		t.Errorf("community files: readme %v, issue template %v, pull request template %v, code of conduct %v, contributing %v, security %v; want the first four",
+			info.HasReadme, info.HasIssuesTemplate, info.HasPullRequestTemplate, info.HasCodeOfConduct, info.HasContributingGuidelines, info.HasSecurityPolicy)
+	}
+	if info.Commits != 60 {
+		t.Errorf("commits = %d, want the X-Total-Count of 60", info.Commits)
+	}
+	if info.Milestones != 3 {
+		t.Errorf("milestones = %d, want 3", info.Milestones)
+	}
+
+	// The history is scanned once, over both pages, and shared by the contributor calls
+	if fake.commitPages != 4 {
+		t.Errorf("%d commit pages listed, want 2 for the history and 2 for the recent authors", fake.commitPages)
+	}
+	if info.TotalContributorsCount != 2 {
+		t.Errorf("contributors = %d, want 2", info.TotalContributorsCount)
+	}
+	commits := 0
+	for _, s := range info.ContributorStats {
+		commits += s.Total
+	}
+	if commits != 60 {
+		t.Errorf("contributor stats count %d commits, want 60", commits)
+	}
+
+	var prs []string
+	for _, pr := range info.PullRequests {
+		prs = append(prs, fmt.Sprintf("%d:%s", pr.Number, pr.Status))
+	}
+	if got := strings.Join(prs, " "); got != "2:closed 1:merged" {
+		t.Errorf("pull requests %s, want 2:closed 1:merged, newest first", got)
+	}
+
+	if len(info.Contributors) != 1 || info.Contributors[0].Login != "alice" || info.Contributors[0].Location != "Lisbon" {
+		t.Errorf("contributors = %+v, want alice's profile", info.Contributors)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	asOf := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	// 5 commits by the snapshot date, after 3 newer ones
+	fake := &fakeGitea{commits: fakeCommits(asOf.AddDate(0, 0, 3), 6, 2)}
+	c := newTestClient(t, fake)
+	ctx := source.WithAsOf(context.Background(), asOf)
+
+	n, err := c.commitCount(ctx, "acme", "widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("commits = %d, want 5 until the snapshot date", n)
+	}
+
+	milestones, err := c.milestones(ctx, "acme", "widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(milestones) != 2 {
+		t.Fatalf("%d milestones, want the 2 created by the snapshot date", len(milestones))
+	}
+	if milestones[0].ClosedAt == nil || milestones[1].ClosedAt != nil {
+		t.Errorf("milestones closed at %v and %v, want the second reopened", milestones[0].ClosedAt, milestones[1].ClosedAt)
+	}
+}
+
+// Instances that ignore since and until still list only the commits in the window
+func TestListCommitsFiltersIgnored(t *testing.T) {
+	asOf := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	since := asOf.AddDate(0, 0, -4)
+	commits := fakeCommits(asOf.AddDate(0, 0, 3), 10, 100)
+
+	for _, ignore := range []bool{false, true} {
+		fake := &fakeGitea{commits: commits, ignoreFilters: ignore}
+		c := newTestClient(t, fake)
+
+		var dates []time.Time
+		err := c.listCommits(source.WithAsOf(context.Background(), asOf), "acme", "widget", since, false, func(cm source.Commit) bool {
+			dates = append(dates, cm.Date)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dates) != 5 || !dates[0].Equal(asOf) || !dates[4].Equal(since) {
+			t.Errorf("ignore filters %v: listed %v, want the 5 days from %v to %v", ignore, dates, since, asOf)
+		}
+		// The scan stops at the first commit older than since instead of paging on
+		if fake.commitPages != 1 {
+			t.Errorf("ignore filters %v: %d pages listed, want 1", ignore, fake.commitPages)
+		}
+	}
+}
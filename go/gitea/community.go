@@ -0,0 +1,352 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+type commit struct {
+	Commit struct {
+		Author struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"` // Account linked to the author email, if any
+	Stats *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+type user struct {
+	ID          int64     `json:"id"`
+	Login       string    `json:"login"`
+	FullName    string    `json:"full_name"`
+	Email       string    `json:"email"`
+	AvatarURL   string    `json:"avatar_url"`
+	HTMLURL     string    `json:"html_url"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+}
+
+// listCommits pages through the commits newer than since (all when zero) and up to the
+// snapshot date attached to ctx, newest first, calling fn for each until it returns false
+// or maxStatsCommits commits have been seen
+func (c *Client) listCommits(ctx context.Context, owner, repo string, since time.Time, withStats bool, fn func(source.Commit) bool) error {
+	until := source.AsOfFrom(ctx)
+	seen := 0
+	for page := 1; ; page++ {
+		query := url.Values{
+			"limit":        {strconv.Itoa(pageLimit)},
+			"page":         {strconv.Itoa(page)},
+			"stat":         {strconv.FormatBool(withStats)},
+			"verification": {"false"},
+			"files":        {"false"},
+		}
+		if !since.IsZero() {
+			query.Set("since", since.UTC().Format(time.RFC3339))
+		}
+		if !until.IsZero() {
+			query.Set("until", until.Format(time.RFC3339))
+		}
+
+		var commits []commit
+		if _, err := c.get(ctx, repoPath(owner, repo)+"/commits", query, &commits); err != nil {
+			return err
+		}
+		for _, cm := range commits {
+			sc := source.Commit{
+				Email: cm.Commit.Author.Email,
+				Name:  cm.Commit.Author.Name,
+				Date:  cm.Commit.Author.Date,
+			}
+			if cm.Author != nil {
+				sc.Login = cm.Author.Login
+			}
+			if cm.Stats != nil {
+				sc.Additions = cm.Stats.Additions
+				sc.Deletions = cm.Stats.Deletions
+			}
+			// Older instances ignore since and until, so stop at the first older commit
+			// and skip the newer ones
+			if !since.IsZero() && sc.Date.Before(since) {
+				return nil
+			}
+			if !until.IsZero() && sc.Date.After(until) {
+				continue
+			}
+			seen++
+			if !fn(sc) || seen >= maxStatsCommits {
+				return nil
+			}
+		}
+		if len(commits) < pageLimit {
+			return nil
+		}
+	}
+}
+
+// history returns the most recent maxStatsCommits commits with their line stats. The scan
+// is shared by the concurrent calls of an extraction and reused for scanTTL.
+func (c *Client) history(ctx context.Context, owner, repo string) ([]source.Commit, error) {
+	key := strings.ToLower(owner + "/" + repo)
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		key += "@" + asOf.Format(time.RFC3339)
+	}
+
+	c.mu.Lock()
+	for k, s := range c.scans {
+		if !s.at.IsZero() && time.Since(s.at) > scanTTL {
+			delete(c.scans, k)
+		}
+	}
+	scan, ok := c.scans[key]
+	if !ok {
+		scan = &commitScan{done: make(chan struct{})}
+		c.scans[key] = scan
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-scan.done:
+			return scan.commits, scan.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	scan.err = c.listCommits(ctx, owner, repo, time.Time{}, true, func(cm source.Commit) bool {
+		scan.commits = append(scan.commits, cm)
+		return true
+	})
+
+	c.mu.Lock()
+	if scan.err != nil {
+		// Failed scans are not reused
+		delete(c.scans, key)
+	}
+	scan.at = time.Now()
+	c.mu.Unlock()
+	close(scan.done)
+
+	return scan.commits, scan.err
+}
+
+// Contributors returns the commit authors ordered by commit count, identified by their
+// login when the author email is linked to an account and by email otherwise
+func (c *Client) Contributors(ctx context.Context, owner, repo string) ([]source.Contributor, int, int, error) {
+	commits, err := c.history(ctx, owner, repo)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	summary := source.SummarizeCommits(commits)
+	return summary.Contributors, len(summary.Contributors), summary.NonAnonymous, nil
+}
+
+// RecentContributors returns the authors that committed in the last days days
+func (c *Client) RecentContributors(ctx context.Context, owner, repo string, days int) ([]string, error) {
+	since := source.Now(ctx).AddDate(0, 0, -days)
+	var commits []source.Commit
+	err := c.listCommits(ctx, owner, repo, since, false, func(cm source.Commit) bool {
+		commits = append(commits, cm)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return source.RecentAuthors(commits, since), nil
+}
+
+// ContributorStats derives weekly activity from the most recent maxStatsCommits commits
+func (c *Client) ContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
+	commits, err := c.history(ctx, owner, repo)
+	if err != nil {
+		return []models.ContributorStats{}, err
+	}
+	return source.SummarizeCommits(commits).Stats, nil
+}
+
+// PullRequests returns up to max closed pull requests, newest first, leaving out those
+// closed after the snapshot date attached to ctx
+func (c *Client) PullRequests(ctx context.Context, owner, repo string, max int) ([]models.PullRequestInfo, error) {
+	type pullRequest struct {
+		Number    int        `json:"number"`
+		CreatedAt *time.Time `json:"created_at"`
+		ClosedAt  *time.Time `json:"closed_at"`
+		MergedAt  *time.Time `json:"merged_at"`
+	}
+
+	asOf := source.AsOfFrom(ctx)
+	var prs []models.PullRequestInfo
+	for page := 1; ; page++ {
+		query := url.Values{"state": {"closed"}, "limit": {strconv.Itoa(pageLimit)}, "page": {strconv.Itoa(page)}}
+		var batch []pullRequest
+		if _, err := c.get(ctx, repoPath(owner, repo)+"/pulls", query, &batch); err != nil {
+			return nil, err
+		}
+
+		for _, p := range batch {
+			pr := models.PullRequestInfo{
+				Number:    p.Number,
+				CreatedAt: p.CreatedAt,
+				ClosedAt:  p.ClosedAt,
+				MergedAt:  p.MergedAt,
+			}
+			if pr.ClosedAt == nil {
+				pr.ClosedAt = pr.MergedAt
+			}
+			if !source.ClosedBy(pr, asOf) {
+				continue
+			}
+			switch {
+			case pr.ClosedAt == nil:
+				pr.Status = "open"
+			case pr.MergedAt != nil:
+				pr.Status = "merged"
+			default:
+				pr.Status = "closed"
+			}
+			prs = append(prs, pr)
+		}
+		if len(batch) < pageLimit || (max > 0 && len(prs) >= max) {
+			break
+		}
+	}
+
+	// The listing order depends on the instance version; return the newest first
+	sort.SliceStable(prs, func(i, j int) bool {
+		a, b := prs[i].CreatedAt, prs[j].CreatedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+	if max > 0 && len(prs) > max {
+		prs = prs[:max]
+	}
+	return prs, nil
+}
+
+// ContributorDetails fetches the profile of each contributor. Authors identified by an
+// email that is not linked to an account come back with an Error.
+func (c *Client) ContributorDetails(ctx context.Context, logins []string) ([]models.ContributorDetail, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+
+	results := make([]models.ContributorDetail, len(logins))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for i, login := range logins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, login string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if strings.Contains(login, "@") {
+				results[idx] = models.ContributorDetail{Login: login, Error: "commit email is not linked to an account"}
+				return
+			}
+
+			var u user
+			if _, err := c.get(ctx, "users/"+url.PathEscape(login), nil, &u); err != nil {
+				c.logger.Debugf("Failed to fetch Gitea user %s: %v", login, err)
+				results[idx] = models.ContributorDetail{Login: login, Error: err.Error()}
+				return
+			}
+
+			results[idx] = models.ContributorDetail{
+				Login:     u.Login,
+				ID:        u.ID,
+				AvatarURL: u.AvatarURL,
+				HTMLURL:   u.HTMLURL,
+				Type:      "User",
+				Name:      u.FullName,
+				Blog:      u.Website,
+				Location:  u.Location,
+				Email:     u.Email,
+				Bio:       u.Description,
+				CreatedAt: u.Created,
+			}
+		}(i, login)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Error == "" {
+			return results, nil
+		}
+	}
+	return results, fmt.Errorf("all contributor detail requests failed. First error: %s", results[0].Error)
+}
+
+// FollowGraph returns the follow edges between the given users
+func (c *Client) FollowGraph(ctx context.Context, logins []string) (models.FollowGraph, error) {
+	lists := make(map[string][]string, len(logins))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+	failed := 0
+
+	for _, login := range logins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(login string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			following, err := c.following(ctx, login)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				c.logger.Debugf("Failed to fetch following list for %s: %v", login, err)
+				failed++
+				return
+			}
+			lists[login] = following
+		}(login)
+	}
+	wg.Wait()
+
+	graph := source.FollowEdges(logins, lists)
+	if failed == len(logins) && len(logins) > 0 {
+		return graph, fmt.Errorf("failed to fetch community following lists for all contributors")
+	}
+	return graph, nil
+}
+
+// following returns the logins a user follows
+func (c *Client) following(ctx context.Context, login string) ([]string, error) {
+	var logins []string
+	for page := 1; ; page++ {
+		query := url.Values{"limit": {strconv.Itoa(pageLimit)}, "page": {strconv.Itoa(page)}}
+		var users []user
+		if _, err := c.get(ctx, "users/"+url.PathEscape(login)+"/following", query, &users); err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			logins = append(logins, u.Login)
+		}
+		if len(users) < pageLimit {
+			return logins, nil
+		}
+	}
+}
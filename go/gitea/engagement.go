@@ -0,0 +1,141 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github-extractor/source"
+)
+
+type issue struct {
+	Number      int       `json:"number"`
+	User        *user     `json:"user"`
+	Comments    int       `json:"comments"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	PullRequest *struct{} `json:"pull_request"` // Set on pull requests
+}
+
+type comment struct {
+	User *user `json:"user"`
+}
+
+type review struct {
+	User          *user  `json:"user"`
+	State         string `json:"state"`
+	CommentsCount int    `json:"comments_count"`
+}
+
+// login returns the login of u, or "" for deleted accounts
+func (u *user) login() string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+// Threads returns up to max of the most recently updated issues and pull requests with
+// their first source.MaxResponses comments and reviews, five threads at a time. Every
+// inline comment of a review counts as a review comment. Reactions are not read.
+func (c *Client) Threads(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	var issues []issue
+	for page := 1; len(issues) < max; page++ {
+		query := url.Values{"state": {"all"}, "limit": {strconv.Itoa(pageLimit)}, "page": {strconv.Itoa(page)}}
+		var batch []issue
+		if _, err := c.get(ctx, repoPath(owner, repo)+"/issues", query, &batch); err != nil {
+			return nil, err
+		}
+		issues = append(issues, batch...)
+		if len(batch) < pageLimit {
+			break
+		}
+	}
+
+	// The listing order depends on the instance version; keep the most recently updated
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].UpdatedAt.After(issues[j].UpdatedAt) })
+	if len(issues) > max {
+		issues = issues[:max]
+	}
+
+	threads := make([]source.Thread, len(issues))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, 5)
+	failed := 0
+	var firstErr error
+
+	for i, is := range issues {
+		threads[i].Author = is.User.login()
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, is issue) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			responses, err := c.threadResponses(ctx, owner, repo, is)
+			if err != nil {
+				c.logger.Debugf("Failed to fetch the responses to %s/%s#%d: %v", owner, repo, is.Number, err)
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			threads[idx].Responses = responses
+		}(i, is)
+	}
+	wg.Wait()
+
+	if failed == len(issues) && len(issues) > 0 {
+		return nil, fmt.Errorf("all thread response requests failed. First error: %v", firstErr)
+	}
+	return threads, nil
+}
+
+// threadResponses reads the comments of an issue or pull request and the reviews of a
+// pull request
+func (c *Client) threadResponses(ctx context.Context, owner, repo string, is issue) ([]source.Response, error) {
+	var responses []source.Response
+	path := fmt.Sprintf("%s/issues/%d/comments", repoPath(owner, repo), is.Number)
+
+	if is.Comments > 0 {
+		var comments []comment
+		if _, err := c.get(ctx, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		if len(comments) > source.MaxResponses {
+			comments = comments[:source.MaxResponses]
+		}
+		for _, cm := range comments {
+			responses = append(responses, source.Response{Kind: source.ResponseComment, Author: cm.User.login()})
+		}
+	}
+	if is.PullRequest == nil {
+		return responses, nil
+	}
+
+	var reviews []review
+	path = fmt.Sprintf("%s/pulls/%d/reviews", repoPath(owner, repo), is.Number)
+	query := url.Values{"limit": {strconv.Itoa(pageLimit)}, "page": {"1"}}
+	if _, err := c.get(ctx, path, query, &reviews); err != nil {
+		return nil, err
+	}
+	for _, r := range reviews {
+		// Pending reviews are drafts nobody else sees yet
+		if r.State == "PENDING" {
+			continue
+		}
+		author := r.User.login()
+		responses = append(responses, source.Response{Kind: source.ResponseReview, Author: author})
+		for i := 0; i < r.CommentsCount; i++ {
+			responses = append(responses, source.Response{Kind: source.ResponseReviewComment, Author: author})
+		}
+	}
+	return responses, nil
+}
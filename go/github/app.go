@@ -0,0 +1,210 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is how long an app JWT is valid; GitHub accepts at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appClockSkew backdates the JWT issue time to tolerate clock drift
+	appClockSkew = 60 * time.Second
+	// tokenRefreshMargin is how long before expiry an installation token is replaced
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// AppCredentials authenticate the client as a GitHub App installation
+type AppCredentials struct {
+	AppID      int64
+	PrivateKey []byte // PEM-encoded RSA key, as downloaded from the app settings
+	// InstallationID selects the installation to act as; when 0 the app must have
+	// exactly one installation, which is then used
+	InstallationID int64
+}
+
+// appInstallation mints installation access tokens for a GitHub App and keeps one
+// valid, refreshing it shortly before it expires
+type appInstallation struct {
+	appID   int64
+	key     *rsa.PrivateKey
+	baseURL string // REST endpoint, with a trailing slash
+	http    *http.Client
+
+	mu             sync.Mutex
+	installationID int64
+	token          string
+	expires        time.Time
+}
+
+// newAppInstallation parses the app private key
+func newAppInstallation(creds AppCredentials, baseURL string, client *http.Client) (*appInstallation, error) {
+	if creds.AppID <= 0 {
+		return nil, fmt.Errorf("invalid GitHub App ID %d", creds.AppID)
+	}
+	key, err := parsePrivateKey(creds.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &appInstallation{
+		appID:          creds.AppID,
+		key:            key,
+		baseURL:        baseURL,
+		http:           client,
+		installationID: creds.InstallationID,
+	}, nil
+}
+
+// parsePrivateKey decodes a PKCS#1 or PKCS#8 RSA private key
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// name identifies the installation in token statuses and errors
+func (a *appInstallation) name() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.nameLocked()
+}
+
+func (a *appInstallation) nameLocked() string {
+	if a.installationID == 0 {
+		return fmt.Sprintf("app %d", a.appID)
+	}
+	return fmt.Sprintf("app %d installation %d", a.appID, a.installationID)
+}
+
+// accessToken returns a valid installation token, minting a new one when the current
+// token is missing or about to expire
+func (a *appInstallation) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Until(a.expires) > tokenRefreshMargin {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	if a.installationID == 0 {
+		if a.installationID, err = a.findInstallation(ctx, jwt); err != nil {
+			return "", err
+		}
+	}
+
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	path := "app/installations/" + strconv.FormatInt(a.installationID, 10) + "/access_tokens"
+	if err := a.call(ctx, http.MethodPost, path, jwt, &out); err != nil {
+		return "", fmt.Errorf("create installation token for %s: %w", a.nameLocked(), err)
+	}
+	a.token, a.expires = out.Token, out.ExpiresAt
+	return a.token, nil
+}
+
+// findInstallation returns the ID of the app's only installation
+func (a *appInstallation) findInstallation(ctx context.Context, jwt string) (int64, error) {
+	var installations []struct {
+		ID      int64 `json:"id"`
+		Account struct {
+			Login string `json:"login"`
+		} `json:"account"`
+	}
+	if err := a.call(ctx, http.MethodGet, "app/installations?per_page=100", jwt, &installations); err != nil {
+		return 0, fmt.Errorf("list installations of app %d: %w", a.appID, err)
+	}
+
+	switch len(installations) {
+	case 0:
+		return 0, fmt.Errorf("app %d is not installed anywhere", a.appID)
+	case 1:
+		return installations[0].ID, nil
+	default:
+		accounts := make([]string, len(installations))
+		for i, inst := range installations {
+			accounts[i] = fmt.Sprintf("%s (%d)", inst.Account.Login, inst.ID)
+		}
+		return 0, fmt.Errorf("app %d has %d installations, choose one of %s", a.appID, len(installations), strings.Join(accounts, ", "))
+	}
+}
+
+// call sends an app-authenticated request and decodes the JSON response into out
+func (a *appInstallation) call(ctx context.Context, method, path, jwt string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jwt returns the RS256-signed JSON Web Token the app authenticates with
+func (a *appInstallation) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
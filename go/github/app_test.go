@@ -0,0 +1,196 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeApp serves the GitHub App endpoints, accepting only JWTs signed with key for app 42
+type fakeApp struct {
+	key           *rsa.PublicKey
+	installations []int64
+
+	mu     sync.Mutex
+	minted int
+	lists  int
+}
+
+func (f *fakeApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r.Header.Get("Authorization")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/app/installations":
+		f.lists++
+		var out []map[string]interface{}
+		for _, id := range f.installations {
+			out = append(out, map[string]interface{}{"id": id, "account": map[string]string{"login": fmt.Sprintf("org%d", id)}})
+		}
+		json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/app/installations/") && strings.HasSuffix(r.URL.Path, "/access_tokens"):
+		f.minted++
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/app/installations/"), "/access_tokens")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("ghs_%s_%d", id, f.minted),
+			"expires_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// verify checks the bearer JWT the way GitHub does: an RS256 signature by the app key,
+// the app ID as issuer and a lifetime of at most 10 minutes
+func (f *fakeApp) verify(authorization string) error {
+	jwt, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return fmt.Errorf("no bearer token in %q", authorization)
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed JWT")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("bad signature: %w", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	switch {
+	case claims.Iss != "42":
+		return fmt.Errorf("issuer %q", claims.Iss)
+	case claims.Iat > now || claims.Exp <= now:
+		return fmt.Errorf("JWT not valid now")
+	case claims.Exp-claims.Iat > int64((10 * time.Minute).Seconds()):
+		return fmt.Errorf("JWT lifetime over 10 minutes")
+	}
+	return nil
+}
+
+// newTestApp starts a fake app server and returns an installation client for app 42
+func newTestApp(t *testing.T, installationID int64, installations ...int64) (*appInstallation, *fakeApp) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeApp{key: &key.PublicKey, installations: installations}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	app, err := newAppInstallation(AppCredentials{AppID: 42, PrivateKey: pemKey, InstallationID: installationID}, srv.URL+"/", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app, fake
+}
+
+func TestAppInstallationToken(t *testing.T) {
+	app, fake := newTestApp(t, 0, 7)
+	ctx := context.Background()
+
+	token, err := app.accessToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ghs_7_1" {
+		t.Errorf("token = %q, want one minted for the only installation", token)
+	}
+	if name := app.name(); name != "app 42 installation 7" {
+		t.Errorf("name = %q", name)
+	}
+
+	// A token far from expiry is reused
+	if token, err = app.accessToken(ctx); err != nil || token != "ghs_7_1" {
+		t.Errorf("second token = %q, %v, want ghs_7_1 reused", token, err)
+	}
+
+	// A token about to expire is replaced, without listing the installations again
+	app.mu.Lock()
+	app.expires = time.Now().Add(tokenRefreshMargin - time.Second)
+	app.mu.Unlock()
+	if token, err = app.accessToken(ctx); err != nil || token != "ghs_7_2" {
+		t.Errorf("token near expiry = %q, %v, want ghs_7_2", token, err)
+	}
+
+	if fake.minted != 2 || fake.lists != 1 {
+		t.Errorf("%d tokens minted after %d installation listings, want 2 after 1", fake.minted, fake.lists)
+	}
+}
+
+func TestAppInstallationChoice(t *testing.T) {
+	tests := []struct {
+		name           string
+		installationID int64
+		installations  []int64
+		want           string // Token or error substring
+	}{
+		{"configured installation", 9, []int64{7, 9}, "ghs_9_1"},
+		{"not installed", 0, nil, "not installed anywhere"},
+		{"several installations", 0, []int64{7, 9}, "choose one of org7 (7), org9 (9)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, fake := newTestApp(t, tt.installationID, tt.installations...)
+			token, err := app.accessToken(context.Background())
+			got := token
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("accessToken = %q, want %q", got, tt.want)
+			}
+			if tt.installationID != 0 && fake.lists != 0 {
+				t.Error("installations listed although one was configured")
+			}
+		})
+	}
+}
+
+func TestAppRejectedKey(t *testing.T) {
+	app, _ := newTestApp(t, 7)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.key = other
+
+	if _, err := app.accessToken(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want the 401 of the exchange", err)
+	}
+}
@@ -0,0 +1,357 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/source"
+)
+
+// archiveMaxAge is how long a recording keeps being added to before recording the same
+// repository again starts a new recording next to it
+const archiveMaxAge = 24 * time.Hour
+
+// recordingLayout names the directory of a recording after its RecordedAt time, so the
+// recordings of a repository sort by age
+const recordingLayout = "20060102T150405Z"
+
+// ErrNotArchived is returned in replay mode for requests the archive has no response to
+var ErrNotArchived = errors.New("request not in archive")
+
+// archiveManifest describes an archive; it is stored next to the recorded exchanges
+type archiveManifest struct {
+	Host       string    `json:"host"`
+	Owner      string    `json:"owner"`
+	Repo       string    `json:"repo"`
+	RecordedAt time.Time `json:"recorded_at"` // Clock the extraction's time windows were computed from
+}
+
+// archiveEntry is one recorded request and the response the client received for it
+type archiveEntry struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Accept      string      `json:"accept,omitempty"`
+	RequestBody []byte      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// archive holds the API exchanges of the extractions of one repository. Exchanges are
+// keyed by request, so a request made again (such as a contributor stats poll that got
+// a 202 first) keeps the last response.
+type archive struct {
+	dir      string
+	manifest archiveManifest
+}
+
+type archiveKey struct{}
+
+// withArchive returns a copy of ctx whose requests are recorded into or replayed from a
+func withArchive(ctx context.Context, a *archive) context.Context {
+	return context.WithValue(ctx, archiveKey{}, a)
+}
+
+// archiveFrom returns the archive attached to ctx, or nil
+func archiveFrom(ctx context.Context) *archive {
+	a, _ := ctx.Value(archiveKey{}).(*archive)
+	return a
+}
+
+// archiver opens the per-repository archives under a directory, for recording or for
+// replay. Every recording of a repository has its own directory, laid out as
+// dir/host/owner/repo/<RecordedAt>/, and recordings are never deleted.
+type archiver struct {
+	dir      string
+	host     string
+	replay   bool
+	replayAt time.Time // Replay the latest recording made by then; zero for the latest
+
+	mu   sync.Mutex
+	open map[string]*archive
+}
+
+func newArchiver(dir, host string, replay bool, replayAt time.Time) *archiver {
+	return &archiver{dir: dir, host: host, replay: replay, replayAt: replayAt, open: make(map[string]*archive)}
+}
+
+// archive returns the archive of owner/repo. When recording, a recording is added to while
+// it is younger than archiveMaxAge, and a new one is started next to it after that; when
+// replaying, the recording chosen by replayAt must already exist.
+func (r *archiver) archive(owner, repo string) (*archive, error) {
+	if err := checkRepoPath(owner, repo); err != nil {
+		return nil, err
+	}
+	owner, repo = strings.ToLower(owner), strings.ToLower(repo)
+	key := owner + "/" + repo
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a, ok := r.open[key]; ok && (r.replay || time.Since(a.manifest.RecordedAt) < archiveMaxAge) {
+		return a, nil
+	}
+
+	repoDir := filepath.Join(r.dir, r.host, owner, repo)
+	var a *archive
+	if r.replay {
+		var err error
+		if a, err = r.recording(repoDir); err != nil {
+			return nil, fmt.Errorf("no archive of %s in %s: %w", key, r.dir, err)
+		}
+	} else {
+		recordedAt := time.Now().UTC().Truncate(time.Second)
+		a = &archive{
+			dir:      filepath.Join(repoDir, recordedAt.Format(recordingLayout)),
+			manifest: archiveManifest{Host: r.host, Owner: owner, Repo: repo, RecordedAt: recordedAt},
+		}
+		if err := os.MkdirAll(a.dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create archive of %s: %w", key, err)
+		}
+		data, err := json.MarshalIndent(a.manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(a.dir, "manifest.json"), data, 0o644); err != nil {
+			return nil, fmt.Errorf("write archive manifest of %s: %w", key, err)
+		}
+	}
+
+	r.open[key] = a
+	return a, nil
+}
+
+// recording returns the recording of a repository to replay, among the <RecordedAt>
+// directories of repoDir: the latest one made at or before replayAt, or the latest one
+// when replayAt is zero.
+func (r *archiver) recording(repoDir string) (*archive, error) {
+	entries, err := os.ReadDir(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *archive
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(repoDir, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		a := &archive{dir: dir}
+		if err := json.Unmarshal(data, &a.manifest); err != nil {
+			return nil, fmt.Errorf("read archive manifest in %s: %w", dir, err)
+		}
+		if !r.replayAt.IsZero() && a.manifest.RecordedAt.After(r.replayAt) {
+			continue
+		}
+		if latest == nil || a.manifest.RecordedAt.After(latest.manifest.RecordedAt) {
+			latest = a
+		}
+	}
+	if latest == nil {
+		if !r.replayAt.IsZero() {
+			return nil, fmt.Errorf("no recording made by %s", r.replayAt.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("no recording")
+	}
+	return latest, nil
+}
+
+// entryPath maps a request to its file in the archive. The key covers the method, URL,
+// Accept header and body, since GraphQL queries are all POSTs to the same URL.
+func (a *archive) entryPath(method, url, accept string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", method, url, accept)
+	h.Write(body)
+	return filepath.Join(a.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// save writes an entry atomically
+func (a *archive) save(entry *archiveEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := a.entryPath(entry.Method, entry.URL, entry.Accept, entry.RequestBody)
+	tmp, err := os.CreateTemp(a.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return errors.Join(writeErr, closeErr)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// load reads the entry recorded for a request
+func (a *archive) load(method, url, accept string, body []byte) (*archiveEntry, error) {
+	data, err := os.ReadFile(a.entryPath(method, url, accept, body))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotArchived, method, url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry archiveEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("read archive entry for %s %s: %w", method, url, err)
+	}
+	return &entry, nil
+}
+
+// requestBody returns the body of req without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// recordTransport saves every exchange made on behalf of an archived extraction, after
+// retries and caching, so the archive holds exactly what the client saw. Requests
+// without an archive in their context pass through unrecorded.
+type recordTransport struct {
+	transport http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a := archiveFrom(req.Context())
+	if a == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = a.save(&archiveEntry{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Accept:      req.Header.Get("Accept"),
+		RequestBody: reqBody,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header.Clone(),
+		Body:        body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("record %s %s: %w", req.Method, req.URL, err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers requests from the archive attached to their context and
+// never touches the network
+type replayTransport struct{}
+
+func (replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a := archiveFrom(req.Context())
+	if a == nil {
+		return nil, fmt.Errorf("%w: %s %s is not part of an archived extraction", ErrNotArchived, req.Method, req.URL)
+	}
+
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := a.load(req.Method, req.URL.String(), req.Header.Get("Accept"), reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	header := entry.Header.Clone()
+	header.Set("X-From-Archive", "1")
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}, nil
+}
+
+// archived attaches the archive of owner/repo to ctx when the client records or replays.
+// Calls nested in an archived extraction keep the archive they already have.
+func (c *Client) archived(ctx context.Context, owner, repo string) (context.Context, error) {
+	if c.archiver == nil || archiveFrom(ctx) != nil {
+		return ctx, nil
+	}
+	a, err := c.archiver.archive(owner, repo)
+	if err != nil {
+		return ctx, err
+	}
+	return withArchive(ctx, a), nil
+}
+
+// now is the clock time windows are computed from: the snapshot date attached to ctx with
+// source.WithAsOf, else the recording time of the archive attached to ctx, so a replay
+// asks for the same windows, else the current time
+func (c *Client) now(ctx context.Context) time.Time {
+	if t := source.AsOfFrom(ctx); !t.IsZero() {
+		return t
+	}
+	if a := archiveFrom(ctx); a != nil {
+		return a.manifest.RecordedAt
+	}
+	return time.Now()
+}
+
+// Replaying reports whether the client serves every request from recorded archives
+func (c *Client) Replaying() bool {
+	return c.archiver != nil && c.archiver.replay
+}
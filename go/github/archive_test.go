@@ -0,0 +1,68 @@
+package github
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeRecording stores an empty recording of acme/widget made at t in dir
+func writeRecording(t *testing.T, dir string, at time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(archiveManifest{Host: "github.com", Owner: "acme", Repo: "widget", RecordedAt: at})
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Recording again keeps the earlier recordings, and a replay picks one by time
+func TestArchiveKeepsEveryRecording(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "github.com", "acme", "widget")
+	first := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeRecording(t, filepath.Join(repoDir, first.Format(recordingLayout)), first)
+	writeRecording(t, filepath.Join(repoDir, older.Format(recordingLayout)), older)
+
+	recorded, err := newArchiver(dir, "github.com", false, time.Time{}).archive("Acme", "Widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorded.save(&archiveEntry{Method: "GET", URL: "https://api.github.com/repos/acme/widget", StatusCode: 200}); err != nil {
+		t.Fatal(err)
+	}
+	for _, earlier := range []time.Time{first, older} {
+		kept := filepath.Join(repoDir, earlier.Format(recordingLayout), "manifest.json")
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("earlier recording lost: %v", err)
+		}
+	}
+
+	tests := []struct {
+		replayAt time.Time
+		want     time.Time
+	}{
+		{time.Time{}, recorded.manifest.RecordedAt},
+		{older, older},
+		{older.AddDate(0, 1, 0), older},
+		{older.AddDate(0, 0, -1), first},
+	}
+	for _, tt := range tests {
+		a, err := newArchiver(dir, "github.com", true, tt.replayAt).archive("acme", "widget")
+		if err != nil {
+			t.Fatalf("replay at %v: %v", tt.replayAt, err)
+		}
+		if !a.manifest.RecordedAt.Equal(tt.want) {
+			t.Errorf("replay at %v: recording of %v, want %v", tt.replayAt, a.manifest.RecordedAt, tt.want)
+		}
+	}
+
+	if _, err := newArchiver(dir, "github.com", true, first.AddDate(0, 0, -1)).archive("acme", "widget"); err == nil {
+		t.Error("replay before the first recording found one")
+	}
+}
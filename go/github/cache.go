@@ -0,0 +1,178 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// CacheStats reports how effective the response cache has been since startup
+type CacheStats struct {
+	Enabled  bool    `json:"enabled"`
+	Hits     int64   `json:"hits"`   // Revalidated with a 304 and served from disk
+	Misses   int64   `json:"misses"` // Fetched in full from GitHub
+	Stored   int64   `json:"stored"` // Responses written to the cache
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// cacheEntry is a cached response as stored on disk
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// cacheTransport keeps successful GET responses on disk and revalidates them with
+// If-None-Match / If-Modified-Since. GitHub answers unchanged resources with a
+// 304, which does not count against the rate limit.
+type cacheTransport struct {
+	transport http.RoundTripper
+	dir       string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	stored atomic.Int64
+}
+
+// newCacheTransport creates a caching transport storing its entries under dir
+func newCacheTransport(transport http.RoundTripper, dir string) (*cacheTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache directory %s: %w", dir, err)
+	}
+	return &cacheTransport{transport: transport, dir: dir}, nil
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	path := t.entryPath(req)
+	entry := t.load(path)
+	if entry != nil {
+		// Clone the request to avoid modifying the original
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		t.hits.Add(1)
+		resp.Body.Close()
+		return entry.response(req, resp), nil
+	}
+	t.misses.Add(1)
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.save(path, &cacheEntry{URL: req.URL.String(), Header: resp.Header.Clone(), Body: body})
+	return resp, nil
+}
+
+// Stats returns the cache counters
+func (t *cacheTransport) Stats() CacheStats {
+	stats := CacheStats{
+		Enabled: true,
+		Hits:    t.hits.Load(),
+		Misses:  t.misses.Load(),
+		Stored:  t.stored.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// entryPath maps a request to its cache file. The key covers the URL and the
+// Accept header, since GitHub varies the representation on both.
+func (t *cacheTransport) entryPath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(t.dir, key[:2], key+".json")
+}
+
+// load reads a cache entry, returning nil when it is missing or unreadable
+func (t *cacheTransport) load(path string) *cacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// save writes a cache entry atomically; failures only cost a future cache hit
+func (t *cacheTransport) save(path string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	t.stored.Add(1)
+}
+
+// response rebuilds a 200 response from the entry. Rate limit headers come from
+// the fresh 304 so quota tracking stays accurate.
+func (e *cacheEntry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := e.Header.Clone()
+	for name, values := range notModified.Header {
+		if strings.HasPrefix(name, "X-Ratelimit-") {
+			header[name] = values
+		}
+	}
+	header.Set("X-From-Cache", "1")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,235 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// etagServer serves body with an ETag and answers matching If-None-Match headers with a
+// 304. It counts the requests it received.
+func etagServer(t *testing.T, body string) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("X-RateLimit-Remaining", "4998")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func get(t *testing.T, rt http.RoundTripper, ctx context.Context, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestCacheRevalidatesWithETag(t *testing.T) {
+	srv, requests := etagServer(t, `{"name":"widget"}`)
+	cache, err := newCacheTransport(http.DefaultTransport, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, body := get(t, cache, context.Background(), srv.URL+"/repos/acme/widget")
+	if first.StatusCode != http.StatusOK || body != `{"name":"widget"}` {
+		t.Fatalf("first response = %d %q", first.StatusCode, body)
+	}
+	if first.Header.Get("X-From-Cache") != "" {
+		t.Error("first response marked as coming from the cache")
+	}
+
+	second, body := get(t, cache, context.Background(), srv.URL+"/repos/acme/widget")
+	if second.StatusCode != http.StatusOK || body != `{"name":"widget"}` {
+		t.Fatalf("revalidated response = %d %q, want the cached 200", second.StatusCode, body)
+	}
+	if second.Header.Get("X-From-Cache") != "1" {
+		t.Error("revalidated response not marked as coming from the cache")
+	}
+	if got := second.Header.Get("X-RateLimit-Remaining"); got != "4998" {
+		t.Errorf("X-RateLimit-Remaining = %q, want the 304's 4998", got)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("server saw %d requests, want 2", requests.Load())
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Stored != 1 || stats.HitRatio != 0.5 {
+		t.Errorf("stats = %+v, want 1 hit, 1 miss, 1 stored", stats)
+	}
+}
+
+func TestCacheRevalidatesWithLastModified(t *testing.T) {
+	const modified = "Mon, 02 Jan 2006 15:04:05 GMT"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == modified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", modified)
+		io.WriteString(w, "body")
+	}))
+	defer srv.Close()
+
+	cache, err := newCacheTransport(http.DefaultTransport, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, cache, context.Background(), srv.URL)
+	resp, body := get(t, cache, context.Background(), srv.URL)
+	if resp.StatusCode != http.StatusOK || body != "body" || resp.Header.Get("X-From-Cache") != "1" {
+		t.Errorf("got %d %q from cache %q, want the cached body", resp.StatusCode, body, resp.Header.Get("X-From-Cache"))
+	}
+}
+
+func TestCacheSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		header  http.Header // Request headers
+		respond func(w http.ResponseWriter)
+	}{
+		{
+			name:   "non-GET request",
+			method: http.MethodPost,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("ETag", `"v1"`)
+				io.WriteString(w, "body")
+			},
+		},
+		{
+			name:   "range request",
+			method: http.MethodGet,
+			header: http.Header{"Range": {"bytes=0-1"}},
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("ETag", `"v1"`)
+				io.WriteString(w, "body")
+			},
+		},
+		{
+			name:   "no validator",
+			method: http.MethodGet,
+			respond: func(w http.ResponseWriter) {
+				io.WriteString(w, "body")
+			},
+		},
+		{
+			name:   "non-200 response",
+			method: http.MethodGet,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("ETag", `"v1"`)
+				w.WriteHeader(http.StatusAccepted)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditional atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+					conditional.Add(1)
+				}
+				tt.respond(w)
+			}))
+			defer srv.Close()
+
+			cache, err := newCacheTransport(http.DefaultTransport, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader(""))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for name, values := range tt.header {
+					req.Header[name] = values
+				}
+				resp, err := cache.RoundTrip(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.Header.Get("X-From-Cache") != "" {
+					t.Errorf("request %d served from the cache", i+1)
+				}
+			}
+			if conditional.Load() != 0 {
+				t.Errorf("%d conditional requests sent, want none", conditional.Load())
+			}
+			if stats := cache.Stats(); stats.Stored != 0 || stats.Hits != 0 {
+				t.Errorf("stats = %+v, want nothing stored or hit", stats)
+			}
+		})
+	}
+}
+
+// A recording made through the cache holds the full cached body, not the 304, so the
+// replay does not depend on the cache directory
+func TestCacheRecordAndReplay(t *testing.T) {
+	srv, requests := etagServer(t, `{"name":"widget"}`)
+	cache, err := newCacheTransport(http.DefaultTransport, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := srv.URL + "/repos/acme/widget"
+
+	// Warm the cache outside any archive
+	get(t, cache, context.Background(), url)
+
+	dir := t.TempDir()
+	recorder := newArchiver(dir, "github.com", false, time.Time{})
+	a, err := recorder.archive("acme", "widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, body := get(t, &recordTransport{transport: cache}, withArchive(context.Background(), a), url)
+	if resp.Header.Get("X-From-Cache") != "1" || body != `{"name":"widget"}` {
+		t.Fatalf("recorded response = %q from cache %q, want a cache hit", body, resp.Header.Get("X-From-Cache"))
+	}
+
+	replayer := newArchiver(dir, "github.com", true, time.Time{})
+	a, err = replayer.archive("acme", "widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := requests.Load()
+	resp, body = get(t, replayTransport{}, withArchive(context.Background(), a), url)
+	if resp.StatusCode != http.StatusOK || body != `{"name":"widget"}` {
+		t.Errorf("replayed response = %d %q, want the cached 200", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-From-Archive") != "1" {
+		t.Error("replayed response not marked as coming from the archive")
+	}
+	if requests.Load() != seen {
+		t.Error("replay reached the server")
+	}
+}
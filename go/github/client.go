@@ -4,46 +4,127 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	gith "github.com/google/go-github/v57/github"
 	"github.com/sirupsen/logrus"
 
 	"github-extractor/models"
+	"github-extractor/source"
 )
 
 // Client wraps the GitHub API client
 type Client struct {
-	client *gith.Client
-	ctx    context.Context
-	token  string
-	logger *logrus.Logger
+	client     *gith.Client
+	host       string
+	graphqlURL string
+	useGraphQL bool
+	cloneDir   string
+	pool       *tokenPool
+	retry      *retryTransport
+	cache      *cacheTransport
+	archiver   *archiver // Nil unless recording or replaying
+	logger     *logrus.Logger
 }
 
+// Options configures a Client
+type Options struct {
+	Tokens   []string // Pooled tokens; requests use the one with the most remaining quota
+	CacheDir string   // Directory for cached API responses; empty disables caching
+	// API selects how pull requests, contributor profiles and follow edges are fetched:
+	// APIREST (default) or APIGraphQL, which batches them into far fewer requests.
+	API string
+	// CloneDir, when set, enables clone mode: repositories are cloned (or fetched again)
+	// under this directory, and commits, contributor statistics and community files are
+	// read from the clone instead of the API.
+	CloneDir string
+
+	// BaseURL is the REST endpoint of a GitHub Enterprise Server instance, such as
+	// https://ghe.example.com/api/v3/; empty means github.com. UploadURL and GraphQLURL
+	// default to the standard paths of the same instance.
+	BaseURL    string
+	UploadURL  string
+	GraphQLURL string
+	CABundle   string // PEM file of additional trusted certificate authorities
+
+	// APIURL replaces the github.com REST endpoint https://api.github.com/ while the
+	// client keeps serving github.com, such as with a githubtest.Server. GraphQLURL
+	// defaults to its graphql path.
+	APIURL string
+
+	Proxy string // Proxy URL; empty uses HTTPS_PROXY and friends
+
+	// App, when set, authenticates as a GitHub App installation. Its installation token
+	// joins the pool next to Tokens and is refreshed before it expires.
+	App *AppCredentials
+
+	// Record, when set, saves every request and response of each extraction into an
+	// archive per repository under this directory. Replay instead serves extractions
+	// and eligibility checks from such archives without any network access, using the
+	// latest recording of each repository made at or before ReplayAt (zero for the latest).
+	Record   string
+	Replay   string
+	ReplayAt time.Time
+}
+
+// authTransport authenticates each request with a token from the pool and
+// rotates to another token when the current one runs out of quota
 type authTransport struct {
 	transport http.RoundTripper
-	token     string
+	pool      *tokenPool
 }
 
 var errContributorStatsPending = errors.New("contributor stats are still being generated by GitHub")
 
 func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if t.token != "" {
+	resource := requestResource(req)
+	replayable := req.Body == nil || req.GetBody != nil
+	_, pinned := req.Context().Value(pinnedTokenKey{}).(int)
+
+	for attempt := 0; ; attempt++ {
+		token := t.pool.pick(req.Context(), resource)
+
 		// Clone the request to avoid modifying the original
-		req = req.Clone(req.Context())
-		req.Header.Set("Authorization", "Bearer "+t.token)
+		r := req.Clone(req.Context())
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		credential, err := token.credential(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		if credential != "" {
+			r.Header.Set("Authorization", "Bearer "+credential)
+		}
+
+		resp, err := t.transport.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		t.pool.update(token, resource, resp.Header)
+
+		if isRateLimited(resp) && replayable && !pinned && attempt < len(t.pool.tokens)-1 && t.pool.hasAlternative(token, resource) {
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
 	}
-	return t.transport.RoundTrip(req)
 }
 
-// NewClient creates a new GitHub API client
-func NewClient(token string, logger *logrus.Logger) *Client {
+// NewClient creates a new GitHub API client for github.com or, when opts.BaseURL is set,
+// a GitHub Enterprise Server instance
+func NewClient(opts Options, logger *logrus.Logger) (*Client, error) {
 	baseTransport := &http.Transport{
 		Proxy:                 http.ProxyFromEnvironment,
 		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
@@ -53,38 +134,147 @@ func NewClient(token string, logger *logrus.Logger) *Client {
 		MaxIdleConns:          100,
 		MaxIdleConnsPerHost:   20,
 	}
+	if err := configureTransport(baseTransport, opts); err != nil {
+		return nil, err
+	}
+	if opts.Record != "" && opts.Replay != "" {
+		return nil, fmt.Errorf("record and replay cannot be enabled together")
+	}
+	if (opts.Record != "" || opts.Replay != "") && opts.CloneDir != "" {
+		return nil, fmt.Errorf("clone mode reads outside the API and cannot be recorded or replayed")
+	}
+
+	restURL, uploadURL, graphqlURL, host := "https://api.github.com/", "", DefaultGraphQLURL, DefaultHost
+	if opts.GraphQLURL != "" {
+		graphqlURL = opts.GraphQLURL
+	}
+	if opts.BaseURL != "" {
+		var err error
+		if restURL, uploadURL, graphqlURL, host, err = enterpriseURLs(opts); err != nil {
+			return nil, err
+		}
+	}
+	var apiURL *url.URL
+	if opts.APIURL != "" {
+		if opts.BaseURL != "" {
+			return nil, fmt.Errorf("an API URL and an Enterprise base URL cannot be set together")
+		}
+		var err error
+		if apiURL, err = url.Parse(strings.TrimSuffix(opts.APIURL, "/") + "/"); err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+			return nil, fmt.Errorf("invalid GitHub API URL %q", opts.APIURL)
+		}
+		restURL = apiURL.String()
+		if opts.GraphQLURL == "" {
+			graphqlURL = restURL + "graphql"
+		}
+	}
 
-	var transport http.RoundTripper = baseTransport
-	if token != "" {
-		transport = &authTransport{
-			transport: baseTransport,
-			token:     token,
+	var app *appInstallation
+	if opts.App != nil {
+		var err error
+		// Tokens are minted outside the pool, so they skip its authentication and cache
+		app, err = newAppInstallation(*opts.App, restURL, &http.Client{Transport: baseTransport, Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	// Default http client
+	pool := newTokenPool(opts.Tokens, app)
+	auth := &authTransport{
+		transport: baseTransport,
+		pool:      pool,
+	}
+
+	// Throttled requests are retried only once no pooled token can serve them
+	retry := &retryTransport{
+		transport: auth,
+		logger:    logger,
+	}
+	var transport http.RoundTripper = retry
+
+	// The cache sits outside the auth transport so entries are shared across tokens
+	var cache *cacheTransport
+	if opts.CacheDir != "" {
+		var err error
+		cache, err = newCacheTransport(transport, opts.CacheDir)
+		if err != nil {
+			logger.Warnf("Response cache disabled: %v", err)
+		} else {
+			transport = cache
+			logger.Infof("Caching GitHub responses in %s", opts.CacheDir)
+		}
+	}
+
+	// Recording sees responses after retries and caching; replaying bypasses the network
+	var arch *archiver
+	switch {
+	case opts.Record != "":
+		arch = newArchiver(opts.Record, host, false, time.Time{})
+		transport = &recordTransport{transport: transport}
+		logger.Infof("Recording GitHub API traffic into %s", opts.Record)
+	case opts.Replay != "":
+		arch = newArchiver(opts.Replay, host, true, opts.ReplayAt)
+		transport = replayTransport{}
+		logger.Infof("Replaying GitHub API traffic from %s", opts.Replay)
+	}
+
+	// Default http client. There is no overall timeout, since a request may wait for a
+	// rate limit to lift; the retry transport bounds each attempt instead.
 	defaultHTTP := &http.Client{
-		Timeout:   120 * time.Second,
 		Transport: transport,
 	}
 
-	return &Client{
-		client: gith.NewClient(defaultHTTP),
-		ctx:    context.Background(),
-		token:  token,
-		logger: logger,
+	client := gith.NewClient(defaultHTTP)
+	if opts.BaseURL != "" {
+		var err error
+		if client, err = client.WithEnterpriseURLs(restURL, uploadURL); err != nil {
+			return nil, fmt.Errorf("configure GitHub Enterprise URLs: %w", err)
+		}
+		logger.Infof("Using GitHub Enterprise Server at %s", restURL)
+	}
+	if apiURL != nil {
+		client.BaseURL = apiURL
 	}
+
+	return &Client{
+		client:     client,
+		host:       host,
+		graphqlURL: graphqlURL,
+		useGraphQL: opts.API == APIGraphQL,
+		cloneDir:   opts.CloneDir,
+		pool:       pool,
+		retry:      retry,
+		cache:      cache,
+		archiver:   arch,
+		logger:     logger,
+	}, nil
 }
 
-// GetRepositoryInfo fetches detailed information about a repository
-func (c *Client) GetRepositoryInfo(owner, repo string) models.RepositoryInfo {
+// GetRepositoryInfo fetches detailed information about a repository.
+// Progress is reported to the ProgressFunc attached to ctx with WithProgress, if any, and
+// contributors are selected with the policy attached with source.WithSelection. With a
+// snapshot date attached with source.WithAsOf, the activity data stops at that date.
+func (c *Client) GetRepositoryInfo(ctx context.Context, owner, repo string) models.RepositoryInfo {
+	progress := progressFrom(ctx)
+	policy := source.SelectionFrom(ctx)
 	info := models.RepositoryInfo{
-		Owner: owner,
-		Repo:  repo,
+		Owner:     owner,
+		Repo:      repo,
+		Selection: models.ContributorSelection{Policy: policy, Logins: []string{}},
+	}
+
+	if err := c.checkCloneAsOf(ctx); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	ctx, err := c.archived(ctx, owner, repo)
+	if err != nil {
+		info.Error = fmt.Sprintf("Failed to open archive: %v", err)
+		return info
 	}
 
 	// Get repository details
-	repository, _, err := c.client.Repositories.Get(c.ctx, owner, repo)
+	repository, _, err := c.client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
 		info.Error = fmt.Sprintf("Failed to fetch repository: %v", err)
 		return info
@@ -130,193 +320,101 @@ func (c *Client) GetRepositoryInfo(owner, repo string) models.RepositoryInfo {
 	if repository.License != nil && repository.License.Name != nil {
 		info.License = *repository.License.Name
 	}
+	if err := source.MarkAsOf(ctx, &info); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	progress.report(ProgressRepository, "repository details fetched")
 
 	// Set derived booleans
 	info.HasDescription = info.Description != ""
 	info.HasLicense = info.License != ""
 	info.HasWikiPage = info.HasWiki
 
-	// Check for community health metrics
-	metrics, _, err := c.client.Repositories.GetCommunityHealthMetrics(c.ctx, owner, repo)
-	if err == nil && metrics.Files != nil {
-		info.HasCodeOfConduct = metrics.Files.CodeOfConduct != nil
-		info.HasContributingGuidelines = metrics.Files.Contributing != nil
-		info.HasIssuesTemplate = metrics.Files.IssueTemplate != nil
-		info.HasPullRequestTemplate = metrics.Files.PullRequestTemplate != nil
-		info.HasReadme = metrics.Files.Readme != nil
+	// In clone mode, commits, contributor statistics and community files come from the clone
+	local := c.cloneRepository(ctx, owner, repo)
+	if local != nil {
+		if files, err := local.CommunityFiles(ctx); err != nil {
+			c.logger.Warnf("Failed to read community files of the %s/%s clone: %v", owner, repo, err)
+			local = nil
+		} else {
+			files.Apply(&info)
+		}
 	}
 
-	// Check for Security Policy (simple check for SECURITY.md)
-	// Note: This is a basic check. GitHub also supports .github/SECURITY.md
-	_, _, _, err = c.client.Repositories.GetContents(c.ctx, owner, repo, "SECURITY.md", nil)
-	if err == nil {
-		info.HasSecurityPolicy = true
-	} else {
-		_, _, _, err = c.client.Repositories.GetContents(c.ctx, owner, repo, ".github/SECURITY.md", nil)
+	if local == nil {
+		// Check for community health metrics
+		metrics, _, err := c.client.Repositories.GetCommunityHealthMetrics(ctx, owner, repo)
+		if err == nil && metrics.Files != nil {
+			info.HasCodeOfConduct = metrics.Files.CodeOfConduct != nil
+			info.HasContributingGuidelines = metrics.Files.Contributing != nil
+			info.HasIssuesTemplate = metrics.Files.IssueTemplate != nil
+			info.HasPullRequestTemplate = metrics.Files.PullRequestTemplate != nil
+			info.HasReadme = metrics.Files.Readme != nil
+		}
+
+		// Check for Security Policy (simple check for SECURITY.md)
+		// Note: This is a basic check. GitHub also supports .github/SECURITY.md
+		_, _, _, err = c.client.Repositories.GetContents(ctx, owner, repo, "SECURITY.md", nil)
 		if err == nil {
 			info.HasSecurityPolicy = true
+		} else {
+			_, _, _, err = c.client.Repositories.GetContents(ctx, owner, repo, ".github/SECURITY.md", nil)
+			if err == nil {
+				info.HasSecurityPolicy = true
+			}
 		}
 	}
+	progress.report(ProgressRepository, "community files checked")
 
-	// Proceed to fetch contributors, commits and milestones concurrently
+	// Count commits and milestones while the community data every source shares is fetched
 	var wg sync.WaitGroup
-	var commitErr, milestoneErr, contributorErr, recentContributorErr, contributorStatsErr, allPRsErr error
-	var commits, milestones int
-	var contributors []string
-	var recentContributors []string
-	var totalContributors, nonAnonContributors int
-	var contributorStats []models.ContributorStats
-	var allPRs []models.PullRequestInfo
-
-	wg.Add(6)
+	var commitErr, milestoneErr error
+	var commits int
+	var milestones []models.MilestoneInfo
 
-	// Get number of commits
+	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		commits, commitErr = c.getCommitCount(owner, repo)
-	}()
-
-	// Get number of milestones
-	go func() {
-		defer wg.Done()
-		milestones, milestoneErr = c.getMilestoneCount(owner, repo)
-	}()
-
-	// Get contributors
-	go func() {
-		defer wg.Done()
-		contributors, totalContributors, nonAnonContributors, contributorErr = c.getContributors(owner, repo)
-	}()
-
-	// Get recent contributors (last 90 days)
-	go func() {
-		defer wg.Done()
-		recentContributors, recentContributorErr = c.getRecentContributors(owner, repo, 90)
-	}()
-
-	// Get contributor statistics with ALL contributors (not just top 100)
-	// This provides commit counts per user, weekly activity, and tenure data
-	go func() {
-		defer wg.Done()
-		contributorStats, contributorStatsErr = c.getAllContributorStats(owner, repo)
+		if local != nil {
+			commits, commitErr = local.CommitCount(ctx)
+		} else {
+			commits, commitErr = c.getCommitCount(ctx, owner, repo)
+		}
+		if commitErr == nil {
+			progress.report(ProgressCommits, "commits counted (%d)", commits)
+		}
 	}()
-
-	// Get all pull requests (limited to 1000 for performance)
 	go func() {
 		defer wg.Done()
-		allPRs, allPRsErr = c.getAllPullRequests(owner, repo, 1000)
+		milestones, milestoneErr = c.getMilestones(ctx, owner, repo)
+		if milestoneErr == nil {
+			progress.report(ProgressMilestones, "milestones counted (%d)", len(milestones))
+		}
 	}()
 
+	source.FillCommunity(withClone(ctx, local), c, &info)
+	communityErr := info.Error
+	info.Error = ""
 	wg.Wait()
 
-	// Process results
 	if commitErr != nil {
 		info.Error = fmt.Sprintf("Failed to fetch commits: %v", commitErr)
 	} else {
 		info.Commits = commits
 	}
 
-	if contributorStatsErr != nil {
-		c.logger.Warnf("Failed to fetch contributor stats: %v", contributorStatsErr)
-		// Set to empty slice instead of leaving as nil (which becomes null in JSON)
-		info.ContributorStats = []models.ContributorStats{}
-	} else {
-		info.ContributorStats = contributorStats
-		c.logger.Infof("Fetched statistics for %d contributors", len(contributorStats))
-	}
-
-	if allPRsErr != nil {
-		c.logger.Warnf("Failed to fetch pull requests: %v", allPRsErr)
-		// Don't overwrite existing error, but log the warning
-	} else {
-		info.PullRequests = allPRs
-	}
-
 	if milestoneErr != nil {
 		if info.Error == "" {
 			info.Error = fmt.Sprintf("Failed to fetch milestones: %v", milestoneErr)
 		}
 	} else {
-		info.Milestones = milestones
-		info.HasMilestones = milestones > 0
+		source.SetMilestones(&info, milestones)
 	}
 
-	if contributorErr != nil {
-		if info.Error == "" {
-			info.Error = fmt.Sprintf("Failed to fetch contributors: %v", contributorErr)
-		}
-	} else {
-		info.TotalContributorsCount = totalContributors
-		info.NonAnonymousContributorsCount = nonAnonContributors
-
-		// Filter contributors: top sqrt(total) + recent (90 days)
-		targetContributorsSet := make(map[string]struct{})
-
-		// 1. Top sqrt(total)
-		limit := int(math.Ceil(math.Sqrt(float64(totalContributors))))
-		if limit > len(contributors) {
-			limit = len(contributors)
-		}
-		for i := 0; i < limit; i++ {
-			targetContributorsSet[contributors[i]] = struct{}{}
-		}
-
-		// 2. Recent contributors
-		if recentContributorErr != nil {
-			if info.Error == "" {
-				info.Error = fmt.Sprintf("Failed to fetch recent contributors: %v", recentContributorErr)
-			}
-		} else {
-			for _, u := range recentContributors {
-				targetContributorsSet[u] = struct{}{}
-			}
-		}
-
-		var targetContributors []string
-		for u := range targetContributorsSet {
-			targetContributors = append(targetContributors, u)
-		}
-
-		info.SelectedContributorsCount = len(targetContributors)
-
-		// convert usernames into detailed contributor profiles
-		details, detErr := c.getContributorsDetails(targetContributors)
-		if detErr != nil {
-			if info.Error == "" {
-				info.Error = fmt.Sprintf("Failed to fetch contributor details: %v", detErr)
-			}
-			// Even if detailed fetch failed, return repository with empty contributors
-			info.Contributors = nil
-			info.ContributorsWithLocationCount = 0
-		} else {
-			communityFollowers, communityFollowing, followGraphErr := c.getCommunityFollowCounts(targetContributors)
-			if followGraphErr != nil {
-				c.logger.Warnf("Failed to fetch full community follow graph for %s/%s: %v", owner, repo, followGraphErr)
-			}
-
-			for i := range details {
-				loginKey := strings.ToLower(details[i].Login)
-				details[i].Followers = communityFollowers[loginKey]
-				details[i].Following = communityFollowing[loginKey]
-				if details[i].Following > 0 {
-					details[i].FollowerFollowingRatio = float64(details[i].Followers) / float64(details[i].Following)
-				} else {
-					details[i].FollowerFollowingRatio = 0
-				}
-			}
-
-			// Filter contributors with location
-			var withLocation []models.ContributorDetail
-			for _, d := range details {
-				if d.Location != "" {
-					withLocation = append(withLocation, d)
-				}
-			}
-			info.Contributors = withLocation
-			info.ContributorsWithLocationCount = len(withLocation)
-		}
+	if info.Error == "" {
+		info.Error = communityErr
 	}
-
 	return info
 }
 
@@ -324,7 +422,15 @@ func (c *Client) GetRepositoryInfo(owner, repo string) models.RepositoryInfo {
 //   - at least 1 closed milestone
 //   - at least `minCommits` commits (use 100 where caller passes 100)
 //   - at least `minActive` distinct commit authors in the last `days` days (use 3, 90)
-func (c *Client) CheckRepoEligibility(owner, repo string, minCommits int, days int, minActive int) (bool, string, error) {
+func (c *Client) CheckRepoEligibility(ctx context.Context, owner, repo string, minCommits int, days int, minActive int) (bool, string, error) {
+	if err := c.checkCloneAsOf(ctx); err != nil {
+		return false, "", err
+	}
+	ctx, err := c.archived(ctx, owner, repo)
+	if err != nil {
+		return false, "", err
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(3)
 
@@ -339,20 +445,20 @@ func (c *Client) CheckRepoEligibility(owner, repo string, minCommits int, days i
 	// 1) closed milestones
 	go func() {
 		defer wg.Done()
-		// hasClosed, milestoneErr = c.hasClosedMilestones(owner, repo)
-		_, milestoneErr = c.hasClosedMilestones(owner, repo)
+		// hasClosed, milestoneErr = c.hasClosedMilestones(ctx, owner, repo)
+		_, milestoneErr = c.hasClosedMilestones(ctx, owner, repo)
 	}()
 
 	// 2) commits >= minCommits
 	go func() {
 		defer wg.Done()
-		commitCount, commitErr = c.getCommitCountWithLimit(owner, repo, minCommits)
+		commitCount, commitErr = c.getCommitCountWithLimit(ctx, owner, repo, minCommits)
 	}()
 
 	// 3) active contributors
 	go func() {
 		defer wg.Done()
-		activeOk, activeCount, activeErr = c.hasActiveContributors(owner, repo, days, minActive)
+		activeOk, activeCount, activeErr = c.hasActiveContributors(ctx, owner, repo, days, minActive)
 	}()
 
 	wg.Wait()
@@ -381,28 +487,170 @@ func (c *Client) CheckRepoEligibility(owner, repo string, minCommits int, days i
 	return true, "", nil
 }
 
-// GetRemainingRequests fetches the remaining number of requests for the REST API (Core).
+// GetRemainingRequests fetches the remaining number of requests for the REST API (Core),
+// summed over every pooled token.
 // This is useful for monitoring usage against the 5,000 hourly limit.
 // Note: This API call itself does not consume quota.
-func (c *Client) GetRemainingRequests() (int, error) {
-	limits, _, err := c.client.RateLimits(c.ctx)
+func (c *Client) GetRemainingRequests(ctx context.Context) (int, error) {
+	statuses, err := c.TokenStatus(ctx)
 	if err != nil {
 		return 0, err
 	}
-	if limits.Core != nil {
-		return limits.Core.Remaining, nil
+
+	remaining := 0
+	for _, s := range statuses {
+		remaining += s.Core.Remaining
+	}
+	return remaining, nil
+}
+
+// TokenStatus refreshes and returns the core, search and GraphQL quota of every pooled token.
+// Note: The rate_limit endpoint does not consume quota.
+func (c *Client) TokenStatus(ctx context.Context) ([]TokenStatus, error) {
+	for i, t := range c.pool.tokens {
+		limits, _, err := c.client.RateLimits(withPinnedToken(ctx, i))
+		if err != nil {
+			return nil, fmt.Errorf("fetch rate limits for token %s: %w", t.name(), err)
+		}
+		if limits.Core == nil {
+			return nil, fmt.Errorf("could not retrieve core rate limits")
+		}
+
+		for resource, rate := range map[string]*gith.Rate{
+			ResourceCore:    limits.Core,
+			ResourceSearch:  limits.Search,
+			ResourceGraphQL: limits.GraphQL,
+		} {
+			if rate != nil {
+				c.pool.set(t, resource, RateStatus{Limit: rate.Limit, Remaining: rate.Remaining, Reset: rate.Reset.Time})
+			}
+		}
+	}
+
+	return c.pool.status(), nil
+}
+
+// CacheStats returns the response cache hit and miss counters
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.Stats()
+}
+
+// Host returns the hostname repositories are extracted from
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Contributors returns the contributor logins ordered by contributions, the total number
+// of contributors including anonymous ones and the number of non-anonymous ones. The
+// contributor list covers the whole history, so a snapshot ranks the authors with commits
+// by the snapshot date from the contributor statistics instead.
+func (c *Client) Contributors(ctx context.Context, owner, repo string) ([]source.Contributor, int, int, error) {
+	progress := progressFrom(ctx)
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		stats, err := c.ContributorStats(ctx, owner, repo)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		contributors, total, nonAnon := source.ContributorsFromStats(stats)
+		progress.report(ProgressContributors, "contributors as of %s (%d)", asOf.Format(time.DateOnly), total)
+		return contributors, total, nonAnon, nil
+	}
+
+	contributors, total, nonAnon, err := c.getContributors(ctx, owner, repo)
+	if err == nil {
+		progress.report(ProgressContributors, "contributors fetched (%d)", total)
+	}
+	return contributors, total, nonAnon, err
+}
+
+// RecentContributors returns the logins that committed in the last days days
+func (c *Client) RecentContributors(ctx context.Context, owner, repo string, days int) ([]string, error) {
+	recent, err := c.getRecentContributors(ctx, owner, repo, days)
+	if err == nil {
+		progressFrom(ctx).report(ProgressContributors, "recent contributors fetched (%d)", len(recent))
+	}
+	return recent, err
+}
+
+// ContributorStats returns the stats/contributors data of every contributor, read from
+// the local clone attached to ctx in clone mode
+func (c *Client) ContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
+	var stats []models.ContributorStats
+	var err error
+	if local := cloneFrom(ctx); local != nil {
+		stats, err = localContributorStats(ctx, local)
+	} else {
+		stats, err = c.getAllContributorStats(ctx, owner, repo)
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to fetch contributor stats: %v", err)
+		return nil, err
+	}
+	progressFrom(ctx).report(ProgressContributorStats, "contributor stats fetched (%d)", len(stats))
+	return stats, nil
+}
+
+// PullRequests returns up to max closed pull requests, newest first, with their merge time
+func (c *Client) PullRequests(ctx context.Context, owner, repo string, max int) ([]models.PullRequestInfo, error) {
+	var prs []models.PullRequestInfo
+	var err error
+	if c.useGraphQL {
+		prs, err = c.getAllPullRequestsGraphQL(ctx, owner, repo, max)
+	} else {
+		prs, err = c.getAllPullRequests(ctx, owner, repo, max)
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to fetch pull requests: %v", err)
+		return nil, err
+	}
+	progressFrom(ctx).report(ProgressPullRequests, "pull requests fetched (%d)", len(prs))
+	return prs, nil
+}
+
+// ContributorDetails fetches the public profile of each login
+func (c *Client) ContributorDetails(ctx context.Context, logins []string) ([]models.ContributorDetail, error) {
+	if c.useGraphQL {
+		return c.getContributorsDetailsGraphQL(ctx, logins)
+	}
+	return c.getContributorsDetails(ctx, logins)
+}
+
+// FollowGraph returns which of the logins follow which others
+func (c *Client) FollowGraph(ctx context.Context, logins []string) (models.FollowGraph, error) {
+	var graph models.FollowGraph
+	var err error
+	if c.useGraphQL {
+		graph, err = c.getCommunityFollowGraphGraphQL(ctx, logins)
+	} else {
+		graph, err = c.getCommunityFollowGraph(ctx, logins)
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to fetch the full community follow graph: %v", err)
 	}
-	return 0, fmt.Errorf("could not retrieve core rate limits")
+	return graph, err
+}
+
+// ThrottleStats returns how often and how long requests were held back by rate limits
+func (c *Client) ThrottleStats() ThrottleStats {
+	return c.retry.Stats()
 }
 
 // Returns true if repository has at least one closed milestone.
-func (c *Client) hasClosedMilestones(owner, repo string) (bool, error) {
+func (c *Client) hasClosedMilestones(ctx context.Context, owner, repo string) (bool, error) {
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		n, err := c.countMilestonesAsOf(ctx, owner, repo, "closed", asOf)
+		return n > 0, err
+	}
+
 	opt := &gith.MilestoneListOptions{
 		State:       "closed",
 		ListOptions: gith.ListOptions{PerPage: 1},
 	}
 
-	milestones, resp, err := c.client.Issues.ListMilestones(c.ctx, owner, repo, opt)
+	milestones, resp, err := c.client.Issues.ListMilestones(ctx, owner, repo, opt)
 	if err != nil {
 		return false, err
 	}
@@ -418,17 +666,19 @@ func (c *Client) hasClosedMilestones(owner, repo string) (bool, error) {
 	return false, nil
 }
 
-// getCommitCount returns the total number of commits in the repository
-func (c *Client) getCommitCount(owner, repo string) (int, error) {
+// getCommitCount returns the total number of commits in the repository, up to the
+// snapshot date attached to ctx
+func (c *Client) getCommitCount(ctx context.Context, owner, repo string) (int, error) {
 	// Optimization: Request 1 item per page. The LastPage value in the response header
 	// will tell us the total number of pages, which equals the total number of commits.
 	opts := &gith.CommitsListOptions{
+		Until: source.AsOfFrom(ctx),
 		ListOptions: gith.ListOptions{
 			PerPage: 1,
 		},
 	}
 
-	commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, opts)
+	commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
 	if err != nil {
 		return 0, err
 	}
@@ -443,13 +693,14 @@ func (c *Client) getCommitCount(owner, repo string) (int, error) {
 }
 
 // getCommitCountWithLimit counts commits but stops early when limit is reached.
-func (c *Client) getCommitCountWithLimit(owner, repo string, limit int) (int, error) {
+func (c *Client) getCommitCountWithLimit(ctx context.Context, owner, repo string, limit int) (int, error) {
 	opts := &gith.CommitsListOptions{
+		Until:       source.AsOfFrom(ctx),
 		ListOptions: gith.ListOptions{PerPage: 100},
 	}
 	total := 0
 	for {
-		commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, opts)
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
 		if err != nil {
 			return total, err
 		}
@@ -465,45 +716,76 @@ func (c *Client) getCommitCountWithLimit(owner, repo string, limit int) (int, er
 	return total, nil
 }
 
-// getMilestoneCount returns the total number of milestones (open + closed)
-func (c *Client) getMilestoneCount(owner, repo string) (int, error) {
-	// Helper to get count for a state
-	getCount := func(state string) (int, error) {
-		opts := &gith.MilestoneListOptions{
-			State: state,
-			ListOptions: gith.ListOptions{
-				PerPage: 1,
-			},
-		}
-		milestones, resp, err := c.client.Issues.ListMilestones(c.ctx, owner, repo, opts)
+// getMilestones lists the milestones of the repository, open and closed, that existed at
+// the snapshot date attached to ctx. Milestones cannot be listed up to a date, so every
+// page is read.
+func (c *Client) getMilestones(ctx context.Context, owner, repo string) ([]models.MilestoneInfo, error) {
+	opts := &gith.MilestoneListOptions{
+		State:       "all",
+		ListOptions: gith.ListOptions{PerPage: 100},
+	}
+
+	list := []models.MilestoneInfo{}
+	for {
+		milestones, resp, err := c.client.Issues.ListMilestones(ctx, owner, repo, opts)
 		if err != nil {
-			return 0, err
+			return nil, err
+		}
+		for _, m := range milestones {
+			info := models.MilestoneInfo{CreatedAt: m.GetCreatedAt().Time}
+			if m.ClosedAt != nil {
+				closed := m.ClosedAt.Time
+				info.ClosedAt = &closed
+			}
+			list = append(list, info)
 		}
-		if resp.LastPage == 0 {
-			return len(milestones), nil
+		if resp.NextPage == 0 {
+			break
 		}
-		return resp.LastPage, nil
+		opts.Page = resp.NextPage
 	}
+	return source.MilestonesAsOf(list, source.AsOfFrom(ctx)), nil
+}
 
-	openCount, err := getCount("open")
-	if err != nil {
-		return 0, err
+// countMilestonesAsOf pages through the milestones in state and counts those that existed
+// at asOf, or for closed milestones those closed by then. Milestones cannot be listed up
+// to a date, so every page is read.
+func (c *Client) countMilestonesAsOf(ctx context.Context, owner, repo, state string, asOf time.Time) (int, error) {
+	opts := &gith.MilestoneListOptions{
+		State:       state,
+		ListOptions: gith.ListOptions{PerPage: 100},
 	}
 
-	closedCount, err := getCount("closed")
-	if err != nil {
-		return 0, err
+	count := 0
+	for {
+		milestones, resp, err := c.client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range milestones {
+			at := m.CreatedAt
+			if state == "closed" {
+				at = m.ClosedAt
+			}
+			if at != nil && !at.After(asOf) {
+				count++
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
-
-	return openCount + closedCount, nil
+	return count, nil
 }
 
 // hasActiveContributors returns (ok, count, err) where ok==true if unique authors in 'days' period >= minNeeded.
 // It counts unique commit authors (by Login) in commits since now - days.
-func (c *Client) hasActiveContributors(owner, repo string, days int, minNeeded int) (bool, int, error) {
-	since := time.Now().AddDate(0, 0, -days)
+func (c *Client) hasActiveContributors(ctx context.Context, owner, repo string, days int, minNeeded int) (bool, int, error) {
+	since := c.now(ctx).AddDate(0, 0, -days)
 	opts := &gith.CommitsListOptions{
 		Since:       since,
+		Until:       source.AsOfFrom(ctx),
 		ListOptions: gith.ListOptions{PerPage: 100},
 	}
 
@@ -511,7 +793,7 @@ func (c *Client) hasActiveContributors(owner, repo string, days int, minNeeded i
 	pageLimit := 50 // safety cap to avoid extremely long scans; adjust if needed
 	pages := 0
 	for {
-		commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, opts)
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
 		if err != nil {
 			return false, len(seen), err
 		}
@@ -540,10 +822,11 @@ func (c *Client) hasActiveContributors(owner, repo string, days int, minNeeded i
 }
 
 // getRecentContributors returns a list of contributors who have committed in the last `days` days.
-func (c *Client) getRecentContributors(owner, repo string, days int) ([]string, error) {
-	since := time.Now().AddDate(0, 0, -days)
+func (c *Client) getRecentContributors(ctx context.Context, owner, repo string, days int) ([]string, error) {
+	since := c.now(ctx).AddDate(0, 0, -days)
 	opts := &gith.CommitsListOptions{
 		Since:       since,
+		Until:       source.AsOfFrom(ctx),
 		ListOptions: gith.ListOptions{PerPage: 100},
 	}
 
@@ -551,7 +834,7 @@ func (c *Client) getRecentContributors(owner, repo string, days int) ([]string,
 	var recent []string
 
 	for {
-		commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, opts)
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -575,7 +858,7 @@ func (c *Client) getRecentContributors(owner, repo string, days int) ([]string,
 }
 
 // getContributors returns the list of contributor usernames, total count (including anon), and non-anon count
-func (c *Client) getContributors(owner, repo string) ([]string, int, int, error) {
+func (c *Client) getContributors(ctx context.Context, owner, repo string) ([]source.Contributor, int, int, error) {
 	opts := &gith.ListContributorsOptions{
 		Anon: "true",
 		ListOptions: gith.ListOptions{
@@ -583,12 +866,12 @@ func (c *Client) getContributors(owner, repo string) ([]string, int, int, error)
 		},
 	}
 
-	var allContributors []string
+	var allContributors []source.Contributor
 	var totalCount int
 	var nonAnonCount int
 
 	for {
-		contributors, resp, err := c.client.Repositories.ListContributors(c.ctx, owner, repo, opts)
+		contributors, resp, err := c.client.Repositories.ListContributors(ctx, owner, repo, opts)
 		if err != nil {
 			return nil, 0, 0, err
 		}
@@ -597,7 +880,7 @@ func (c *Client) getContributors(owner, repo string) ([]string, int, int, error)
 
 		for _, contributor := range contributors {
 			if contributor.Login != nil {
-				allContributors = append(allContributors, *contributor.Login)
+				allContributors = append(allContributors, source.Contributor{Login: *contributor.Login, Contributions: contributor.GetContributions()})
 				nonAnonCount++
 			}
 		}
@@ -612,7 +895,8 @@ func (c *Client) getContributors(owner, repo string) ([]string, int, int, error)
 }
 
 // getContributorsDetails fetches detailed information for a list of contributors
-func (c *Client) getContributorsDetails(usernames []string) ([]models.ContributorDetail, error) {
+func (c *Client) getContributorsDetails(ctx context.Context, usernames []string) ([]models.ContributorDetail, error) {
+	progress := progressFrom(ctx)
 	if len(usernames) == 0 {
 		return nil, nil
 	}
@@ -620,6 +904,7 @@ func (c *Client) getContributorsDetails(usernames []string) ([]models.Contributo
 	var wg sync.WaitGroup
 	results := make([]models.ContributorDetail, len(usernames))
 	sem := make(chan struct{}, 5)
+	var done int32
 
 	for i, username := range usernames {
 		wg.Add(1)
@@ -627,8 +912,11 @@ func (c *Client) getContributorsDetails(usernames []string) ([]models.Contributo
 		go func(idx int, u string) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer func() {
+				progress.reportCount(ProgressProfiles, "contributor profiles", int(atomic.AddInt32(&done, 1)), len(usernames))
+			}()
 
-			user, _, err := c.client.Users.Get(c.ctx, u)
+			user, _, err := c.client.Users.Get(ctx, u)
 			if err != nil {
 				c.logger.Debugf("Failed to fetch user %s: %v", u, err)
 				results[idx].Error = err.Error()
@@ -704,28 +992,26 @@ func (c *Client) getContributorsDetails(usernames []string) ([]models.Contributo
 	return results, nil
 }
 
-// getCommunityFollowCounts computes followers/following counts restricted to the provided community.
+// getCommunityFollowGraph builds the follow graph restricted to the provided community.
 // It scans each member's following list and keeps only edges between members of the same community.
-func (c *Client) getCommunityFollowCounts(usernames []string) (map[string]int, map[string]int, error) {
+func (c *Client) getCommunityFollowGraph(ctx context.Context, usernames []string) (models.FollowGraph, error) {
+	progress := progressFrom(ctx)
 	community := make(map[string]struct{}, len(usernames))
-	followersCount := make(map[string]int, len(usernames))
-	followingCount := make(map[string]int, len(usernames))
+	lists := make(map[string][]string, len(usernames))
 
 	for _, u := range usernames {
-		key := strings.ToLower(u)
-		community[key] = struct{}{}
-		followersCount[key] = 0
-		followingCount[key] = 0
+		community[strings.ToLower(u)] = struct{}{}
 	}
 
 	if len(community) == 0 {
-		return followersCount, followingCount, nil
+		return source.FollowEdges(usernames, lists), nil
 	}
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 5)
 	var mu sync.Mutex
 	failed := 0
+	var done int32
 
 	for _, username := range usernames {
 		u := username
@@ -735,13 +1021,16 @@ func (c *Client) getCommunityFollowCounts(usernames []string) (map[string]int, m
 		go func() {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer func() {
+				progress.reportCount(ProgressFollowGraph, "follow lists", int(atomic.AddInt32(&done, 1)), len(usernames))
+			}()
 
 			selfKey := strings.ToLower(u)
 			seenCommunityFollowees := make(map[string]struct{})
 
 			opts := &gith.ListOptions{PerPage: 100}
 			for {
-				following, resp, err := c.client.Users.ListFollowing(c.ctx, u, opts)
+				following, resp, err := c.client.Users.ListFollowing(ctx, u, opts)
 				if err != nil {
 					c.logger.Debugf("Failed to fetch following list for %s: %v", u, err)
 					mu.Lock()
@@ -771,9 +1060,8 @@ func (c *Client) getCommunityFollowCounts(usernames []string) (map[string]int, m
 			}
 
 			mu.Lock()
-			followingCount[selfKey] = len(seenCommunityFollowees)
 			for targetKey := range seenCommunityFollowees {
-				followersCount[targetKey]++
+				lists[u] = append(lists[u], targetKey)
 			}
 			mu.Unlock()
 		}()
@@ -781,17 +1069,19 @@ func (c *Client) getCommunityFollowCounts(usernames []string) (map[string]int, m
 
 	wg.Wait()
 
+	graph := source.FollowEdges(usernames, lists)
 	if failed == len(usernames) && len(usernames) > 0 {
-		return followersCount, followingCount, fmt.Errorf("failed to fetch community following lists for all contributors")
+		return graph, fmt.Errorf("failed to fetch community following lists for all contributors")
 	}
 
-	return followersCount, followingCount, nil
+	return graph, nil
 }
 
 // getAllCommits fetches commits from a repository with an optional limit
 // If maxCommits is 0, fetches all commits. Otherwise, stops after maxCommits.
-func (c *Client) getAllCommits(owner, repo string, maxCommits int) ([]models.CommitInfo, error) {
+func (c *Client) getAllCommits(ctx context.Context, owner, repo string, maxCommits int) ([]models.CommitInfo, error) {
 	opts := &gith.CommitsListOptions{
+		Until: source.AsOfFrom(ctx),
 		ListOptions: gith.ListOptions{
 			PerPage: 100,
 		},
@@ -800,7 +1090,7 @@ func (c *Client) getAllCommits(owner, repo string, maxCommits int) ([]models.Com
 	var allCommits []models.CommitInfo
 
 	for {
-		commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, opts)
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -848,10 +1138,10 @@ func (c *Client) getAllCommits(owner, repo string, maxCommits int) ([]models.Com
 // If maxPRs is 0, fetches all PRs. Otherwise, stops after maxPRs.
 // Uses GitHub Search API to avoid timeouts on large repositories.
 // Note: Search API has a rate limit of 30 requests per minute.
-func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.PullRequestInfo, error) {
+func (c *Client) getAllPullRequests(ctx context.Context, owner, repo string, maxPRs int) ([]models.PullRequestInfo, error) {
 	// Use Search API to avoid timeouts on large repositories
 	// Query for closed PRs in descending order
-	query := fmt.Sprintf("repo:%s/%s type:pr state:closed", owner, repo)
+	query := pullRequestQuery(ctx, owner, repo)
 
 	opts := &gith.SearchOptions{
 		Sort:  "created",
@@ -862,10 +1152,11 @@ func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.Pu
 	}
 
 	var allPRs []models.PullRequestInfo
+	progress := progressFrom(ctx)
 
 	for {
 		// Search API has a rate limit of 30 requests/minute
-		result, resp, err := c.client.Search.Issues(c.ctx, query, opts)
+		result, resp, err := c.client.Search.Issues(ctx, query, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -898,6 +1189,7 @@ func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.Pu
 
 			allPRs = append(allPRs, prInfo)
 		}
+		progress.report(ProgressPullRequests, "PR search %d/%d", len(allPRs), result.GetTotal())
 
 		if resp.NextPage == 0 {
 			break
@@ -909,6 +1201,7 @@ func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.Pu
 	c.logger.Infof("Fetching merged_at for %d PRs from %s/%s", len(allPRs), owner, repo)
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 10) // Limit concurrent requests to 10
+	var done int32
 
 	for i := range allPRs {
 		wg.Add(1)
@@ -916,8 +1209,11 @@ func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.Pu
 			defer wg.Done()
 			sem <- struct{}{}        // Acquire
 			defer func() { <-sem }() // Release
+			defer func() {
+				progress.reportCount(ProgressPullRequests, "PR merged_at", int(atomic.AddInt32(&done, 1)), len(allPRs))
+			}()
 
-			pr, _, err := c.client.PullRequests.Get(c.ctx, owner, repo, allPRs[idx].Number)
+			pr, _, err := c.client.PullRequests.Get(ctx, owner, repo, allPRs[idx].Number)
 			if err != nil {
 				c.logger.Debugf("Failed to fetch PR #%d: %v", allPRs[idx].Number, err)
 				return
@@ -942,27 +1238,43 @@ func (c *Client) getAllPullRequests(owner, repo string, maxPRs int) ([]models.Pu
 
 	wg.Wait()
 
+	// Partial merged_at data is useless once the caller has gone away
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	return allPRs, nil
 }
 
+// pullRequestQuery searches the closed pull requests of a repository, limited to those
+// closed by the snapshot date attached to ctx
+func pullRequestQuery(ctx context.Context, owner, repo string) string {
+	query := fmt.Sprintf("repo:%s/%s type:pr state:closed", owner, repo)
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		query += " closed:<=" + asOf.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return query
+}
+
 // getContributorStatsWithRetry fetches aggregated contributor statistics using the stats/contributors endpoint
 // This is much more efficient than fetching all commits individually
 // Returns commit counts per contributor and weekly activity data for computing:
 // - Development Distribution (Gini coefficient from commit counts)
 // - Technical Pulse (active weeks in last year)
 // - Contributor Retention (tenure from first/last commit dates)
-func (c *Client) getContributorStatsWithRetry(owner, repo string) ([]models.ContributorStats, error) {
+func (c *Client) getContributorStatsWithRetry(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
 	// Use the stats/contributors endpoint
 	// Note: This endpoint can take a while to compute on first request (GitHub caches it)
 	// We'll retry up to 8 times with increasing delays if GitHub returns 202 (still computing)
 
 	maxRetries := 8
+	progress := progressFrom(ctx)
 	var contributors []*gith.ContributorStats
 	var resp *gith.Response
 	var err error
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
-		contributors, resp, err = c.client.Repositories.ListContributorsStats(c.ctx, owner, repo)
+		contributors, resp, err = c.client.Repositories.ListContributorsStats(ctx, owner, repo)
 
 		if err != nil {
 			var acceptedErr *gith.AcceptedError
@@ -979,7 +1291,10 @@ func (c *Client) getContributorStatsWithRetry(owner, repo string) ([]models.Cont
 
 					c.logger.Infof("GitHub is computing contributor stats for %s/%s (attempt %d/%d), waiting %v...",
 						owner, repo, attempt+1, maxRetries+1, waitTime)
-					time.Sleep(waitTime)
+					progress.report(ProgressContributorStats, "stats pending, retry %d/%d, waiting %v", attempt+1, maxRetries, waitTime)
+					if err := sleep(ctx, waitTime); err != nil {
+						return []models.ContributorStats{}, err
+					}
 					continue
 				}
 
@@ -1003,7 +1318,10 @@ func (c *Client) getContributorStatsWithRetry(owner, repo string) ([]models.Cont
 				}
 				c.logger.Infof("GitHub is computing contributor stats (attempt %d/%d), waiting %v...",
 					attempt+1, maxRetries+1, waitTime)
-				time.Sleep(waitTime)
+				progress.report(ProgressContributorStats, "stats pending, retry %d/%d, waiting %v", attempt+1, maxRetries, waitTime)
+				if err := sleep(ctx, waitTime); err != nil {
+					return []models.ContributorStats{}, err
+				}
 				continue
 			} else {
 				return []models.ContributorStats{}, errContributorStatsPending
@@ -1081,6 +1399,19 @@ func (c *Client) getContributorStatsWithRetry(owner, repo string) ([]models.Cont
 	return stats, nil
 }
 
+// sleep waits for d, returning early with the context error if ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // sumTotalCommits is a helper to sum all commits across contributors
 func sumTotalCommits(stats []models.ContributorStats) int {
 	total := 0
@@ -1096,12 +1427,13 @@ func sumTotalCommits(stats []models.ContributorStats) int {
 // - Weekly activity data
 // - First/last commit dates (derived from weeks)
 // This saves hundreds of API calls compared to individual commit fetching.
-func (c *Client) getAllContributorStats(owner, repo string) ([]models.ContributorStats, error) {
+// The weeks after the snapshot date attached to ctx are dropped.
+func (c *Client) getAllContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
 	c.logger.Infof("Fetching contributor statistics for %s/%s using stats/contributors endpoint", owner, repo)
 
 	// Use the stats/contributors endpoint - this is THE most efficient way to get all data
 	// It provides everything in one call: commit counts, weekly activity, and date ranges
-	weeklyStats, err := c.getContributorStatsWithRetry(owner, repo)
+	weeklyStats, err := c.getContributorStatsWithRetry(ctx, owner, repo)
 	if err != nil {
 		if errors.Is(err, errContributorStatsPending) {
 			c.logger.Infof("Contributor stats still computing for %s/%s after retries; returning empty stats for now", owner, repo)
@@ -1118,12 +1450,12 @@ func (c *Client) getAllContributorStats(owner, repo string) ([]models.Contributo
 	}
 
 	c.logger.Infof("Successfully fetched stats for %d contributors with weekly activity data", len(weeklyStats))
-	return weeklyStats, nil
+	return source.StatsAsOf(weeklyStats, source.AsOfFrom(ctx)), nil
 }
 
 // getContributorCommitDates efficiently fetches the first and last commit dates for a contributor
 // Makes only 2 API calls: one for newest commit, one for oldest commit
-func (c *Client) getContributorCommitDates(owner, repo, author string) (time.Time, time.Time, error) {
+func (c *Client) getContributorCommitDates(ctx context.Context, owner, repo, author string) (time.Time, time.Time, error) {
 	var firstCommit, lastCommit time.Time
 
 	// Fetch the most recent commit (last commit date)
@@ -1135,7 +1467,7 @@ func (c *Client) getContributorCommitDates(owner, repo, author string) (time.Tim
 		},
 	}
 
-	commits, resp, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, lastOpts)
+	commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, lastOpts)
 	if err != nil {
 		return firstCommit, lastCommit, err
 	}
@@ -1155,7 +1487,7 @@ func (c *Client) getContributorCommitDates(owner, repo, author string) (time.Tim
 			},
 		}
 
-		firstCommits, _, err := c.client.Repositories.ListCommits(c.ctx, owner, repo, firstOpts)
+		firstCommits, _, err := c.client.Repositories.ListCommits(ctx, owner, repo, firstOpts)
 		if err != nil {
 			return firstCommit, lastCommit, err
 		}
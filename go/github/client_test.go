@@ -0,0 +1,381 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+	"github-extractor/models"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+// widget is a repository with 150 daily commits by alice, bob, carol and an anonymous
+// author, 120 closed pull requests of which every other one was merged, and two
+// milestones
+func widget(now time.Time) githubtest.Repository {
+	r := githubtest.Repository{
+		Owner:       "acme",
+		Repo:        "widget",
+		Description: "Widgets",
+		Language:    "Go",
+		License:     "MIT License",
+		Stars:       42,
+		Forks:       7,
+		HasIssues:   true,
+		CreatedAt:   now.AddDate(-2, 0, 0),
+		UpdatedAt:   now,
+		Files:       []string{"README.md", "CONTRIBUTING.md", "SECURITY.md"},
+		Contributors: []githubtest.Contributor{
+			{Login: "alice", Contributions: 45},
+			{Login: "bob", Contributions: 45},
+			{Login: "carol", Contributions: 45},
+			{Name: "Anonymous", Email: "anon@example.com", Contributions: 15},
+		},
+		Milestones: []githubtest.Milestone{
+			{Title: "v1", State: "closed", CreatedAt: now.AddDate(-1, 0, 0)},
+			{Title: "v2", State: "open", CreatedAt: now.AddDate(0, -1, 0)},
+		},
+	}
+
+	authors := []string{"alice", "bob", "carol"}
+	for i := 0; i < 150; i++ {
+		c := githubtest.Commit{SHA: fmt.Sprintf("%040x", i), Date: now.AddDate(0, 0, -i)}
+		if i%10 == 9 {
+			c.AuthorName, c.AuthorEmail = "Anonymous", "anon@example.com"
+		} else {
+			c.AuthorLogin = authors[i%3]
+		}
+		r.Commits = append(r.Commits, c)
+	}
+
+	week := now.AddDate(0, 0, -14).Truncate(24 * time.Hour)
+	for _, login := range authors {
+		r.Stats = append(r.Stats, githubtest.ContributorStats{Author: login, Weeks: []githubtest.Week{
+			{Start: week, Commits: 20},
+			{Start: week.AddDate(0, 0, 7), Commits: 25},
+		}})
+	}
+
+	for i := 0; i < 120; i++ {
+		created := now.AddDate(0, 0, -i-1)
+		closed := created.Add(time.Hour)
+		pr := githubtest.PullRequest{Number: i + 1, CreatedAt: created, ClosedAt: &closed}
+		if i%2 == 0 {
+			pr.MergedAt = &closed
+		}
+		r.PullRequests = append(r.PullRequests, pr)
+	}
+	return r
+}
+
+// newFake serves repos and the profiles of alice, bob and carol, and returns a client
+// pointed at it
+func newFake(t *testing.T, repos ...githubtest.Repository) (*githubtest.Server, *github.Client) {
+	t.Helper()
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	for _, r := range repos {
+		fake.AddRepository(r)
+	}
+	fake.AddUser(githubtest.User{Login: "alice", Location: "Lisbon", Follows: []string{"bob"}})
+	fake.AddUser(githubtest.User{Login: "bob", Location: "Tokyo", Follows: []string{"alice", "carol"}})
+	fake.AddUser(githubtest.User{Login: "carol"})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, client
+}
+
+func TestGetRepositoryInfo(t *testing.T) {
+	fake, client := newFake(t, widget(time.Now()))
+	ctx := source.WithSelection(context.Background(), models.SelectionPolicy{Strategy: "all"})
+
+	info := client.GetRepositoryInfo(ctx, "acme", "widget")
+	if info.Error != "" {
+		t.Fatalf("extraction failed: %s", info.Error)
+	}
+
+	if info.Description != "Widgets" || info.Language != "Go" || info.License != "MIT License" || info.Stars != 42 || info.Forks != 7 {
+		t.Errorf("details = %q %q %q %d stars %d forks", info.Description, info.Language, info.License, info.Stars, info.Forks)
+	}
+	if !info.HasReadme || !info.HasContributingGuidelines || !info.HasSecurityPolicy || info.HasCodeOfConduct {
+		t.Errorf("community files: readme %v, contributing %v, security %v, code of conduct %v; want the first three",
+			info.HasReadme, info.HasContributingGuidelines, info.HasSecurityPolicy, info.HasCodeOfConduct)
+	}
+	if info.Commits != 150 {
+		t.Errorf("commits = %d, want 150", info.Commits)
+	}
+	if info.Milestones != 2 || !info.HasMilestones || len(info.MilestoneDates) != 2 {
+		t.Errorf("milestones = %d with %d dates, want 2", info.Milestones, len(info.MilestoneDates))
+	}
+	if info.TotalContributorsCount != 4 || info.NonAnonymousContributorsCount != 3 {
+		t.Errorf("contributors = %d total, %d with an account; want 4 and 3", info.TotalContributorsCount, info.NonAnonymousContributorsCount)
+	}
+	if len(info.ContributorStats) != 3 {
+		t.Errorf("statistics of %d contributors, want 3", len(info.ContributorStats))
+	}
+
+	merged := 0
+	for _, pr := range info.PullRequests {
+		if pr.MergedAt != nil {
+			merged++
+			if pr.Status != "merged" {
+				t.Errorf("pull request #%d: status %q, want merged", pr.Number, pr.Status)
+			}
+		}
+	}
+	if len(info.PullRequests) != 120 || merged != 60 {
+		t.Errorf("pull requests = %d with %d merged, want 120 with 60", len(info.PullRequests), merged)
+	}
+
+	if got := strings.Join(info.Selection.Logins, ","); got != "alice,bob,carol" {
+		t.Errorf("selection = %s, want alice,bob,carol", got)
+	}
+	if info.ContributorsWithLocationCount != 2 {
+		t.Errorf("%d contributors with a location, want 2", info.ContributorsWithLocationCount)
+	}
+	if len(info.FollowGraph.Edges) != 3 {
+		t.Errorf("follow edges = %v, want 3", info.FollowGraph.Edges)
+	}
+	for _, d := range info.Contributors {
+		if d.Login == "bob" && (d.Followers != 1 || d.Following != 2) {
+			t.Errorf("bob follows %d and is followed by %d, want 2 and 1", d.Following, d.Followers)
+		}
+	}
+
+	// Threads are only read when engagement is requested
+	if n := fake.RequestCount("/repos/acme/widget/issues"); n != 0 {
+		t.Errorf("%d thread requests without engagement, want none", n)
+	}
+	client.GetRepositoryInfo(source.WithEngagement(ctx, true), "acme", "widget")
+	if fake.RequestCount("/repos/acme/widget/issues") == 0 {
+		t.Error("threads not read with engagement")
+	}
+}
+
+func TestGetRepositoryInfoNotFound(t *testing.T) {
+	_, client := newFake(t)
+	info := client.GetRepositoryInfo(context.Background(), "acme", "missing")
+	if !strings.Contains(info.Error, "Failed to fetch repository") {
+		t.Errorf("error = %q, want the repository lookup to fail", info.Error)
+	}
+}
+
+func TestCheckRepoEligibility(t *testing.T) {
+	_, client := newFake(t, widget(time.Now()))
+
+	tests := []struct {
+		name                  string
+		repo                  string
+		minCommits, minActive int
+		days                  int
+		wantEligible          bool
+		wantReason            string
+		wantErr               bool
+	}{
+		{name: "eligible", repo: "widget", minCommits: 150, days: 90, minActive: 4, wantEligible: true},
+		{name: "too few commits", repo: "widget", minCommits: 151, days: 90, minActive: 1, wantReason: "fewer than 151 commits (found 150)"},
+		{name: "too few active", repo: "widget", minCommits: 1, days: 30, minActive: 5, wantReason: "fewer than 5 active contributors in the last 30 days (found 4)"},
+		{name: "unknown repository", repo: "missing", minCommits: 1, days: 30, minActive: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eligible, reason, err := client.CheckRepoEligibility(context.Background(), "acme", tt.repo, tt.minCommits, tt.days, tt.minActive)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eligible != tt.wantEligible || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("got %v %q, want %v %q", eligible, reason, tt.wantEligible, tt.wantReason)
+			}
+		})
+	}
+}
+
+// The statistics are requested again after each 202 until GitHub has computed them
+func TestContributorStatsRetriesWhilePending(t *testing.T) {
+	repo := widget(time.Now())
+	repo.StatsPending = 1
+	fake, client := newFake(t, repo)
+
+	stats, err := client.ContributorStats(context.Background(), "acme", "widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Errorf("statistics of %d contributors, want 3", len(stats))
+	}
+	if n := fake.RequestCount("/repos/acme/widget/stats/contributors"); n != 2 {
+		t.Errorf("%d statistics requests, want a 202 and a retry", n)
+	}
+}
+
+// Pull requests are read page after page by following the Link header, up to the limit
+func TestPullRequestsFollowLinks(t *testing.T) {
+	tests := []struct {
+		max      int
+		want     int
+		searches int
+	}{
+		{max: 0, want: 120, searches: 2},
+		{max: 150, want: 120, searches: 2},
+		{max: 50, want: 50, searches: 1},
+	}
+	for _, tt := range tests {
+		fake, client := newFake(t, widget(time.Now()))
+		prs, err := client.PullRequests(context.Background(), "acme", "widget", tt.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(prs) != tt.want {
+			t.Errorf("max %d: %d pull requests, want %d", tt.max, len(prs), tt.want)
+		}
+		if n := fake.RequestCount("/search/issues"); n != tt.searches {
+			t.Errorf("max %d: %d search pages, want %d", tt.max, n, tt.searches)
+		}
+		for i := 1; i < len(prs); i++ {
+			if !prs[i].CreatedAt.Before(*prs[i-1].CreatedAt) {
+				t.Fatalf("max %d: pull requests not newest first at %d", tt.max, i)
+			}
+		}
+	}
+}
+
+// The GraphQL backend extracts the same repository as the REST one
+func TestGraphQLMatchesREST(t *testing.T) {
+	fake, rest := newFake(t, widget(time.Now()))
+	opts := fake.Options()
+	opts.API = github.APIGraphQL
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	graphql, err := github.NewClient(opts, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := source.WithSelection(context.Background(), models.SelectionPolicy{Strategy: "all"})
+
+	want := rest.GetRepositoryInfo(ctx, "acme", "widget")
+	if want.Error != "" {
+		t.Fatalf("REST extraction failed: %s", want.Error)
+	}
+	searches := fake.RequestCount("/search/issues")
+	got := graphql.GetRepositoryInfo(ctx, "acme", "widget")
+	if got.Error != "" {
+		t.Fatalf("GraphQL extraction failed: %s", got.Error)
+	}
+	// Two pages of pull requests, one profile batch and one following batch
+	if n := fake.RequestCount("/graphql"); n != 4 || fake.RequestCount("/search/issues") != searches {
+		t.Errorf("%d GraphQL queries and %d more searches, want 4 queries and no search", n, fake.RequestCount("/search/issues")-searches)
+	}
+
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("GraphQL extraction differs from REST:\n got %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+// Snapshots are refused in clone mode before anything is fetched
+func TestCloneModeRejectsAsOf(t *testing.T) {
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	fake.AddRepository(widget(time.Now()))
+	opts := fake.Options()
+	opts.CloneDir = t.TempDir()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(opts, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := source.WithAsOf(context.Background(), time.Now().AddDate(0, -1, 0))
+
+	if info := client.GetRepositoryInfo(ctx, "acme", "widget"); !strings.Contains(info.Error, "clone mode") {
+		t.Errorf("extraction error = %q, want as_of refused in clone mode", info.Error)
+	}
+	if _, _, err := client.CheckRepoEligibility(ctx, "acme", "widget", 1, 30, 1); err == nil || !strings.Contains(err.Error(), "clone mode") {
+		t.Errorf("eligibility error = %v, want as_of refused in clone mode", err)
+	}
+	if _, err := client.EstimateCost(ctx, "acme", "widget"); err == nil || !strings.Contains(err.Error(), "clone mode") {
+		t.Errorf("estimate error = %v, want as_of refused in clone mode", err)
+	}
+	if n := len(fake.Requests()); n != 0 {
+		t.Errorf("%d requests made, want none", n)
+	}
+}
+
+// Threads reads the most recent issues and pull requests with their comments, review
+// comments and submitted reviews
+func TestThreads(t *testing.T) {
+	now := time.Now()
+	repo := widget(now)
+	repo.Threads = []githubtest.Thread{
+		{Number: 1, Author: "alice", Reactions: 2, UpdatedAt: now.Add(-3 * time.Hour), Comments: []githubtest.Comment{
+			{Author: "bob", Reactions: 1},
+			{Author: "alice"},
+			{Author: "dependabot[bot]"},
+			{Author: ""},
+		}},
+		{Number: 2, Pull: true, Author: "carol", UpdatedAt: now.Add(-time.Hour),
+			ReviewComments: []githubtest.Comment{{Author: "alice", Reactions: 3}},
+			Reviews:        []githubtest.Review{{Author: "bob", State: "APPROVED"}, {Author: "alice", State: "PENDING"}},
+		},
+		{Number: 3, Author: "bob", UpdatedAt: now.Add(-2 * time.Hour)},
+		{Number: 4, Author: "carol", UpdatedAt: now.Add(-48 * time.Hour), Comments: []githubtest.Comment{{Author: "alice"}}},
+	}
+	fake, client := newFake(t, repo)
+
+	threads, err := client.Threads(context.Background(), "acme", "widget", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := func(th source.Thread) string {
+		var responses []string
+		for _, r := range th.Responses {
+			responses = append(responses, fmt.Sprintf("%s:%s:%d", r.Kind, r.Author, r.Reactions))
+		}
+		return fmt.Sprintf("%s(%d) [%s]", th.Author, th.Reactions, strings.Join(responses, " "))
+	}
+	want := []string{
+		"carol(0) [review_comment:alice:3 review:bob:0]",
+		"bob(0) []",
+		"alice(2) [comment:bob:1 comment:alice:0 comment:dependabot[bot]:0 comment::0]",
+	}
+	if len(threads) != len(want) {
+		t.Fatalf("%d threads, want the %d most recently updated", len(threads), len(want))
+	}
+	for i := range want {
+		if got := format(threads[i]); got != want[i] {
+			t.Errorf("thread %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	// Issues without comments and beyond the limit are not read
+	for _, path := range []string{"/repos/acme/widget/issues/3/comments", "/repos/acme/widget/issues/4/comments", "/repos/acme/widget/pulls/1/"} {
+		if n := fake.RequestCount(path); n != 0 {
+			t.Errorf("%d requests to %s, want none", n, path)
+		}
+	}
+
+	e := source.SummarizeEngagement(threads)
+	if e.RespondedThreads != 2 || len(e.Contributors) != 3 || len(e.Collaboration) != 3 {
+		t.Errorf("engagement = %+v, want 2 responded threads, 3 participants and 3 edges", e)
+	}
+}
@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github-extractor/gitlocal"
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// noreplyEmail matches the private commit emails GitHub assigns, which carry the login
+var noreplyEmail = regexp.MustCompile(`^(?:\d+\+)?([a-z0-9-]+(?:\[bot\])?)@users\.noreply\.github\.com$`)
+
+// repoName matches the characters GitHub allows in account and repository names
+var repoName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// checkRepoPath rejects an owner or repository name that could leave its directory when
+// joined into a path, such as one with a separator or made only of dots
+func checkRepoPath(owner, repo string) error {
+	for _, name := range []string{owner, repo} {
+		if !repoName.MatchString(name) || name == "." || name == ".." {
+			return fmt.Errorf("invalid repository name %q", owner+"/"+repo)
+		}
+	}
+	return nil
+}
+
+// errCloneAsOf rejects snapshots in clone mode. A snapshot ranks its contributors from
+// the contributor statistics, which the clone keys by email for authors without a noreply
+// address, and emails cannot be profiled or looked up in the follow graph.
+var errCloneAsOf = errors.New("as_of cannot be combined with clone mode")
+
+// checkCloneAsOf returns errCloneAsOf for a snapshot date attached to ctx in clone mode
+func (c *Client) checkCloneAsOf(ctx context.Context) error {
+	if c.cloneDir != "" && !source.AsOfFrom(ctx).IsZero() {
+		return errCloneAsOf
+	}
+	return nil
+}
+
+// cloneRepository makes an up-to-date local clone of owner/repo under the clone
+// directory. It returns nil when clone mode is off or the clone failed, in which case
+// the extraction falls back to the API.
+func (c *Client) cloneRepository(ctx context.Context, owner, repo string) *gitlocal.Repo {
+	if c.cloneDir == "" {
+		return nil
+	}
+	if err := checkRepoPath(owner, repo); err != nil {
+		c.logger.Warnf("Local clone of %s/%s skipped, using the API instead: %v", owner, repo, err)
+		return nil
+	}
+
+	dir := filepath.Join(c.cloneDir, c.host, strings.ToLower(owner), strings.ToLower(repo)+".git")
+	remote := "https://" + c.host + "/" + owner + "/" + repo + ".git"
+	token, err := c.pool.tokens[0].credential(ctx)
+	if err != nil {
+		c.logger.Warnf("Local clone of %s/%s skipped, using the API instead: %v", owner, repo, err)
+		return nil
+	}
+	local, err := gitlocal.Sync(ctx, remote, dir, token)
+	if err != nil {
+		c.logger.Warnf("Local clone of %s/%s failed, using the API instead: %v", owner, repo, err)
+		return nil
+	}
+	progressFrom(ctx).report(ProgressClone, "repository cloned to %s", local.Dir())
+	return local
+}
+
+type cloneKey struct{}
+
+// withClone returns a copy of ctx whose contributor statistics are read from local, or ctx
+// itself when local is nil
+func withClone(ctx context.Context, local *gitlocal.Repo) context.Context {
+	if local == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, cloneKey{}, local)
+}
+
+// cloneFrom returns the local clone attached to ctx, or nil
+func cloneFrom(ctx context.Context) *gitlocal.Repo {
+	local, _ := ctx.Value(cloneKey{}).(*gitlocal.Repo)
+	return local
+}
+
+// localContributorStats derives the stats/contributors data from a local clone. Authors
+// are identified by login when their email is a GitHub noreply address and by
+// lowercased email otherwise.
+func localContributorStats(ctx context.Context, local *gitlocal.Repo) ([]models.ContributorStats, error) {
+	commits, err := local.Commits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range commits {
+		if m := noreplyEmail.FindStringSubmatch(strings.ToLower(commits[i].Email)); m != nil {
+			commits[i].Login = m[1]
+		}
+	}
+	return source.SummarizeCommits(commits).Stats, nil
+}
@@ -0,0 +1,107 @@
+package github
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/gitlocal"
+)
+
+func TestCheckRepoPath(t *testing.T) {
+	tests := []struct {
+		owner, repo string
+		valid       bool
+	}{
+		{"golang", "go", true},
+		{"my-org", "repo.name_2", true},
+		{"acme", ".github", true},
+		{"acme", "..widget", true},
+		{"..", "repo", false},
+		{"acme", "..", false},
+		{"acme", ".", false},
+		{"acme", "", false},
+		{"", "repo", false},
+		{"acme", "../../etc", false},
+		{"acme/x", "repo", false},
+		{"acme", `a\\b`, false},
+		{"acme", "a b", false},
+	}
+	for _, tt := range tests {
+		if err := checkRepoPath(tt.owner, tt.repo); (err == nil) != tt.valid {
+			t.Errorf("checkRepoPath(%q, %q) = %v, want valid %v", tt.owner, tt.repo, err, tt.valid)
+		}
+	}
+}
+
+func TestArchiveRejectsPathNames(t *testing.T) {
+	recorder := newArchiver(t.TempDir(), "github.com", false, time.Time{})
+	if _, err := recorder.archive("..", "repo"); err == nil {
+		t.Error("archive of ../repo created")
+	}
+}
+
+// Clone statistics key noreply authors by the login in their email, and others by email
+func TestLocalContributorStats(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+			"GIT_COMMITTER_NAME=Committer", "GIT_COMMITTER_EMAIL=committer@example.com")
+		cmd.Env = append(cmd.Env, env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git(nil, "init", "--quiet")
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, author := range []string{
+		"Alice <12345+Alice@users.noreply.github.com>",
+		"Alice <alice@users.noreply.github.com>",
+		"Dependabot <49699333+dependabot[bot]@users.noreply.github.com>",
+		"Bob <Bob@Example.com>",
+		"Mallory <mallory@users.noreply.github.com.example.com>",
+	} {
+		date = date.AddDate(0, 0, 1)
+		stamp := date.Format(time.RFC3339)
+		git([]string{"GIT_AUTHOR_DATE=" + stamp, "GIT_COMMITTER_DATE=" + stamp},
+			"commit", "--quiet", "--allow-empty", "--author", author, "-m", "change")
+	}
+
+	ctx := context.Background()
+	local, err := gitlocal.Open(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := localContributorStats(ctx, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, s := range stats {
+		got[s.Author] = s.Total
+	}
+	want := map[string]int{
+		"alice":           2,
+		"dependabot[bot]": 1,
+		"bob@example.com": 1,
+		"mallory@users.noreply.github.com.example.com": 1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("authors = %v, want %v", got, want)
+	}
+	for author, n := range want {
+		if got[author] != n {
+			t.Errorf("%s has %d commits, want %d", author, got[author], n)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	gith "github.com/google/go-github/v57/github"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// Fixed number of core requests GetRepositoryInfo spends regardless of the repository size:
+// repository, community profile, security policy (up to 2), commit count, a page of
+// milestones and contributor stats (up to 9 attempts while GitHub computes them).
+const baseExtractionCost = 15
+
+// Cost is the estimated number of API requests an extraction will spend
+type Cost struct {
+	Core    int `json:"core"`
+	Search  int `json:"search"`
+	GraphQL int `json:"graphql"`
+
+	// Sizes the estimate is based on
+	Commits      int `json:"commits"`
+	Contributors int `json:"contributors"`
+	PullRequests int `json:"pull_requests"`
+}
+
+// Quota is the quota left for a rate limit resource across every pooled token
+type Quota struct {
+	Remaining int       `json:"remaining"`
+	Limit     int       `json:"limit"`
+	Reset     time.Time `json:"reset"` // Earliest time a depleted token gets its quota back
+}
+
+// EstimateCost probes the commit, contributor and pull request counts of a repository
+// (3 requests) and estimates how many requests GetRepositoryInfo will spend on it.
+func (c *Client) EstimateCost(ctx context.Context, owner, repo string) (Cost, error) {
+	if err := c.checkCloneAsOf(ctx); err != nil {
+		return Cost{}, err
+	}
+	ctx, err := c.archived(ctx, owner, repo)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	commits, err := c.getCommitCount(ctx, owner, repo)
+	if err != nil {
+		return Cost{}, fmt.Errorf("count commits: %w", err)
+	}
+
+	_, resp, err := c.client.Repositories.ListContributors(ctx, owner, repo, &gith.ListContributorsOptions{
+		Anon:        "true",
+		ListOptions: gith.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return Cost{}, fmt.Errorf("count contributors: %w", err)
+	}
+	contributors := resp.LastPage
+	if contributors == 0 {
+		contributors = 1
+	}
+
+	result, _, err := c.client.Search.Issues(ctx, pullRequestQuery(ctx, owner, repo), &gith.SearchOptions{ListOptions: gith.ListOptions{PerPage: 1}})
+	if err != nil {
+		return Cost{}, fmt.Errorf("count pull requests: %w", err)
+	}
+	pullRequests := result.GetTotal()
+
+	return estimateCost(commits, contributors, pullRequests, source.SelectionFrom(ctx), source.EngagementFrom(ctx), c.useGraphQL), nil
+}
+
+// estimateCost turns repository sizes into a request estimate for the REST or GraphQL backend
+// when contributors are selected with policy, and threads are read when engagement is set
+func estimateCost(commits, contributors, pullRequests int, policy models.SelectionPolicy, engagement, graphQL bool) Cost {
+	pages := func(n int) int { return (n + 99) / 100 }
+
+	prs := pullRequests
+	if prs > source.MaxPullRequests {
+		prs = source.MaxPullRequests
+	}
+
+	// Profiles are fetched for the top contributors the policy keeps plus the recent ones;
+	// assume sqrt(total) recent contributors. Each also costs at least one page of its
+	// following list.
+	selected := source.SelectionLimit(policy, contributors)
+	recentCommits := 0
+	if policy.Days > 0 {
+		selected += int(math.Ceil(math.Sqrt(float64(contributors))))
+
+		// The recent commit scan is bounded by the total commit count
+		recentCommits = min(commits, 1000)
+	}
+	if selected > contributors {
+		selected = contributors
+	}
+
+	cost := Cost{
+		Core:         baseExtractionCost + pages(contributors) + pages(recentCommits),
+		Commits:      commits,
+		Contributors: contributors,
+		PullRequests: pullRequests,
+	}
+	if graphQL {
+		// One query per page of PRs and per batch of profiles and follow lists
+		cost.GraphQL = pages(prs) +
+			(selected+profileBatchSize-1)/profileBatchSize +
+			(selected+followBatchSize-1)/followBatchSize
+		if engagement {
+			// One query per batch of threads and of discussions
+			cost.GraphQL += (source.MaxThreads+threadBatchSize-1)/threadBatchSize +
+				(source.MaxThreads+discussionBatchSize-1)/discussionBatchSize
+		}
+	} else {
+		cost.Core += 2*selected + prs
+		cost.Search = pages(prs)
+		if engagement {
+			// Threads are listed by the page, then each costs up to three requests for
+			// its comments, review comments and reviews
+			cost.Core += pages(source.MaxThreads) + 3*source.MaxThreads
+		}
+	}
+	return cost
+}
+
+// Quota refreshes the token pool and returns the quota left for resource across all tokens.
+// Tokens whose reset time has passed count with their full limit.
+func (c *Client) Quota(ctx context.Context, resource string) (Quota, error) {
+	statuses, err := c.TokenStatus(ctx)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	now := time.Now()
+	var q Quota
+	for _, s := range statuses {
+		var r RateStatus
+		switch resource {
+		case ResourceSearch:
+			r = s.Search
+		case ResourceGraphQL:
+			r = s.GraphQL
+		default:
+			r = s.Core
+		}
+
+		q.Limit += r.Limit
+		if now.After(r.Reset) {
+			q.Remaining += r.Limit
+			continue
+		}
+		q.Remaining += r.Remaining
+		if r.Remaining < r.Limit && (q.Reset.IsZero() || r.Reset.Before(q.Reset)) {
+			q.Reset = r.Reset
+		}
+	}
+	return q, nil
+}
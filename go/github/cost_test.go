@@ -0,0 +1,92 @@
+package github
+
+import (
+	"testing"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+func TestEstimateCost(t *testing.T) {
+	sqrt := models.SelectionPolicy{Strategy: source.SelectSqrt}
+	recent := models.SelectionPolicy{Strategy: source.SelectSqrt, Days: 90}
+	threadQueries := (source.MaxThreads+threadBatchSize-1)/threadBatchSize + (source.MaxThreads+discussionBatchSize-1)/discussionBatchSize
+
+	tests := []struct {
+		name         string
+		prs          int
+		policy       models.SelectionPolicy
+		engagement   bool
+		graphQL      bool
+		core, search int
+		graphQLCost  int // Queries beyond those for the pull requests, profiles and follow lists
+	}{
+		{
+			// 15 fixed + 4 contributor pages + 2 requests for each of 20 profiles + 250 PRs
+			name:   "rest",
+			prs:    250,
+			policy: sqrt,
+			core:   15 + 4 + 40 + 250,
+			search: 3,
+		},
+		{
+			name:       "rest with engagement",
+			prs:        250,
+			policy:     sqrt,
+			engagement: true,
+			core:       15 + 4 + 40 + 250 + 1 + 3*source.MaxThreads,
+			search:     3,
+		},
+		{
+			// 20 more recent contributors and 10 pages of recent commits
+			name:   "rest with recent contributors",
+			prs:    250,
+			policy: recent,
+			core:   15 + 4 + 10 + 80 + 250,
+			search: 3,
+		},
+		{
+			name:   "pull requests are capped",
+			prs:    5000,
+			policy: sqrt,
+			core:   15 + 4 + 40 + source.MaxPullRequests,
+			search: 10,
+		},
+		{
+			name:    "graphql",
+			prs:     250,
+			policy:  sqrt,
+			graphQL: true,
+			core:    15 + 4,
+		},
+		{
+			name:        "graphql with engagement",
+			prs:         250,
+			policy:      sqrt,
+			engagement:  true,
+			graphQL:     true,
+			core:        15 + 4,
+			graphQLCost: threadQueries,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost := estimateCost(5000, 400, tt.prs, tt.policy, tt.engagement, tt.graphQL)
+			if cost.Core != tt.core || cost.Search != tt.search {
+				t.Errorf("core %d, search %d; want %d and %d", cost.Core, cost.Search, tt.core, tt.search)
+			}
+			if tt.graphQL {
+				base := estimateCost(5000, 400, tt.prs, tt.policy, false, true).GraphQL
+				if cost.GraphQL-base != tt.graphQLCost {
+					t.Errorf("engagement adds %d queries, want %d", cost.GraphQL-base, tt.graphQLCost)
+				}
+			} else if cost.GraphQL != 0 {
+				t.Errorf("graphql = %d on the REST backend", cost.GraphQL)
+			}
+			if cost.Commits != 5000 || cost.Contributors != 400 || cost.PullRequests != tt.prs {
+				t.Errorf("sizes = %+v", cost)
+			}
+		})
+	}
+}
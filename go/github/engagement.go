@@ -0,0 +1,160 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github-extractor/source"
+
+	gith "github.com/google/go-github/v57/github"
+)
+
+// Threads returns up to max of the most recently updated issues and pull requests with
+// their comments, review comments and reviews, and with GraphQL up to max discussions
+func (c *Client) Threads(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	var threads []source.Thread
+	var err error
+	if c.useGraphQL {
+		threads, err = c.getThreadsGraphQL(ctx, owner, repo, max)
+	} else {
+		threads, err = c.getThreads(ctx, owner, repo, max)
+	}
+	if err != nil {
+		c.logger.Warnf("Failed to fetch threads: %v", err)
+		return nil, err
+	}
+	progressFrom(ctx).report(ProgressThreads, "threads fetched (%d)", len(threads))
+	return threads, nil
+}
+
+// getThreads lists the most recently updated issues and pull requests, then reads the
+// first source.MaxResponses comments of each, and the review comments and reviews of
+// pull requests, five threads at a time. Discussions have no REST API and are left out.
+func (c *Client) getThreads(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	progress := progressFrom(ctx)
+
+	type item struct {
+		number   int
+		pull     bool
+		comments int
+	}
+	var items []item
+	var threads []source.Thread
+
+	opts := &gith.IssueListByRepoOptions{
+		State:       "all",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: gith.ListOptions{PerPage: 100},
+	}
+	for len(threads) < max {
+		issues, resp, err := c.client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if len(threads) == max {
+				break
+			}
+			threads = append(threads, source.Thread{
+				Author:    issue.GetUser().GetLogin(),
+				Reactions: issue.GetReactions().GetTotalCount(),
+			})
+			items = append(items, item{number: issue.GetNumber(), pull: issue.IsPullRequest(), comments: issue.GetComments()})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+	var done, failed int32
+	var firstErr error
+	var errOnce sync.Once
+
+	for i := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				progress.reportCount(ProgressThreads, "threads", int(atomic.AddInt32(&done, 1)), len(items))
+			}()
+
+			responses, err := c.threadResponses(ctx, owner, repo, items[idx].number, items[idx].pull, items[idx].comments > 0)
+			if err != nil {
+				c.logger.Debugf("Failed to fetch the responses to %s/%s#%d: %v", owner, repo, items[idx].number, err)
+				atomic.AddInt32(&failed, 1)
+				errOnce.Do(func() { firstErr = err })
+				return
+			}
+			threads[idx].Responses = responses
+		}(i)
+	}
+	wg.Wait()
+
+	if len(items) > 0 && int(failed) == len(items) {
+		return nil, fmt.Errorf("all thread response requests failed. First error: %v", firstErr)
+	}
+	return threads, nil
+}
+
+// threadResponses reads the first page of comments of an issue or pull request, and of
+// review comments and reviews of a pull request
+func (c *Client) threadResponses(ctx context.Context, owner, repo string, number int, pull, commented bool) ([]source.Response, error) {
+	var responses []source.Response
+
+	if commented {
+		comments, _, err := c.client.Issues.ListComments(ctx, owner, repo, number, &gith.IssueListCommentsOptions{
+			ListOptions: gith.ListOptions{PerPage: source.MaxResponses},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			responses = append(responses, source.Response{
+				Kind:      source.ResponseComment,
+				Author:    comment.GetUser().GetLogin(),
+				Reactions: comment.GetReactions().GetTotalCount(),
+			})
+		}
+	}
+	if !pull {
+		return responses, nil
+	}
+
+	reviewComments, _, err := c.client.PullRequests.ListComments(ctx, owner, repo, number, &gith.PullRequestListCommentsOptions{
+		ListOptions: gith.ListOptions{PerPage: source.MaxResponses},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range reviewComments {
+		responses = append(responses, source.Response{
+			Kind:      source.ResponseReviewComment,
+			Author:    comment.GetUser().GetLogin(),
+			Reactions: comment.GetReactions().GetTotalCount(),
+		})
+	}
+
+	reviews, _, err := c.client.PullRequests.ListReviews(ctx, owner, repo, number, &gith.ListOptions{PerPage: source.MaxResponses})
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		// Pending reviews are drafts nobody else sees yet
+		if review.GetState() == "PENDING" {
+			continue
+		}
+		responses = append(responses, source.Response{
+			Kind:   source.ResponseReview,
+			Author: review.GetUser().GetLogin(),
+		})
+	}
+	return responses, nil
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultHost is the host repositories are extracted from when Options.BaseURL is unset
+const DefaultHost = "github.com"
+
+// enterpriseURLs derives the REST, upload and GraphQL endpoints and the web host of a
+// GitHub Enterprise Server instance from opts. BaseURL may be the REST endpoint
+// (https://ghe.example.com/api/v3/) or just the instance (https://ghe.example.com);
+// the other endpoints default to their standard paths on the same instance.
+func enterpriseURLs(opts Options) (baseURL, uploadURL, graphqlURL, host string, err error) {
+	u, err := url.Parse(strings.TrimSuffix(opts.BaseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", "", "", "", fmt.Errorf("invalid GitHub base URL %q", opts.BaseURL)
+	}
+
+	host = u.Host
+	root := u.Scheme + "://" + u.Host
+	if strings.HasSuffix(u.Path, "/api/v3") {
+		root += strings.TrimSuffix(u.Path, "/api/v3")
+	} else {
+		root += u.Path
+	}
+
+	baseURL = root + "/api/v3/"
+	uploadURL = root + "/api/uploads/"
+	graphqlURL = root + "/api/graphql"
+	if opts.UploadURL != "" {
+		uploadURL = opts.UploadURL
+	}
+	if opts.GraphQLURL != "" {
+		graphqlURL = opts.GraphQLURL
+	}
+	return baseURL, uploadURL, graphqlURL, host, nil
+}
+
+// configureTransport applies the CA bundle and proxy of opts to the base transport
+func configureTransport(t *http.Transport, opts Options) error {
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return fmt.Errorf("read CA bundle: %w", err)
+		}
+		// Trust the bundle on top of the system roots, so public endpoints keep working
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA bundle %s", opts.CABundle)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return nil
+}
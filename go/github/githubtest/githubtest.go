@@ -0,0 +1,698 @@
+// Package githubtest provides an in-process fake of the GitHub REST and GraphQL APIs for
+// offline end-to-end tests of the extractor. A Server serves the repositories and users it
+// is given, with GitHub's pagination, rate limit headers and 202 responses for contributor
+// statistics that are still being computed. Point a client at it with Server.Options.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/github"
+)
+
+// Repository is a repository the fake serves
+type Repository struct {
+	Owner         string
+	Repo          string
+	Description   string
+	Language      string
+	License       string // License name; empty for none
+	DefaultBranch string // Defaults to "main"
+	Stars         int
+	Forks         int
+	OpenIssues    int
+	Watchers      int
+	Size          int
+	HasIssues     bool
+	HasWiki       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// Files are the paths of the repository files the contents API finds, such as
+	// "SECURITY.md". README.md, CODE_OF_CONDUCT.md, CONTRIBUTING.md and the issue and
+	// pull request templates among them also appear in the community profile.
+	Files []string
+
+	Commits      []Commit      // In any order; served newest first
+	Contributors []Contributor // Ordered by contributions, as GitHub lists them
+	Stats        []ContributorStats
+	// StatsPending is how many stats/contributors requests get a 202 while GitHub
+	// "computes" the statistics, before they are served
+	StatsPending int
+	Milestones   []Milestone
+	PullRequests []PullRequest
+	Threads      []Thread // Issues and pull request conversations, for engagement
+}
+
+// Commit is a commit of a repository
+type Commit struct {
+	SHA         string
+	AuthorLogin string // Empty for commits not linked to an account
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+}
+
+// Contributor is an entry of the contributors list. An empty Login is an anonymous
+// contributor, identified by Name and Email.
+type Contributor struct {
+	Login         string
+	Name          string
+	Email         string
+	Contributions int
+}
+
+// ContributorStats is the weekly commit activity of a contributor
+type ContributorStats struct {
+	Author string
+	Weeks  []Week
+}
+
+// Week is the activity of a contributor in the week starting at Start
+type Week struct {
+	Start     time.Time
+	Additions int
+	Deletions int
+	Commits   int
+}
+
+// Milestone is a milestone of a repository
+type Milestone struct {
+	Title     string
+	State     string // "open" or "closed"
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+}
+
+// PullRequest is a pull request of a repository. Only closed pull requests are found
+// by the issue search.
+type PullRequest struct {
+	Number    int
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+	MergedAt  *time.Time
+}
+
+// Thread is an issue or pull request conversation the REST issues API lists, most
+// recently updated first
+type Thread struct {
+	Number         int
+	Pull           bool
+	Author         string // Empty for a deleted account
+	Reactions      int
+	UpdatedAt      time.Time
+	Comments       []Comment // Conversation comments
+	ReviewComments []Comment // Inline comments on the diff of a pull request
+	Reviews        []Review
+}
+
+// Comment is a comment posted in a thread
+type Comment struct {
+	Author    string // Empty for a deleted account
+	Reactions int
+}
+
+// Review is a pull request review
+type Review struct {
+	Author string
+	State  string // Defaults to "COMMENTED"
+}
+
+// User is an account the fake serves profiles and following lists for
+type User struct {
+	Login     string
+	Type      string // Defaults to "User"
+	Name      string
+	Company   string
+	Blog      string
+	Location  string
+	Email     string
+	Bio       string
+	Followers int
+	Following int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Follows   []string // Logins the user follows
+}
+
+// RateLimit is the quota the fake reports in rate limit headers and on /rate_limit
+const RateLimit = 5000
+
+// Server is a fake GitHub API. Its zero value is not usable; create one with NewServer.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	repos       map[string]*Repository
+	users       map[string]*User
+	statsServed map[string]int
+	requests    []string
+}
+
+// NewServer starts a fake serving no repositories or users yet. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		repos:       make(map[string]*Repository),
+		users:       make(map[string]*User),
+		statsServed: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Options returns client options that send every REST and GraphQL request to the fake,
+// with a placeholder token
+func (s *Server) Options() github.Options {
+	return github.Options{Tokens: []string{"githubtest-token"}, APIURL: s.URL + "/"}
+}
+
+// AddRepository serves r, replacing any repository with the same owner and name
+func (s *Server) AddRepository(r Repository) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[strings.ToLower(r.Owner+"/"+r.Repo)] = &r
+}
+
+// AddUser serves u, replacing any user with the same login
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[strings.ToLower(u.Login)] = &u
+}
+
+// Requests returns the method and request URI of every request served so far, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// RequestCount returns how many requests were served for paths starting with prefix
+func (s *Server) RequestCount(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		_, uri, _ := strings.Cut(r, " ")
+		if strings.HasPrefix(uri, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-RateLimit-Limit", strconv.Itoa(RateLimit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(RateLimit-1))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	h.Set("X-RateLimit-Resource", "core")
+
+	if r.Method == http.MethodPost && r.URL.Path == "/graphql" {
+		s.serveGraphQL(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		notFound(w)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "rate_limit":
+		s.serveRateLimit(w)
+	case len(parts) == 2 && parts[0] == "search" && parts[1] == "issues":
+		s.serveSearch(w, r)
+	case len(parts) >= 2 && parts[0] == "users":
+		s.serveUser(w, r, parts[1], parts[2:])
+	case len(parts) >= 3 && parts[0] == "repos":
+		repo, ok := s.repos[strings.ToLower(parts[1]+"/"+parts[2])]
+		if !ok {
+			notFound(w)
+			return
+		}
+		s.serveRepository(w, r, repo, parts[3:])
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) serveRepository(w http.ResponseWriter, r *http.Request, repo *Repository, rest []string) {
+	if len(rest) == 0 {
+		writeJSON(w, http.StatusOK, repositoryJSON(repo))
+		return
+	}
+
+	switch rest[0] {
+	case "community":
+		files := map[string]interface{}{}
+		for key, path := range map[string]string{
+			"readme":                "README.md",
+			"code_of_conduct":       "CODE_OF_CONDUCT.md",
+			"contributing":          "CONTRIBUTING.md",
+			"issue_template":        ".github/ISSUE_TEMPLATE.md",
+			"pull_request_template": ".github/PULL_REQUEST_TEMPLATE.md",
+		} {
+			if repo.hasFile(path) {
+				files[key] = map[string]string{"url": s.URL + "/repos/" + repo.Owner + "/" + repo.Repo + "/contents/" + path}
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"files": files})
+
+	case "contents":
+		path := strings.Join(rest[1:], "/")
+		if !repo.hasFile(path) {
+			notFound(w)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"type": "file", "name": path[strings.LastIndex(path, "/")+1:], "path": path})
+
+	case "commits":
+		s.serveCommits(w, r, repo)
+
+	case "contributors":
+		var items []interface{}
+		for _, c := range repo.Contributors {
+			if c.Login == "" {
+				if r.URL.Query().Get("anon") == "" {
+					continue
+				}
+				items = append(items, map[string]interface{}{"type": "Anonymous", "name": c.Name, "email": c.Email, "contributions": c.Contributions})
+				continue
+			}
+			items = append(items, map[string]interface{}{"login": c.Login, "type": "User", "contributions": c.Contributions})
+		}
+		s.writePage(w, r, items)
+
+	case "stats":
+		if len(rest) != 2 || rest[1] != "contributors" {
+			notFound(w)
+			return
+		}
+		key := strings.ToLower(repo.Owner + "/" + repo.Repo)
+		if s.statsServed[key] < repo.StatsPending {
+			s.statsServed[key]++
+			w.Header().Set("Retry-After", "1")
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{})
+			return
+		}
+		items := []interface{}{}
+		for _, st := range repo.Stats {
+			total := 0
+			weeks := []interface{}{}
+			for _, wk := range st.Weeks {
+				total += wk.Commits
+				weeks = append(weeks, map[string]interface{}{"w": wk.Start.Unix(), "a": wk.Additions, "d": wk.Deletions, "c": wk.Commits})
+			}
+			items = append(items, map[string]interface{}{"author": map[string]string{"login": st.Author}, "total": total, "weeks": weeks})
+		}
+		writeJSON(w, http.StatusOK, items)
+
+	case "milestones":
+		state := r.URL.Query().Get("state")
+		if state == "" {
+			state = "open"
+		}
+		var items []interface{}
+		for i, m := range repo.Milestones {
+			if state == "all" || m.State == state {
+				item := map[string]interface{}{"number": i + 1, "title": m.Title, "state": m.State}
+				if !m.CreatedAt.IsZero() {
+					item["created_at"] = m.CreatedAt.UTC().Format(time.RFC3339)
+				}
+				if m.ClosedAt != nil {
+					item["closed_at"] = m.ClosedAt.UTC().Format(time.RFC3339)
+				}
+				items = append(items, item)
+			}
+		}
+		s.writePage(w, r, items)
+
+	case "pulls":
+		if len(rest) == 3 {
+			s.serveResponses(w, r, repo, true, rest[1], rest[2])
+			return
+		}
+		if len(rest) == 2 {
+			number, _ := strconv.Atoi(rest[1])
+			for _, pr := range repo.PullRequests {
+				if pr.Number == number {
+					writeJSON(w, http.StatusOK, pullRequestJSON(pr))
+					return
+				}
+			}
+			notFound(w)
+			return
+		}
+		s.writePage(w, r, nil)
+
+	case "issues":
+		switch len(rest) {
+		case 1:
+			s.serveThreads(w, r, repo)
+		case 3:
+			s.serveResponses(w, r, repo, false, rest[1], rest[2])
+		default:
+			notFound(w)
+		}
+
+	default:
+		notFound(w)
+	}
+}
+
+// serveCommits lists the commits of repo newest first, filtered by the since, until
+// and author parameters
+func (s *Server) serveCommits(w http.ResponseWriter, r *http.Request, repo *Repository) {
+	q := r.URL.Query()
+	since, _ := time.Parse(time.RFC3339, q.Get("since"))
+	until, _ := time.Parse(time.RFC3339, q.Get("until"))
+	author := strings.ToLower(q.Get("author"))
+
+	commits := append([]Commit(nil), repo.Commits...)
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Date.After(commits[j].Date) })
+
+	var items []interface{}
+	for _, c := range commits {
+		if !since.IsZero() && c.Date.Before(since) {
+			continue
+		}
+		if !until.IsZero() && c.Date.After(until) {
+			continue
+		}
+		if author != "" && strings.ToLower(c.AuthorLogin) != author && strings.ToLower(c.AuthorEmail) != author {
+			continue
+		}
+		item := map[string]interface{}{
+			"sha": c.SHA,
+			"commit": map[string]interface{}{
+				"author": map[string]interface{}{"name": c.AuthorName, "email": c.AuthorEmail, "date": c.Date.UTC().Format(time.RFC3339)},
+			},
+		}
+		if c.AuthorLogin != "" {
+			item["author"] = map[string]string{"login": c.AuthorLogin}
+		}
+		items = append(items, item)
+	}
+	s.writePage(w, r, items)
+}
+
+// serveThreads lists the threads of repo as issues, most recently updated first
+func (s *Server) serveThreads(w http.ResponseWriter, r *http.Request, repo *Repository) {
+	threads := append([]Thread(nil), repo.Threads...)
+	sort.SliceStable(threads, func(i, j int) bool { return threads[i].UpdatedAt.After(threads[j].UpdatedAt) })
+
+	var items []interface{}
+	for _, t := range threads {
+		item := map[string]interface{}{
+			"number":     t.Number,
+			"user":       userJSON(t.Author),
+			"comments":   len(t.Comments),
+			"reactions":  map[string]int{"total_count": t.Reactions},
+			"updated_at": t.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if t.Pull {
+			item["pull_request"] = map[string]string{}
+		}
+		items = append(items, item)
+	}
+	s.writePage(w, r, items)
+}
+
+// serveResponses lists the comments of the thread numbered number, or with pull the
+// review comments or reviews of a pull request thread
+func (s *Server) serveResponses(w http.ResponseWriter, r *http.Request, repo *Repository, pull bool, number, kind string) {
+	var thread *Thread
+	for i := range repo.Threads {
+		if strconv.Itoa(repo.Threads[i].Number) == number {
+			thread = &repo.Threads[i]
+		}
+	}
+	if thread == nil || (pull && !thread.Pull) {
+		notFound(w)
+		return
+	}
+
+	var items []interface{}
+	switch {
+	case kind == "comments" && !pull:
+		for _, c := range thread.Comments {
+			items = append(items, map[string]interface{}{"user": userJSON(c.Author), "reactions": map[string]int{"total_count": c.Reactions}})
+		}
+	case kind == "comments":
+		for _, c := range thread.ReviewComments {
+			items = append(items, map[string]interface{}{"user": userJSON(c.Author), "reactions": map[string]int{"total_count": c.Reactions}})
+		}
+	case kind == "reviews" && pull:
+		for _, rv := range thread.Reviews {
+			state := rv.State
+			if state == "" {
+				state = "COMMENTED"
+			}
+			items = append(items, map[string]interface{}{"user": userJSON(rv.Author), "state": state})
+		}
+	default:
+		notFound(w)
+		return
+	}
+	s.writePage(w, r, items)
+}
+
+// serveSearch answers the "repo:owner/name type:pr state:closed" issue search, optionally
+// narrowed with a "closed:<=timestamp" qualifier, with the closed pull requests of the
+// repository, newest first
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	var repo *Repository
+	var closedBy time.Time
+	closedOnly := false
+	for _, term := range strings.Fields(r.URL.Query().Get("q")) {
+		key, value, _ := strings.Cut(term, ":")
+		switch key {
+		case "repo":
+			repo = s.repos[strings.ToLower(value)]
+		case "state":
+			closedOnly = value == "closed"
+		case "closed":
+			closedBy, _ = time.Parse(time.RFC3339, strings.TrimPrefix(value, "<="))
+		}
+	}
+	if repo == nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+		return
+	}
+
+	prs := append([]PullRequest(nil), repo.PullRequests...)
+	sort.SliceStable(prs, func(i, j int) bool { return prs[i].CreatedAt.After(prs[j].CreatedAt) })
+
+	items := []interface{}{}
+	for _, pr := range prs {
+		if closedOnly && pr.ClosedAt == nil {
+			continue
+		}
+		if !closedBy.IsZero() && (pr.ClosedAt == nil || pr.ClosedAt.After(closedBy)) {
+			continue
+		}
+		item := map[string]interface{}{
+			"number":       pr.Number,
+			"state":        "open",
+			"created_at":   pr.CreatedAt.UTC().Format(time.RFC3339),
+			"pull_request": map[string]string{},
+		}
+		if pr.ClosedAt != nil {
+			item["state"] = "closed"
+			item["closed_at"] = pr.ClosedAt.UTC().Format(time.RFC3339)
+		}
+		items = append(items, item)
+	}
+
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(items), page, perPage)
+	setLinks(w, r, len(items), page, perPage)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count":        len(items),
+		"incomplete_results": false,
+		"items":              items[start:end],
+	})
+}
+
+func (s *Server) serveUser(w http.ResponseWriter, r *http.Request, login string, rest []string) {
+	u, ok := s.users[strings.ToLower(login)]
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	switch {
+	case len(rest) == 0:
+		userType := u.Type
+		if userType == "" {
+			userType = "User"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"login":      u.Login,
+			"type":       userType,
+			"name":       u.Name,
+			"company":    u.Company,
+			"blog":       u.Blog,
+			"location":   u.Location,
+			"email":      u.Email,
+			"bio":        u.Bio,
+			"followers":  u.Followers,
+			"following":  u.Following,
+			"html_url":   "https://github.com/" + u.Login,
+			"avatar_url": "https://avatars.githubusercontent.com/" + u.Login,
+			"created_at": u.CreatedAt.UTC().Format(time.RFC3339),
+			"updated_at": u.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	case len(rest) == 1 && rest[0] == "following":
+		var items []interface{}
+		for _, f := range u.Follows {
+			items = append(items, map[string]string{"login": f, "type": "User"})
+		}
+		s.writePage(w, r, items)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) serveRateLimit(w http.ResponseWriter) {
+	reset := time.Now().Add(time.Hour).Unix()
+	limit := map[string]interface{}{"limit": RateLimit, "used": 0, "remaining": RateLimit, "reset": reset}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resources": map[string]interface{}{"core": limit, "search": limit, "graphql": limit},
+		"rate":      limit,
+	})
+}
+
+// writePage writes the page of items the request asks for, with GitHub's Link header
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	page, perPage := pagination(r)
+	start, end := pageBounds(len(items), page, perPage)
+	setLinks(w, r, len(items), page, perPage)
+	writeJSON(w, http.StatusOK, append([]interface{}{}, items[start:end]...))
+}
+
+// pagination reads the page and per_page parameters with GitHub's defaults
+func pagination(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 30
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	return page, perPage
+}
+
+// pageBounds returns the slice bounds of a page of n items
+func pageBounds(n, page, perPage int) (int, int) {
+	start := min((page-1)*perPage, n)
+	return start, min(start+perPage, n)
+}
+
+// setLinks sets the next and last links of a paginated response
+func setLinks(w http.ResponseWriter, r *http.Request, n, page, perPage int) {
+	last := (n + perPage - 1) / perPage
+	if page >= last {
+		return
+	}
+	link := func(p int, rel string) string {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(p))
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: q.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+	w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+}
+
+func (r *Repository) hasFile(path string) bool {
+	for _, f := range r.Files {
+		if strings.EqualFold(f, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func repositoryJSON(r *Repository) map[string]interface{} {
+	branch := r.DefaultBranch
+	if branch == "" {
+		branch = "main"
+	}
+	repo := map[string]interface{}{
+		"name":              r.Repo,
+		"full_name":         r.Owner + "/" + r.Repo,
+		"owner":             map[string]string{"login": r.Owner},
+		"description":       r.Description,
+		"language":          r.Language,
+		"default_branch":    branch,
+		"stargazers_count":  r.Stars,
+		"forks_count":       r.Forks,
+		"open_issues_count": r.OpenIssues,
+		"watchers_count":    r.Watchers,
+		"size":              r.Size,
+		"has_issues":        r.HasIssues,
+		"has_wiki":          r.HasWiki,
+		"created_at":        r.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at":        r.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if r.License != "" {
+		repo["license"] = map[string]string{"name": r.License}
+	}
+	return repo
+}
+
+func pullRequestJSON(pr PullRequest) map[string]interface{} {
+	item := map[string]interface{}{
+		"number":     pr.Number,
+		"state":      "open",
+		"created_at": pr.CreatedAt.UTC().Format(time.RFC3339),
+		"merged":     pr.MergedAt != nil,
+	}
+	if pr.ClosedAt != nil {
+		item["state"] = "closed"
+		item["closed_at"] = pr.ClosedAt.UTC().Format(time.RFC3339)
+	}
+	if pr.MergedAt != nil {
+		item["merged_at"] = pr.MergedAt.UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+// userJSON is the user of a thread or response, null for a deleted account
+func userJSON(login string) interface{} {
+	if login == "" {
+		return nil
+	}
+	return map[string]string{"login": login, "type": "User"}
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{
+		"message":           "Not Found",
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
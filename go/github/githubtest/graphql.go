@@ -0,0 +1,181 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphqlPageSize is the page size of every connection the fake serves, as the extractor
+// always asks for first: 100
+const graphqlPageSize = 100
+
+// serveGraphQL answers the queries the extractor's GraphQL backend sends. Queries are
+// recognised by the connection or fragment they read rather than parsed: the pull requests
+// of a repository, batches of aliased user profiles and following lists, and the issue
+// search and discussions read for engagement, which are served empty: threads are only
+// modelled on the REST API.
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-RateLimit-Resource", "graphql")
+
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	var data map[string]interface{}
+	var errs []interface{}
+	switch {
+	case strings.Contains(req.Query, "pullRequests("):
+		data, errs = s.graphqlPullRequests(req.Variables)
+	case strings.Contains(req.Query, "fragment profile"):
+		data, errs = s.graphqlUsers(req.Variables, s.graphqlProfile)
+	case strings.Contains(req.Query, "fragment edges"):
+		data, errs = s.graphqlUsers(req.Variables, s.graphqlFollowing)
+	case strings.Contains(req.Query, "search("):
+		data = map[string]interface{}{"search": map[string]interface{}{
+			"issueCount": 0,
+			"nodes":      []interface{}{},
+			"pageInfo":   map[string]interface{}{"hasNextPage": false, "endCursor": nil},
+		}}
+	case strings.Contains(req.Query, "discussions("):
+		data = map[string]interface{}{"repository": map[string]interface{}{"discussions": map[string]interface{}{
+			"nodes":    []interface{}{},
+			"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": nil},
+		}}}
+	default:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"errors": []interface{}{map[string]string{"message": "githubtest: unsupported query"}},
+		})
+		return
+	}
+
+	resp := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// graphqlPullRequests serves a page of the closed and merged pull requests of the
+// repository named by the owner and repo variables, newest first
+func (s *Server) graphqlPullRequests(vars map[string]interface{}) (map[string]interface{}, []interface{}) {
+	owner, _ := vars["owner"].(string)
+	name, _ := vars["repo"].(string)
+	repo, ok := s.repos[strings.ToLower(owner+"/"+name)]
+	if !ok {
+		return map[string]interface{}{"repository": nil}, []interface{}{notResolved("repository", fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", owner, name))}
+	}
+
+	prs := append([]PullRequest(nil), repo.PullRequests...)
+	sort.SliceStable(prs, func(i, j int) bool { return prs[i].CreatedAt.After(prs[j].CreatedAt) })
+
+	nodes := []interface{}{}
+	for _, pr := range prs {
+		if pr.ClosedAt == nil {
+			continue
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"number":    pr.Number,
+			"createdAt": pr.CreatedAt.UTC().Format(time.RFC3339),
+			"closedAt":  pr.ClosedAt.UTC().Format(time.RFC3339),
+			"mergedAt":  graphqlTime(pr.MergedAt),
+		})
+	}
+
+	start, end, info := graphqlPage(len(nodes), vars["after"])
+	return map[string]interface{}{"repository": map[string]interface{}{
+		"pullRequests": map[string]interface{}{
+			"totalCount": len(nodes),
+			"nodes":      nodes[start:end],
+			"pageInfo":   info,
+		},
+	}}, nil
+}
+
+// graphqlUsers resolves the aliased u0, u1, ... user fields of a batch query, whose logins
+// are the l0, l1, ... variables. Logins that are not users, such as bots, resolve to null
+// with an error on their path, as on GitHub.
+func (s *Server) graphqlUsers(vars map[string]interface{}, resolve func(u *User, i int, vars map[string]interface{}) interface{}) (map[string]interface{}, []interface{}) {
+	data := map[string]interface{}{}
+	var errs []interface{}
+	for i := 0; ; i++ {
+		login, ok := vars[fmt.Sprintf("l%d", i)].(string)
+		if !ok {
+			break
+		}
+		alias := fmt.Sprintf("u%d", i)
+		u, ok := s.users[strings.ToLower(login)]
+		if !ok || (u.Type != "" && u.Type != "User") {
+			data[alias] = nil
+			errs = append(errs, notResolved(alias, fmt.Sprintf("Could not resolve to a User with the login of '%s'.", login)))
+			continue
+		}
+		data[alias] = resolve(u, i, vars)
+	}
+	return data, errs
+}
+
+// graphqlProfile serves the fields of the profile fragment
+func (s *Server) graphqlProfile(u *User, _ int, _ map[string]interface{}) interface{} {
+	return map[string]interface{}{
+		"avatarUrl":  "https://avatars.githubusercontent.com/" + u.Login,
+		"url":        "https://github.com/" + u.Login,
+		"name":       u.Name,
+		"company":    u.Company,
+		"websiteUrl": u.Blog,
+		"location":   u.Location,
+		"email":      u.Email,
+		"bio":        u.Bio,
+		"createdAt":  u.CreatedAt.UTC().Format(time.RFC3339),
+		"updatedAt":  u.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// graphqlFollowing serves the page of the following list of u after the cursor in the
+// c<i> variable
+func (s *Server) graphqlFollowing(u *User, i int, vars map[string]interface{}) interface{} {
+	start, end, info := graphqlPage(len(u.Follows), vars[fmt.Sprintf("c%d", i)])
+	nodes := []interface{}{}
+	for _, f := range u.Follows[start:end] {
+		nodes = append(nodes, map[string]string{"login": f})
+	}
+	return map[string]interface{}{"following": map[string]interface{}{"nodes": nodes, "pageInfo": info}}
+}
+
+// graphqlPage returns the bounds of the page of n items after cursor, and its pageInfo.
+// Cursors are the offset of the next item.
+func graphqlPage(n int, cursor interface{}) (int, int, map[string]interface{}) {
+	start := 0
+	if c, ok := cursor.(string); ok {
+		start, _ = strconv.Atoi(c)
+	}
+	start = min(max(start, 0), n)
+	end := min(start+graphqlPageSize, n)
+	info := map[string]interface{}{"hasNextPage": end < n, "endCursor": nil}
+	if end > start {
+		info["endCursor"] = strconv.Itoa(end)
+	}
+	return start, end, info
+}
+
+// notResolved is the error GitHub reports for a field that names no object
+func notResolved(field, message string) map[string]interface{} {
+	return map[string]interface{}{"type": "NOT_FOUND", "path": []string{field}, "message": message}
+}
+
+// graphqlTime formats an optional timestamp, null when unset
+func graphqlTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
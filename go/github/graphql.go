@@ -0,0 +1,625 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// Extraction backends selectable with Options.API
+const (
+	APIREST    = "rest"
+	APIGraphQL = "graphql"
+)
+
+// DefaultGraphQLURL is the GraphQL endpoint of github.com
+const DefaultGraphQLURL = "https://api.github.com/graphql"
+
+const (
+	// profileBatchSize is how many user profiles are fetched by one GraphQL query
+	profileBatchSize = 50
+	// followBatchSize is how many following lists are paged through by one GraphQL query
+	followBatchSize = 20
+	// threadBatchSize is how many issues and pull requests one GraphQL query reads, and
+	// discussionBatchSize how many discussions, each with up to 100 comments and replies
+	threadBatchSize     = 25
+	discussionBatchSize = 10
+)
+
+// graphqlError is an entry of the errors array of a GraphQL response
+type graphqlError struct {
+	Type    string        `json:"type"`
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+// graphqlResponse is the envelope of every GraphQL response
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+// pageInfo is the GraphQL connection cursor
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphql runs a query and decodes its data into out. Errors tied to a path, such as
+// a user that does not exist, leave that alias null and are not reported; callers skip
+// null aliases. Any other error, or a response without data, fails the query.
+func (c *Client) graphql(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.graphqlURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Return the same legacy node IDs as the REST API
+	req.Header.Set("X-Github-Next-Global-ID", "0")
+
+	resp, err := c.client.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("graphql: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var envelope graphqlResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("graphql: decode response: %w", err)
+	}
+	for _, e := range envelope.Errors {
+		if e.Type == "RATE_LIMITED" || len(e.Path) == 0 {
+			return fmt.Errorf("graphql: %s", e.Message)
+		}
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return fmt.Errorf("graphql: response has no data")
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("graphql: decode data: %w", err)
+	}
+	return nil
+}
+
+// getAllPullRequestsGraphQL is the GraphQL counterpart of getAllPullRequests. Closed and
+// merged pull requests come with their mergedAt in pages of 100, so no per-PR request is needed.
+// The connection cannot be limited to a date, so pull requests closed after the snapshot date
+// attached to ctx are skipped as they are read.
+func (c *Client) getAllPullRequestsGraphQL(ctx context.Context, owner, repo string, maxPRs int) ([]models.PullRequestInfo, error) {
+	const query = `query($owner: String!, $repo: String!, $after: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequests(states: [CLOSED, MERGED], first: 100, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      totalCount
+      nodes { number createdAt closedAt mergedAt }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+	var data struct {
+		Repository *struct {
+			PullRequests struct {
+				TotalCount int `json:"totalCount"`
+				Nodes      []struct {
+					Number    int        `json:"number"`
+					CreatedAt *time.Time `json:"createdAt"`
+					ClosedAt  *time.Time `json:"closedAt"`
+					MergedAt  *time.Time `json:"mergedAt"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+
+	var allPRs []models.PullRequestInfo
+	progress := progressFrom(ctx)
+	asOf := source.AsOfFrom(ctx)
+	variables := map[string]interface{}{"owner": owner, "repo": repo, "after": nil}
+
+	for {
+		data.Repository = nil
+		if err := c.graphql(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		if data.Repository == nil {
+			return nil, fmt.Errorf("repository %s/%s not found", owner, repo)
+		}
+		prs := data.Repository.PullRequests
+
+		for _, node := range prs.Nodes {
+			if maxPRs > 0 && len(allPRs) >= maxPRs {
+				c.logger.Infof("Reached PR limit of %d for %s/%s", maxPRs, owner, repo)
+				return allPRs, nil
+			}
+
+			prInfo := models.PullRequestInfo{
+				Number:    node.Number,
+				CreatedAt: node.CreatedAt,
+				ClosedAt:  node.ClosedAt,
+				MergedAt:  node.MergedAt,
+			}
+			if !source.ClosedBy(prInfo, asOf) {
+				continue
+			}
+			if prInfo.ClosedAt == nil {
+				prInfo.Status = "open"
+			} else if prInfo.MergedAt != nil {
+				prInfo.Status = "merged"
+			} else {
+				prInfo.Status = "closed"
+			}
+			allPRs = append(allPRs, prInfo)
+		}
+		total := prs.TotalCount
+		if maxPRs > 0 && total > maxPRs {
+			total = maxPRs
+		}
+		progress.reportCount(ProgressPullRequests, "pull requests", len(allPRs), total)
+
+		if !prs.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = prs.PageInfo.EndCursor
+	}
+
+	return allPRs, nil
+}
+
+// graphqlUser holds the profile fields requested for each contributor
+type graphqlUser struct {
+	DatabaseID int64     `json:"databaseId"`
+	ID         string    `json:"id"`
+	AvatarURL  string    `json:"avatarUrl"`
+	URL        string    `json:"url"`
+	Name       string    `json:"name"`
+	Company    string    `json:"company"`
+	WebsiteURL string    `json:"websiteUrl"`
+	Location   string    `json:"location"`
+	Email      string    `json:"email"`
+	Bio        string    `json:"bio"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// getContributorsDetailsGraphQL is the GraphQL counterpart of getContributorsDetails. Profiles
+// are fetched profileBatchSize at a time with aliased user queries. Logins GraphQL cannot
+// resolve as users, such as bots, fall back to the REST endpoint.
+func (c *Client) getContributorsDetailsGraphQL(ctx context.Context, usernames []string) ([]models.ContributorDetail, error) {
+	progress := progressFrom(ctx)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	results := make([]models.ContributorDetail, len(usernames))
+	var fallback []int
+
+	for start := 0; start < len(usernames); start += profileBatchSize {
+		end := min(start+profileBatchSize, len(usernames))
+		batch := usernames[start:end]
+
+		var params, fields []string
+		variables := make(map[string]interface{}, len(batch))
+		for i, login := range batch {
+			params = append(params, fmt.Sprintf("$l%d: String!", i))
+			fields = append(fields, fmt.Sprintf("u%d: user(login: $l%d) { ...profile }", i, i))
+			variables[fmt.Sprintf("l%d", i)] = login
+		}
+		query := fmt.Sprintf(`query(%s) {
+  %s
+}
+fragment profile on User {
+  databaseId id avatarUrl url name company websiteUrl location email bio createdAt updatedAt
+}`, strings.Join(params, ", "), strings.Join(fields, "\n  "))
+
+		var data map[string]*graphqlUser
+		if err := c.graphql(ctx, query, variables, &data); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger.Debugf("GraphQL profile batch failed, falling back to REST: %v", err)
+			data = nil
+		}
+
+		for i, login := range batch {
+			user := data[fmt.Sprintf("u%d", i)]
+			if user == nil {
+				fallback = append(fallback, start+i)
+				continue
+			}
+			results[start+i] = models.ContributorDetail{
+				Login:     login,
+				ID:        user.DatabaseID,
+				NodeID:    user.ID,
+				AvatarURL: user.AvatarURL,
+				HTMLURL:   user.URL,
+				Type:      "User",
+				Name:      user.Name,
+				Company:   user.Company,
+				Blog:      user.WebsiteURL,
+				Location:  user.Location,
+				Email:     user.Email,
+				Bio:       user.Bio,
+				CreatedAt: user.CreatedAt,
+				UpdatedAt: user.UpdatedAt,
+			}
+		}
+		progress.reportCount(ProgressProfiles, "contributor profiles", end, len(usernames))
+	}
+
+	if len(fallback) > 0 {
+		logins := make([]string, len(fallback))
+		for i, idx := range fallback {
+			logins[i] = usernames[idx]
+		}
+		details, _ := c.getContributorsDetails(ctx, logins)
+		for i, idx := range fallback {
+			results[idx] = details[i]
+		}
+	}
+
+	// Check if all failed
+	allFailed := true
+	var firstErr string
+	for _, r := range results {
+		if r.Error == "" {
+			allFailed = false
+			break
+		}
+		if firstErr == "" {
+			firstErr = r.Error
+		}
+	}
+
+	if allFailed {
+		return results, fmt.Errorf("all contributor detail requests failed. First error: %s", firstErr)
+	}
+
+	return results, nil
+}
+
+// getCommunityFollowGraphGraphQL is the GraphQL counterpart of getCommunityFollowGraph.
+// The following lists of followBatchSize members are paged through together, one page of
+// 100 logins per member and query.
+func (c *Client) getCommunityFollowGraphGraphQL(ctx context.Context, usernames []string) (models.FollowGraph, error) {
+	progress := progressFrom(ctx)
+	community := make(map[string]struct{}, len(usernames))
+	lists := make(map[string][]string, len(usernames))
+
+	for _, u := range usernames {
+		community[strings.ToLower(u)] = struct{}{}
+	}
+
+	if len(community) == 0 {
+		return source.FollowEdges(usernames, lists), nil
+	}
+
+	// pending is a member whose following list has more pages
+	type pending struct {
+		login  string
+		cursor *string
+		seen   map[string]struct{}
+	}
+
+	failed, done := 0, 0
+	finish := func(p *pending, ok bool) {
+		if ok {
+			for targetKey := range p.seen {
+				lists[p.login] = append(lists[p.login], targetKey)
+			}
+		} else {
+			failed++
+		}
+		done++
+		progress.reportCount(ProgressFollowGraph, "follow lists", done, len(usernames))
+	}
+
+	queue := make([]*pending, len(usernames))
+	for i, u := range usernames {
+		queue[i] = &pending{login: u, seen: make(map[string]struct{})}
+	}
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return source.FollowEdges(usernames, lists), err
+		}
+
+		n := min(followBatchSize, len(queue))
+		batch := queue[:n]
+		queue = queue[n:]
+
+		var params, fields []string
+		variables := make(map[string]interface{}, 2*len(batch))
+		for i, p := range batch {
+			params = append(params, fmt.Sprintf("$l%d: String!, $c%d: String", i, i))
+			fields = append(fields, fmt.Sprintf("u%d: user(login: $l%d) { following(first: 100, after: $c%d) { ...edges } }", i, i, i))
+			variables[fmt.Sprintf("l%d", i)] = p.login
+			variables[fmt.Sprintf("c%d", i)] = p.cursor
+		}
+		query := fmt.Sprintf(`query(%s) {
+  %s
+}
+fragment edges on FollowingConnection {
+  nodes { login }
+  pageInfo { hasNextPage endCursor }
+}`, strings.Join(params, ", "), strings.Join(fields, "\n  "))
+
+		var data map[string]*struct {
+			Following struct {
+				Nodes []struct {
+					Login string `json:"login"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"following"`
+		}
+		if err := c.graphql(ctx, query, variables, &data); err != nil {
+			if ctx.Err() != nil {
+				return source.FollowEdges(usernames, lists), ctx.Err()
+			}
+			c.logger.Debugf("Failed to fetch following lists: %v", err)
+			for _, p := range batch {
+				finish(p, false)
+			}
+			continue
+		}
+
+		for i, p := range batch {
+			user := data[fmt.Sprintf("u%d", i)]
+			if user == nil {
+				c.logger.Debugf("Failed to fetch following list for %s", p.login)
+				finish(p, false)
+				continue
+			}
+
+			selfKey := strings.ToLower(p.login)
+			for _, followed := range user.Following.Nodes {
+				targetKey := strings.ToLower(followed.Login)
+				if targetKey == "" || targetKey == selfKey {
+					continue
+				}
+				if _, ok := community[targetKey]; ok {
+					p.seen[targetKey] = struct{}{}
+				}
+			}
+
+			if user.Following.PageInfo.HasNextPage {
+				cursor := user.Following.PageInfo.EndCursor
+				p.cursor = &cursor
+				queue = append(queue, p)
+				continue
+			}
+			finish(p, true)
+		}
+	}
+
+	graph := source.FollowEdges(usernames, lists)
+	if failed == len(usernames) {
+		return graph, fmt.Errorf("failed to fetch community following lists for all contributors")
+	}
+
+	return graph, nil
+}
+
+// graphqlAuthor is the author of a thread or comment; it is null for deleted accounts
+type graphqlAuthor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+}
+
+// login returns the author login as the REST API spells it, where bot logins end in
+// "[bot]"
+func (a *graphqlAuthor) login() string {
+	if a == nil {
+		return ""
+	}
+	if a.Typename == "Bot" {
+		return a.Login + "[bot]"
+	}
+	return a.Login
+}
+
+// graphqlPost is a thread or comment with its author and reaction count
+type graphqlPost struct {
+	Author    *graphqlAuthor `json:"author"`
+	Reactions struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"reactions"`
+}
+
+// getThreadsGraphQL is the GraphQL counterpart of getThreads. Issues and pull requests
+// are searched together by update time, threadBatchSize per query, each with its first
+// source.MaxResponses comments and reviews; every inline comment of a review counts as
+// a review comment. Discussions follow with their comments and replies.
+func (c *Client) getThreadsGraphQL(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	query := fmt.Sprintf(`query($query: String!, $after: String) {
+  search(query: $query, type: ISSUE, first: %d, after: $after) {
+    issueCount
+    nodes {
+      ... on Issue { ...post comments(first: 100) { nodes { ...post } } }
+      ... on PullRequest {
+        ...post
+        comments(first: 100) { nodes { ...post } }
+        reviews(first: 100) { nodes { author { __typename login } comments { totalCount } } }
+      }
+    }
+    pageInfo { hasNextPage endCursor }
+  }
+}
+fragment post on Reactable {
+  ... on Comment { author { __typename login } }
+  reactions { totalCount }
+}`, threadBatchSize)
+
+	var data struct {
+		Search struct {
+			IssueCount int `json:"issueCount"`
+			Nodes      []struct {
+				graphqlPost
+				Comments struct {
+					Nodes []graphqlPost `json:"nodes"`
+				} `json:"comments"`
+				Reviews struct {
+					Nodes []struct {
+						Author   *graphqlAuthor `json:"author"`
+						Comments struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviews"`
+			} `json:"nodes"`
+			PageInfo pageInfo `json:"pageInfo"`
+		} `json:"search"`
+	}
+
+	var threads []source.Thread
+	progress := progressFrom(ctx)
+	variables := map[string]interface{}{
+		"query": fmt.Sprintf("repo:%s/%s sort:updated-desc", owner, repo),
+		"after": nil,
+	}
+
+	for len(threads) < max {
+		data.Search.Nodes = nil
+		if err := c.graphql(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		for _, node := range data.Search.Nodes {
+			if len(threads) == max {
+				break
+			}
+			thread := source.Thread{Author: node.Author.login(), Reactions: node.Reactions.TotalCount}
+			for _, comment := range node.Comments.Nodes {
+				thread.Responses = append(thread.Responses, source.Response{
+					Kind:      source.ResponseComment,
+					Author:    comment.Author.login(),
+					Reactions: comment.Reactions.TotalCount,
+				})
+			}
+			for _, review := range node.Reviews.Nodes {
+				author := review.Author.login()
+				thread.Responses = append(thread.Responses, source.Response{Kind: source.ResponseReview, Author: author})
+				for i := 0; i < review.Comments.TotalCount; i++ {
+					thread.Responses = append(thread.Responses, source.Response{Kind: source.ResponseReviewComment, Author: author})
+				}
+			}
+			threads = append(threads, thread)
+		}
+		progress.reportCount(ProgressThreads, "threads", len(threads), min(data.Search.IssueCount, max))
+
+		if !data.Search.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = data.Search.PageInfo.EndCursor
+	}
+
+	discussions, err := c.getDiscussionsGraphQL(ctx, owner, repo, max)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Older Enterprise Server versions have no discussions
+		c.logger.Debugf("Failed to fetch the discussions of %s/%s: %v", owner, repo, err)
+	}
+	return append(threads, discussions...), nil
+}
+
+// getDiscussionsGraphQL returns up to max of the most recently updated discussions,
+// discussionBatchSize per query, with their first source.MaxResponses comments and replies to each
+func (c *Client) getDiscussionsGraphQL(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	query := fmt.Sprintf(`query($owner: String!, $repo: String!, $after: String) {
+  repository(owner: $owner, name: $repo) {
+    discussions(first: %d, after: $after, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      nodes {
+        ...post
+        comments(first: 100) { nodes { ...post replies(first: 100) { nodes { ...post } } } }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}
+fragment post on Reactable {
+  ... on Comment { author { __typename login } }
+  reactions { totalCount }
+}`, discussionBatchSize)
+
+	var data struct {
+		Repository *struct {
+			Discussions struct {
+				Nodes []struct {
+					graphqlPost
+					Comments struct {
+						Nodes []struct {
+							graphqlPost
+							Replies struct {
+								Nodes []graphqlPost `json:"nodes"`
+							} `json:"replies"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"discussions"`
+		} `json:"repository"`
+	}
+
+	var threads []source.Thread
+	variables := map[string]interface{}{"owner": owner, "repo": repo, "after": nil}
+
+	for len(threads) < max {
+		data.Repository = nil
+		if err := c.graphql(ctx, query, variables, &data); err != nil {
+			return threads, err
+		}
+		if data.Repository == nil {
+			return threads, fmt.Errorf("repository %s/%s not found", owner, repo)
+		}
+		discussions := data.Repository.Discussions
+
+		for _, node := range discussions.Nodes {
+			if len(threads) == max {
+				break
+			}
+			thread := source.Thread{Author: node.Author.login(), Reactions: node.Reactions.TotalCount}
+			for _, comment := range node.Comments.Nodes {
+				thread.Responses = append(thread.Responses, source.Response{
+					Kind:      source.ResponseDiscussionComment,
+					Author:    comment.Author.login(),
+					Reactions: comment.Reactions.TotalCount,
+				})
+				for _, reply := range comment.Replies.Nodes {
+					thread.Responses = append(thread.Responses, source.Response{
+						Kind:      source.ResponseDiscussionComment,
+						Author:    reply.Author.login(),
+						Reactions: reply.Reactions.TotalCount,
+					})
+				}
+			}
+			threads = append(threads, thread)
+		}
+
+		if !discussions.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = discussions.PageInfo.EndCursor
+	}
+	return threads, nil
+}
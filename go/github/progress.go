@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// Extraction steps reported through ProgressEvent.Stage
+const (
+	ProgressRepository       = "repository"
+	ProgressClone            = "clone" // A local clone was made or updated
+	ProgressCommits          = "commits"
+	ProgressMilestones       = "milestones"
+	ProgressContributors     = "contributors"
+	ProgressContributorStats = "contributor_stats"
+	ProgressPullRequests     = "pull_requests"
+	ProgressProfiles         = "profiles"
+	ProgressFollowGraph      = "follow_graph"
+	ProgressThreads          = "threads"   // Issue, pull request and discussion activity
+	ProgressQuota            = "quota"     // Waiting for API quota before the extraction starts
+	ProgressThrottled        = "throttled" // A request is waiting for a rate limit to lift
+)
+
+// ProgressEvent describes a step of a repository extraction
+type ProgressEvent struct {
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+// ProgressFunc receives extraction progress events.
+// It is called from several goroutines and must be safe for concurrent use.
+type ProgressFunc func(ProgressEvent)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose extractions report their progress to f
+func WithProgress(ctx context.Context, f ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, f)
+}
+
+// progressFrom returns the ProgressFunc attached to ctx, or nil
+func progressFrom(ctx context.Context) ProgressFunc {
+	f, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return f
+}
+
+// ReportProgress sends a formatted event to the ProgressFunc attached to ctx, if any
+func ReportProgress(ctx context.Context, stage string, format string, args ...interface{}) {
+	progressFrom(ctx).report(stage, format, args...)
+}
+
+// report sends a formatted event, doing nothing when no callback is set
+func (f ProgressFunc) report(stage string, format string, args ...interface{}) {
+	if f != nil {
+		f(ProgressEvent{Stage: stage, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// reportCount sends a counter event, throttled to about 20 events per counter
+func (f ProgressFunc) reportCount(stage, label string, current, total int) {
+	if f == nil {
+		return
+	}
+	step := total / 20
+	if step < 1 {
+		step = 1
+	}
+	if current%step != 0 && current != total {
+		return
+	}
+	f(ProgressEvent{
+		Stage:   stage,
+		Message: fmt.Sprintf("%s %d/%d", label, current, total),
+		Current: current,
+		Total:   total,
+	})
+}
@@ -0,0 +1,211 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxThrottleRetries is how many times a throttled request is retried before
+	// its rate limit response is returned to the caller
+	maxThrottleRetries = 5
+
+	// maxThrottleWait is the longest a single request waits for a limit to lift;
+	// longer waits are left to the scheduler
+	maxThrottleWait = 15 * time.Minute
+
+	// secondaryBackoff is the first wait after a secondary rate limit response
+	// without Retry-After, doubled on every retry as GitHub recommends
+	secondaryBackoff = time.Minute
+
+	// attemptTimeout bounds every single request; time spent throttled does not count
+	attemptTimeout = 120 * time.Second
+)
+
+// Kinds of throttling recognised by the retry transport
+const (
+	throttlePrimary   = "primary"   // Token quota exhausted
+	throttleSecondary = "secondary" // Secondary rate limit or abuse detection
+)
+
+// ThrottleStats reports how often and how long requests were held back by GitHub rate limits
+type ThrottleStats struct {
+	Primary   int64   `json:"primary"`   // Responses rejected because the quota was exhausted
+	Secondary int64   `json:"secondary"` // Secondary rate limit and abuse detection responses
+	Retries   int64   `json:"retries"`   // Requests sent again after waiting
+	GaveUp    int64   `json:"gave_up"`   // Throttled responses returned to the caller
+	Seconds   float64 `json:"seconds"`   // Total time spent waiting
+}
+
+// retryTransport retries requests rejected by a primary or secondary rate limit,
+// waiting as long as the Retry-After or X-RateLimit-Reset headers ask
+type retryTransport struct {
+	transport http.RoundTripper
+	logger    *logrus.Logger
+
+	primary   atomic.Int64
+	secondary atomic.Int64
+	retries   atomic.Int64
+	gaveUp    atomic.Int64
+	waited    atomic.Int64 // Nanoseconds
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.GetBody != nil
+	ctx := req.Context()
+	backoff := secondaryBackoff
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+
+		resp, err := t.roundTripWithTimeout(r)
+		if err != nil {
+			return nil, err
+		}
+
+		kind, wait := throttled(resp)
+		if kind == "" {
+			return resp, nil
+		}
+		if kind == throttlePrimary {
+			t.primary.Add(1)
+		} else {
+			t.secondary.Add(1)
+			if wait == 0 {
+				wait = backoff
+				backoff *= 2
+			}
+		}
+
+		if !replayable || attempt >= maxThrottleRetries || wait > maxThrottleWait {
+			t.gaveUp.Add(1)
+			t.logger.Warnf("GitHub %s rate limit on %s %s, giving up after %d retries (wait needed: %s)",
+				kind, req.Method, req.URL.Path, attempt, wait.Round(time.Second))
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		t.logger.Warnf("GitHub %s rate limit on %s %s, retrying in %s",
+			kind, req.Method, req.URL.Path, wait.Round(time.Second))
+		progressFrom(ctx).report(ProgressThrottled, "%s rate limit hit, waiting %s", kind, wait.Round(time.Second))
+
+		start := time.Now()
+		err = sleep(ctx, wait)
+		t.waited.Add(int64(time.Since(start)))
+		if err != nil {
+			return nil, err
+		}
+		t.retries.Add(1)
+	}
+}
+
+// roundTripWithTimeout sends a single attempt bounded by attemptTimeout. The deadline
+// stays in place until the response body is closed.
+func (t *retryTransport) roundTripWithTimeout(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), attemptTimeout)
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// Stats returns the throttling counters
+func (t *retryTransport) Stats() ThrottleStats {
+	return ThrottleStats{
+		Primary:   t.primary.Load(),
+		Secondary: t.secondary.Load(),
+		Retries:   t.retries.Load(),
+		GaveUp:    t.gaveUp.Load(),
+		Seconds:   time.Duration(t.waited.Load()).Seconds(),
+	}
+}
+
+// throttled classifies a response. It returns an empty kind for responses that were
+// not rate limited, and the wait the headers ask for (zero when they do not say).
+func throttled(resp *http.Response) (string, time.Duration) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return "", 0
+	}
+
+	if after := resp.Header.Get("Retry-After"); after != "" {
+		return throttleSecondary, retryAfter(after)
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		var wait time.Duration
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Until(time.Unix(reset, 0)) + time.Second
+		}
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return throttlePrimary, wait
+	}
+
+	// Secondary limits are not always announced with a header; recognise them by the message
+	if resp.StatusCode == http.StatusTooManyRequests || isSecondaryLimitMessage(resp) {
+		return throttleSecondary, 0
+	}
+	return "", 0
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// isSecondaryLimitMessage reads the body of a 403 looking for GitHub's secondary rate
+// limit or abuse detection message. The body is restored for the caller.
+func isSecondaryLimitMessage(resp *http.Response) bool {
+	original := resp.Body
+	body, err := io.ReadAll(io.LimitReader(original, 64<<10))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	if err != nil {
+		return false
+	}
+
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse")
+}
+
+// cancelOnClose releases the context of a request once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
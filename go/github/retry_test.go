@@ -0,0 +1,208 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestThrottled(t *testing.T) {
+	now := time.Now()
+	reset := strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)
+	passed := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		kind    string
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{
+			name:   "success",
+			status: http.StatusOK,
+			header: http.Header{"X-Ratelimit-Remaining": {"0"}},
+		},
+		{
+			name:   "forbidden without rate limit",
+			status: http.StatusForbidden,
+			header: http.Header{"X-Ratelimit-Remaining": {"10"}},
+			body:   `{"message":"Resource not accessible by integration"}`,
+		},
+		{
+			name:    "primary limit waits for the reset",
+			status:  http.StatusForbidden,
+			header:  http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}},
+			kind:    throttlePrimary,
+			minWait: 85 * time.Second,
+			maxWait: 92 * time.Second,
+		},
+		{
+			name:    "primary limit on a 429",
+			status:  http.StatusTooManyRequests,
+			header:  http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}},
+			kind:    throttlePrimary,
+			minWait: 85 * time.Second,
+			maxWait: 92 * time.Second,
+		},
+		{
+			name:    "primary limit with a passed reset",
+			status:  http.StatusForbidden,
+			header:  http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {passed}},
+			kind:    throttlePrimary,
+			minWait: time.Second,
+			maxWait: time.Second,
+		},
+		{
+			name:    "primary limit without a reset",
+			status:  http.StatusForbidden,
+			header:  http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"soon"}},
+			kind:    throttlePrimary,
+			minWait: time.Second,
+			maxWait: time.Second,
+		},
+		{
+			name:    "Retry-After wins over the reset",
+			status:  http.StatusForbidden,
+			header:  http.Header{"Retry-After": {"30"}, "X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}},
+			kind:    throttleSecondary,
+			minWait: 30 * time.Second,
+			maxWait: 30 * time.Second,
+		},
+		{
+			name:   "secondary limit message",
+			status: http.StatusForbidden,
+			body:   `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`,
+			kind:   throttleSecondary,
+		},
+		{
+			name:   "abuse detection message",
+			status: http.StatusForbidden,
+			body:   `{"message":"You have triggered an abuse detection mechanism."}`,
+			kind:   throttleSecondary,
+		},
+		{
+			name:   "bare 429",
+			status: http.StatusTooManyRequests,
+			kind:   throttleSecondary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     tt.header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+
+			kind, wait := throttled(resp)
+			if kind != tt.kind {
+				t.Errorf("kind = %q, want %q", kind, tt.kind)
+			}
+			if wait < tt.minWait || wait > tt.maxWait {
+				t.Errorf("wait = %s, want between %s and %s", wait, tt.minWait, tt.maxWait)
+			}
+
+			// The body read while looking for the message is still there for the caller
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("body = %q after classification, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value   string
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{"0", 0, 0},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+		{"later", 0, 0},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got < tt.minWait || got > tt.maxWait {
+			t.Errorf("retryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.minWait, tt.maxWait)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string // Retry-After of the first response
+		status     int    // Status the caller gets
+		requests   int64
+		stats      ThrottleStats
+	}{
+		{
+			name:       "retried after the wait",
+			retryAfter: "1",
+			status:     http.StatusOK,
+			requests:   2,
+			stats:      ThrottleStats{Secondary: 1, Retries: 1},
+		},
+		{
+			name:       "wait too long",
+			retryAfter: strconv.Itoa(int((maxThrottleWait + time.Minute).Seconds())),
+			status:     http.StatusForbidden,
+			requests:   1,
+			stats:      ThrottleStats{Secondary: 1, GaveUp: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				io.WriteString(w, "{}")
+			}))
+			defer srv.Close()
+
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			rt := &retryTransport{transport: http.DefaultTransport, logger: logger}
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if requests.Load() != tt.requests {
+				t.Errorf("server saw %d requests, want %d", requests.Load(), tt.requests)
+			}
+			stats := rt.Stats()
+			stats.Seconds = 0
+			if stats != tt.stats {
+				t.Errorf("stats = %+v, want %+v", stats, tt.stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limit resources tracked separately for every token
+const (
+	ResourceCore    = "core"
+	ResourceSearch  = "search"
+	ResourceGraphQL = "graphql"
+)
+
+// RateStatus is the known quota of a token for one rate limit resource
+type RateStatus struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// TokenStatus reports the quota of a pooled token
+type TokenStatus struct {
+	Token   string     `json:"token"` // Masked, only the last characters are shown
+	Core    RateStatus `json:"core"`
+	Search  RateStatus `json:"search"`
+	GraphQL RateStatus `json:"graphql"`
+}
+
+// poolToken is a token and the last quota GitHub reported for it. App installation
+// tokens are minted on demand, so their value is read through credential.
+type poolToken struct {
+	value  string
+	app    *appInstallation
+	limits map[string]*RateStatus
+}
+
+// tokenPool hands out the token with the most remaining quota for each resource
+type tokenPool struct {
+	mu     sync.Mutex
+	tokens []*poolToken
+}
+
+type pinnedTokenKey struct{}
+
+// newTokenPool creates a pool of the given tokens and app installation, if any; with
+// neither, requests are sent unauthenticated
+func newTokenPool(tokens []string, app *appInstallation) *tokenPool {
+	pool := &tokenPool{}
+	if app != nil {
+		pool.tokens = append(pool.tokens, &poolToken{app: app, limits: make(map[string]*RateStatus)})
+	}
+	for _, t := range tokens {
+		pool.tokens = append(pool.tokens, &poolToken{value: t, limits: make(map[string]*RateStatus)})
+	}
+	if len(pool.tokens) == 0 {
+		pool.tokens = append(pool.tokens, &poolToken{limits: make(map[string]*RateStatus)})
+	}
+	return pool
+}
+
+// withPinnedToken returns a context whose requests always use the pool token at index i
+func withPinnedToken(ctx context.Context, i int) context.Context {
+	return context.WithValue(ctx, pinnedTokenKey{}, i)
+}
+
+// pick returns the token with the most remaining quota for resource. Tokens GitHub has
+// not reported on yet are preferred so their quota gets discovered, and quotas whose
+// reset time has passed count as full.
+func (p *tokenPool) pick(ctx context.Context, resource string) *poolToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i, ok := ctx.Value(pinnedTokenKey{}).(int); ok && i >= 0 && i < len(p.tokens) {
+		return p.tokens[i]
+	}
+
+	now := time.Now()
+	var best *poolToken
+	bestRemaining := -1
+	for _, t := range p.tokens {
+		remaining := t.remaining(resource, now)
+		if remaining > bestRemaining {
+			best, bestRemaining = t, remaining
+		}
+	}
+	return best
+}
+
+// hasAlternative reports whether another token still has quota left for resource
+func (p *tokenPool) hasAlternative(current *poolToken, resource string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, t := range p.tokens {
+		if t != current && t.remaining(resource, now) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// update records the quota reported in the rate limit headers of a response
+func (p *tokenPool) update(t *poolToken, resource string, header http.Header) {
+	limit, errLimit := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, errReset := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if errLimit != nil || errRemaining != nil || errReset != nil {
+		return
+	}
+	if r := header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	p.set(t, resource, RateStatus{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)})
+}
+
+// set stores the quota of a token for a resource
+func (p *tokenPool) set(t *poolToken, resource string, status RateStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t.limits[resource] = &status
+}
+
+// status returns the known quota of every token
+func (p *tokenPool) status() []TokenStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]TokenStatus, len(p.tokens))
+	for i, t := range p.tokens {
+		statuses[i] = TokenStatus{
+			Token:   t.name(),
+			Core:    t.rate(ResourceCore),
+			Search:  t.rate(ResourceSearch),
+			GraphQL: t.rate(ResourceGraphQL),
+		}
+	}
+	return statuses
+}
+
+// credential returns the token to authenticate with, empty for unauthenticated requests
+func (t *poolToken) credential(ctx context.Context) (string, error) {
+	if t.app != nil {
+		return t.app.accessToken(ctx)
+	}
+	return t.value, nil
+}
+
+// name identifies the token without revealing it
+func (t *poolToken) name() string {
+	if t.app != nil {
+		return t.app.name()
+	}
+	return maskToken(t.value)
+}
+
+// remaining returns the usable quota of the token, or MaxInt32 while it is unknown
+func (t *poolToken) remaining(resource string, now time.Time) int {
+	s, ok := t.limits[resource]
+	if !ok {
+		return 1<<31 - 1
+	}
+	if now.After(s.Reset) {
+		return s.Limit
+	}
+	return s.Remaining
+}
+
+// rate returns a copy of the known quota for resource
+func (t *poolToken) rate(resource string) RateStatus {
+	if s, ok := t.limits[resource]; ok {
+		return *s
+	}
+	return RateStatus{}
+}
+
+// requestResource infers which rate limit a request counts against
+func requestResource(req *http.Request) string {
+	path := req.URL.Path
+	switch {
+	case strings.HasSuffix(path, "/graphql"):
+		return ResourceGraphQL
+	case strings.Contains(path, "/search/"):
+		return ResourceSearch
+	default:
+		return ResourceCore
+	}
+}
+
+// isRateLimited reports whether a response was rejected because the token ran out of quota
+func isRateLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// maskToken hides all but the last four characters of a token
+func maskToken(token string) string {
+	if token == "" {
+		return "(unauthenticated)"
+	}
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
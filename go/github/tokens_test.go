@@ -0,0 +1,259 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenPoolPick(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		limits []map[string]RateStatus // Known quota of each token, by resource
+		want   int
+	}{
+		{
+			name:   "unknown quota is preferred",
+			limits: []map[string]RateStatus{{ResourceCore: {Limit: 5000, Remaining: 4000, Reset: future}}, {}},
+			want:   1,
+		},
+		{
+			name: "most remaining",
+			limits: []map[string]RateStatus{
+				{ResourceCore: {Limit: 5000, Remaining: 10, Reset: future}},
+				{ResourceCore: {Limit: 5000, Remaining: 3000, Reset: future}},
+				{ResourceCore: {Limit: 5000, Remaining: 20, Reset: future}},
+			},
+			want: 1,
+		},
+		{
+			name: "passed reset counts as full",
+			limits: []map[string]RateStatus{
+				{ResourceCore: {Limit: 5000, Remaining: 3000, Reset: future}},
+				{ResourceCore: {Limit: 5000, Remaining: 0, Reset: past}},
+			},
+			want: 1,
+		},
+		{
+			name: "resources are tracked separately",
+			limits: []map[string]RateStatus{
+				{ResourceCore: {Limit: 5000, Remaining: 5000, Reset: future}, ResourceSearch: {Limit: 30, Remaining: 0, Reset: future}},
+				{ResourceCore: {Limit: 5000, Remaining: 100, Reset: future}, ResourceSearch: {Limit: 30, Remaining: 30, Reset: future}},
+			},
+			want: 0,
+		},
+		{
+			name: "ties keep the first token",
+			limits: []map[string]RateStatus{
+				{ResourceCore: {Limit: 5000, Remaining: 0, Reset: future}},
+				{ResourceCore: {Limit: 5000, Remaining: 0, Reset: future}},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := make([]string, len(tt.limits))
+			for i := range tokens {
+				tokens[i] = "token-" + strconv.Itoa(i)
+			}
+			pool := newTokenPool(tokens, nil)
+			for i, limits := range tt.limits {
+				for resource, status := range limits {
+					pool.set(pool.tokens[i], resource, status)
+				}
+			}
+
+			if got := pool.pick(context.Background(), ResourceCore); got != pool.tokens[tt.want] {
+				t.Errorf("picked %s, want %s", got.value, pool.tokens[tt.want].value)
+			}
+		})
+	}
+}
+
+func TestTokenPoolPinned(t *testing.T) {
+	pool := newTokenPool([]string{"a", "b"}, nil)
+	pool.set(pool.tokens[1], ResourceCore, RateStatus{Limit: 5000, Remaining: 0, Reset: time.Now().Add(time.Hour)})
+
+	if got := pool.pick(withPinnedToken(context.Background(), 1), ResourceCore); got.value != "b" {
+		t.Errorf("pinned pick = %q, want b", got.value)
+	}
+	if got := pool.pick(withPinnedToken(context.Background(), 5), ResourceCore); got.value != "a" {
+		t.Errorf("out of range pin = %q, want the best token a", got.value)
+	}
+}
+
+func TestTokenPoolUpdate(t *testing.T) {
+	pool := newTokenPool([]string{"a"}, nil)
+	token := pool.tokens[0]
+
+	pool.update(token, ResourceCore, http.Header{
+		"X-Ratelimit-Limit":     {"30"},
+		"X-Ratelimit-Remaining": {"12"},
+		"X-Ratelimit-Reset":     {"1700000000"},
+		"X-Ratelimit-Resource":  {"search"},
+	})
+	if got := token.rate(ResourceSearch); got.Limit != 30 || got.Remaining != 12 || !got.Reset.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("search quota = %+v, want the resource named by the headers", got)
+	}
+	if got := token.rate(ResourceCore); got != (RateStatus{}) {
+		t.Errorf("core quota = %+v, want unknown", got)
+	}
+
+	// Incomplete headers leave the known quota alone
+	pool.update(token, ResourceSearch, http.Header{"X-Ratelimit-Remaining": {"0"}})
+	if got := token.rate(ResourceSearch); got.Remaining != 12 {
+		t.Errorf("remaining = %d after incomplete headers, want 12", got.Remaining)
+	}
+}
+
+// quotaServer answers each token with its remaining quota, rejecting requests with a 403
+// or 429 once it is exhausted, and records the tokens it saw
+type quotaServer struct {
+	mu        sync.Mutex
+	remaining map[string]int
+	status    int // Status of rejected requests
+	seen      []string
+}
+
+func (s *quotaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	s.seen = append(s.seen, token)
+	exhausted := s.remaining[token] == 0
+	if !exhausted {
+		s.remaining[token]--
+	}
+	remaining := s.remaining[token]
+	s.mu.Unlock()
+
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	if exhausted {
+		w.WriteHeader(s.status)
+		io.WriteString(w, `{"message":"API rate limit exceeded"}`)
+		return
+	}
+	io.WriteString(w, `{}`)
+}
+
+func TestAuthTransportRotates(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			quota := &quotaServer{remaining: map[string]int{"a": 0, "b": 100}, status: status}
+			srv := httptest.NewServer(quota)
+			defer srv.Close()
+
+			pool := newTokenPool([]string{"a", "b"}, nil)
+			// a has no known quota, so it is tried first; b is known to have some left
+			pool.set(pool.tokens[1], ResourceCore, RateStatus{Limit: 5000, Remaining: 100, Reset: time.Now().Add(time.Hour)})
+			auth := &authTransport{transport: http.DefaultTransport, pool: pool}
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/repos/acme/widget", nil)
+			resp, err := auth.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200 from the rotated token", resp.StatusCode)
+			}
+			if got := strings.Join(quota.seen, ","); got != "a,b" {
+				t.Errorf("tokens used = %s, want a,b", got)
+			}
+			if got := pool.tokens[0].rate(ResourceCore).Remaining; got != 0 {
+				t.Errorf("exhausted token remaining = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestAuthTransportDoesNotRotate(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining map[string]int
+		ctx       func(context.Context) context.Context
+		want      string // Tokens the server saw
+	}{
+		{
+			name:      "every token exhausted",
+			remaining: map[string]int{"a": 0, "b": 0},
+			ctx:       func(ctx context.Context) context.Context { return ctx },
+			want:      "a,b",
+		},
+		{
+			name:      "pinned token",
+			remaining: map[string]int{"a": 0, "b": 100},
+			ctx:       func(ctx context.Context) context.Context { return withPinnedToken(ctx, 0) },
+			want:      "a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := &quotaServer{remaining: tt.remaining, status: http.StatusForbidden}
+			srv := httptest.NewServer(quota)
+			defer srv.Close()
+
+			auth := &authTransport{transport: http.DefaultTransport, pool: newTokenPool([]string{"a", "b"}, nil)}
+			req, _ := http.NewRequestWithContext(tt.ctx(context.Background()), http.MethodGet, srv.URL+"/repos/acme/widget", nil)
+			resp, err := auth.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("status = %d, want the 403 passed on", resp.StatusCode)
+			}
+			if got := strings.Join(quota.seen, ","); got != tt.want {
+				t.Errorf("tokens used = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestResource(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.github.com/repos/acme/widget", ResourceCore},
+		{"https://api.github.com/search/issues?q=repo:acme/widget", ResourceSearch},
+		{"https://api.github.com/graphql", ResourceGraphQL},
+		{"https://ghe.example.com/api/graphql", ResourceGraphQL},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if got := requestResource(req); got != tt.want {
+			t.Errorf("requestResource(%s) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMaskToken(t *testing.T) {
+	tests := map[string]string{
+		"":                 "(unauthenticated)",
+		"abc":              "****",
+		"ghp_secretvalue1": "****lue1",
+	}
+	for token, want := range tests {
+		if got := maskToken(token); got != want {
+			t.Errorf("maskToken(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// DefaultURL is the address of gitlab.com
+const DefaultURL = "https://gitlab.com"
+
+const (
+	// perPage is the largest page size the GitLab API accepts
+	perPage = 100
+	// maxStatsCommits caps the commit scan behind ContributorStats, like GitHub's stats endpoint
+	maxStatsCommits = 10000
+	// maxActivePages caps the scan behind the active contributor eligibility check
+	maxActivePages = 50
+)
+
+// Client extracts repositories from a GitLab instance through its REST API (v4).
+// Projects are addressed by their full path, so owner may contain subgroups.
+type Client struct {
+	baseURL *url.URL
+	token   string
+	http    *http.Client
+	logger  *logrus.Logger
+
+	mu      sync.Mutex
+	userIDs map[string]int64 // Lowercased username to user ID, filled while fetching profiles
+}
+
+// NewClient creates a client for the GitLab instance at baseURL (DefaultURL when empty).
+// The token is optional; without it only public projects and profiles can be read.
+func NewClient(baseURL, token string, logger *logrus.Logger) (*Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL %q", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		token:   token,
+		http: &http.Client{
+			Timeout: 120 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConnsPerHost: 20,
+			},
+		},
+		logger:  logger,
+		userIDs: make(map[string]int64),
+	}, nil
+}
+
+// Host returns the hostname of the GitLab instance
+func (c *Client) Host() string {
+	return c.baseURL.Host
+}
+
+// get fetches an API path (relative to /api/v4) into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) (http.Header, error) {
+	u := c.baseURL.String() + "/api/v4/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("PRIVATE-TOKEN", c.token)
+	}
+	return source.GetJSON(ctx, c.http, u, header, out)
+}
+
+// projectPath returns the URL-encoded project ID of owner/repo
+func projectPath(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// total reads the X-Total header GitLab sets on paginated responses; it is omitted
+// for collections larger than 10,000 items
+func total(header http.Header) (int, bool) {
+	n, err := strconv.Atoi(header.Get("X-Total"))
+	return n, err == nil
+}
+
+// nextPage reads the X-Next-Page header, returning 0 on the last page
+func nextPage(header http.Header) int {
+	n, _ := strconv.Atoi(header.Get("X-Next-Page"))
+	return n
+}
+
+type project struct {
+	Description     string    `json:"description"`
+	StarCount       int       `json:"star_count"`
+	ForksCount      int       `json:"forks_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastActivityAt  time.Time `json:"last_activity_at"`
+	DefaultBranch   string    `json:"default_branch"`
+	IssuesEnabled   bool      `json:"issues_enabled"`
+	WikiEnabled     bool      `json:"wiki_enabled"`
+	ReadmeURL       string    `json:"readme_url"`
+	License         *struct {
+		Name string `json:"name"`
+	} `json:"license"`
+	Statistics *struct {
+		CommitCount    int   `json:"commit_count"`
+		RepositorySize int64 `json:"repository_size"` // Bytes
+	} `json:"statistics"`
+}
+
+type treeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// GetRepositoryInfo fetches a project and its community in the shape GitHub extractions have.
+// GitLab has no watchers, so Watchers stays 0; Size needs a token with access to the
+// project statistics.
+func (c *Client) GetRepositoryInfo(ctx context.Context, owner, repo string) models.RepositoryInfo {
+	info := models.RepositoryInfo{
+		Owner: owner,
+		Repo:  repo,
+	}
+
+	var p project
+	query := url.Values{"license": {"true"}, "statistics": {"true"}}
+	if _, err := c.get(ctx, projectPath(owner, repo), query, &p); err != nil {
+		info.Error = fmt.Sprintf("Failed to fetch repository: %v", err)
+		return info
+	}
+
+	info.Description = p.Description
+	info.Stars = p.StarCount
+	info.Forks = p.ForksCount
+	info.OpenIssues = p.OpenIssuesCount
+	info.CreatedAt = p.CreatedAt
+	info.UpdatedAt = p.LastActivityAt
+	info.DefaultBranch = p.DefaultBranch
+	info.HasIssues = p.IssuesEnabled
+	info.HasWiki = p.WikiEnabled
+	if p.License != nil {
+		info.License = p.License.Name
+	}
+	if p.Statistics != nil {
+		info.Size = int(p.Statistics.RepositorySize / 1024)
+	}
+	if err := source.MarkAsOf(ctx, &info); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.HasDescription = info.Description != ""
+	info.HasLicense = info.License != ""
+	info.HasWikiPage = info.HasWiki
+	info.HasReadme = p.ReadmeURL != ""
+	info.Language = c.primaryLanguage(ctx, owner, repo)
+	c.checkCommunityFiles(ctx, owner, repo, &info)
+
+	commits, err := c.commitCount(ctx, owner, repo, &p)
+	if err != nil {
+		info.Error = fmt.Sprintf("Failed to fetch commits: %v", err)
+	} else {
+		info.Commits = commits
+	}
+
+	milestones, err := c.milestones(ctx, owner, repo)
+	if err != nil {
+		if info.Error == "" {
+			info.Error = fmt.Sprintf("Failed to fetch milestones: %v", err)
+		}
+	} else {
+		source.SetMilestones(&info, milestones)
+	}
+
+	source.FillCommunity(ctx, c, &info)
+	return info
+}
+
+// primaryLanguage returns the language with the largest share of the project
+func (c *Client) primaryLanguage(ctx context.Context, owner, repo string) string {
+	var languages map[string]float64
+	if _, err := c.get(ctx, projectPath(owner, repo)+"/languages", nil, &languages); err != nil {
+		return ""
+	}
+	best, share := "", -1.0
+	for lang, s := range languages {
+		if s > share || (s == share && lang < best) {
+			best, share = lang, s
+		}
+	}
+	return best
+}
+
+// checkCommunityFiles looks for community files in the repository root and in .gitlab/
+func (c *Client) checkCommunityFiles(ctx context.Context, owner, repo string, info *models.RepositoryInfo) {
+	names := make(map[string]bool)
+	for _, dir := range []string{"", ".gitlab"} {
+		query := url.Values{"per_page": {strconv.Itoa(perPage)}}
+		if dir != "" {
+			query.Set("path", dir)
+		}
+		var entries []treeEntry
+		if _, err := c.get(ctx, projectPath(owner, repo)+"/repository/tree", query, &entries); err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := strings.ToLower(e.Name)
+			if i := strings.LastIndex(name, "."); i > 0 && e.Type == "blob" {
+				name = name[:i]
+			}
+			names[name] = true
+		}
+	}
+
+	info.HasContributingGuidelines = names["contributing"]
+	info.HasCodeOfConduct = names["code_of_conduct"] || names["code-of-conduct"]
+	info.HasSecurityPolicy = names["security"]
+	info.HasIssuesTemplate = names["issue_templates"]
+	info.HasPullRequestTemplate = names["merge_request_templates"]
+	info.HasReadme = info.HasReadme || names["readme"]
+}
+
+// commitCount returns the number of commits on the default branch, up to the snapshot
+// date attached to ctx
+func (c *Client) commitCount(ctx context.Context, owner, repo string, p *project) (int, error) {
+	var commits []struct{}
+	header, err := c.get(ctx, projectPath(owner, repo)+"/repository/commits", untilAsOf(ctx, url.Values{"per_page": {"1"}}), &commits)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := total(header); ok {
+		return n, nil
+	}
+	// The project statistics count the whole history
+	if p != nil && p.Statistics != nil && source.AsOfFrom(ctx).IsZero() {
+		return p.Statistics.CommitCount, nil
+	}
+	return c.countCommits(ctx, owner, repo, maxStatsCommits)
+}
+
+// countCommits pages through the commits, stopping once limit is reached
+func (c *Client) countCommits(ctx context.Context, owner, repo string, limit int) (int, error) {
+	count := 0
+	for page := 1; page != 0 && count < limit; {
+		var commits []struct{}
+		query := untilAsOf(ctx, url.Values{"per_page": {strconv.Itoa(perPage)}, "page": {strconv.Itoa(page)}})
+		header, err := c.get(ctx, projectPath(owner, repo)+"/repository/commits", query, &commits)
+		if err != nil {
+			return count, err
+		}
+		count += len(commits)
+		page = nextPage(header)
+	}
+	return count, nil
+}
+
+// milestones lists the milestones, active and closed, created by the snapshot date attached
+// to ctx. Milestones cannot be listed up to a date, so every page is read. GitLab does not
+// report when a milestone was closed.
+func (c *Client) milestones(ctx context.Context, owner, repo string) ([]models.MilestoneInfo, error) {
+	list := []models.MilestoneInfo{}
+	for page := 1; page != 0; {
+		var milestones []struct {
+			CreatedAt time.Time `json:"created_at"`
+		}
+		query := url.Values{"per_page": {strconv.Itoa(perPage)}, "page": {strconv.Itoa(page)}}
+		header, err := c.get(ctx, projectPath(owner, repo)+"/milestones", query, &milestones)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range milestones {
+			list = append(list, models.MilestoneInfo{CreatedAt: m.CreatedAt})
+		}
+		page = nextPage(header)
+	}
+	return source.MilestonesAsOf(list, source.AsOfFrom(ctx)), nil
+}
+
+// untilAsOf limits a commit listing to the snapshot date attached to ctx
+func untilAsOf(ctx context.Context, query url.Values) url.Values {
+	if asOf := source.AsOfFrom(ctx); !asOf.IsZero() {
+		query.Set("until", asOf.Format(time.RFC3339))
+	}
+	return query
+}
+
+// CheckRepoEligibility applies the commit count and active contributor prechecks
+func (c *Client) CheckRepoEligibility(ctx context.Context, owner, repo string, minCommits, days, minActive int) (bool, string, error) {
+	commitCount, err := c.commitCount(ctx, owner, repo, nil)
+	if err != nil {
+		return false, "", fmt.Errorf("error counting commits: %w", err)
+	}
+	if commitCount < minCommits {
+		return false, fmt.Sprintf("repository has fewer than %d commits (found %d)", minCommits, commitCount), nil
+	}
+
+	since := source.Now(ctx).AddDate(0, 0, -days)
+	seen := make(map[string]struct{})
+	err = c.scanCommits(ctx, owner, repo, since, maxActivePages*perPage, false, func(cm source.Commit) bool {
+		seen[cm.Identity()] = struct{}{}
+		return len(seen) < minActive
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("error checking active contributors: %w", err)
+	}
+	if len(seen) < minActive {
+		return false, fmt.Sprintf("fewer than %d active contributors in the last %d days (found %d)", minActive, days, len(seen)), nil
+	}
+
+	return true, "", nil
+}
@@ -0,0 +1,340 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github-extractor/source"
+)
+
+// fakeProject is the URL-encoded ID of the project the fake serves, in a subgroup
+const fakeProject = "projects/group%2Fsub%2Fwidget"
+
+// fakePageSize keeps the fake's pages small so every listing spans several of them
+const fakePageSize = 2
+
+type fakeCommit struct {
+	email string
+	date  time.Time
+}
+
+// fakeGitLab serves the parts of the GitLab API v4 the client reads for group/sub/widget.
+// Pages hold at most fakePageSize items and link the next one with X-Next-Page.
+type fakeGitLab struct {
+	commits    []fakeCommit // Newest first
+	total      bool         // Set X-Total on listings, as GitLab does below 10,000 items
+	statistics bool         // Return the project statistics
+
+	mu     sync.Mutex
+	paths  []string // Escaped paths of every request
+	untils []string // until parameters of the commit listings
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.paths = append(f.paths, r.URL.EscapedPath())
+	f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/")
+	query := r.URL.Query()
+	switch path {
+	case fakeProject:
+		p := map[string]interface{}{
+			"description":       "Widgets",
+			"star_count":        42,
+			"forks_count":       7,
+			"open_issues_count": 3,
+			"created_at":        "2020-01-01T00:00:00Z",
+			"last_activity_at":  "2025-01-01T00:00:00Z",
+			"default_branch":    "main",
+			"issues_enabled":    true,
+			"wiki_enabled":      false,
+			"license":           map[string]string{"name": "MIT License"},
+		}
+		if f.statistics {
+			p["statistics"] = map[string]int{"commit_count": 500, "repository_size": 4096}
+		}
+		writeJSON(w, p)
+
+	case fakeProject + "/languages":
+		writeJSON(w, map[string]float64{"Go": 80, "Shell": 20})
+
+	case fakeProject + "/repository/tree":
+		switch query.Get("path") {
+		case "":
+			writeJSON(w, []treeEntry{{"README.md", "blob"}, {"CONTRIBUTING.md", "blob"}, {".gitlab", "tree"}})
+		case ".gitlab":
+			writeJSON(w, []treeEntry{{"issue_templates", "tree"}, {"merge_request_templates", "tree"}, {"SECURITY.md", "blob"}})
+		default:
+			http.NotFound(w, r)
+		}
+
+	case fakeProject + "/repository/commits":
+		f.mu.Lock()
+		f.untils = append(f.untils, query.Get("until"))
+		f.mu.Unlock()
+		var items []interface{}
+		for _, c := range f.commits {
+			if until, err := time.Parse(time.RFC3339, query.Get("until")); err == nil && c.date.After(until) {
+				continue
+			}
+			if since, err := time.Parse(time.RFC3339, query.Get("since")); err == nil && c.date.Before(since) {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"author_name":   strings.Split(c.email, "@")[0],
+				"author_email":  c.email,
+				"authored_date": c.date.Format(time.RFC3339),
+				"stats":         map[string]int{"additions": 10, "deletions": 2},
+			})
+		}
+		f.page(w, r, items)
+
+	case fakeProject + "/repository/contributors":
+		counts := make(map[string]int)
+		for _, c := range f.commits {
+			counts[c.email]++
+		}
+		var items []interface{}
+		for email, n := range counts {
+			items = append(items, map[string]interface{}{"email": email, "commits": n})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(map[string]interface{})["email"].(string) < items[j].(map[string]interface{})["email"].(string)
+		})
+		f.page(w, r, items)
+
+	case fakeProject + "/milestones":
+		f.page(w, r, []interface{}{
+			map[string]string{"title": "v1", "state": "closed", "created_at": "2021-01-01T00:00:00Z"},
+			map[string]string{"title": "v2", "state": "closed", "created_at": "2022-01-01T00:00:00Z"},
+			map[string]string{"title": "v3", "state": "active", "created_at": "2023-01-01T00:00:00Z"},
+		})
+
+	case fakeProject + "/merge_requests":
+		f.page(w, r, []interface{}{
+			map[string]interface{}{"iid": 3, "state": "opened", "created_at": "2024-03-01T00:00:00Z"},
+			map[string]interface{}{"iid": 2, "state": "closed", "created_at": "2024-02-01T00:00:00Z", "closed_at": "2024-02-02T00:00:00Z"},
+			map[string]interface{}{"iid": 1, "state": "merged", "created_at": "2024-01-01T00:00:00Z", "merged_at": "2024-01-03T00:00:00Z"},
+		})
+
+	case "users":
+		if query.Get("search") == "alice@example.com" || query.Get("username") == "alice" {
+			writeJSON(w, []map[string]interface{}{{"id": 1, "username": "alice"}})
+			return
+		}
+		writeJSON(w, []interface{}{})
+
+	case "users/1":
+		writeJSON(w, map[string]interface{}{"id": 1, "username": "alice", "name": "Alice", "location": "Lisbon"})
+
+	case "users/1/following":
+		f.page(w, r, []interface{}{})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// page writes the page of items the page and per_page parameters ask for
+func (f *fakeGitLab) page(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	size := fakePageSize
+	if n, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil && n < size {
+		size = n
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	start := min((page-1)*size, len(items))
+	end := min(start+size, len(items))
+	if end < len(items) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	if f.total {
+		w.Header().Set("X-Total", strconv.Itoa(len(items)))
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+	writeJSON(w, items[start:end])
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// fakeCommits returns n commits by alice then m by bob, one a day going back from newest
+func fakeCommits(newest time.Time, n, m int) []fakeCommit {
+	var commits []fakeCommit
+	for i := 0; i < n+m; i++ {
+		email := "alice@example.com"
+		if i >= n {
+			email = "bob@example.com"
+		}
+		commits = append(commits, fakeCommit{email: email, date: newest.AddDate(0, 0, -i)})
+	}
+	return commits
+}
+
+func newTestClient(t *testing.T, fake *fakeGitLab) *Client {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := NewClient(srv.URL, "", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestProjectPath(t *testing.T) {
+	tests := []struct{ owner, repo, want string }{
+		{"acme", "widget", "projects/acme%2Fwidget"},
+		{"group/sub", "widget", "projects/group%2Fsub%2Fwidget"},
+	}
+	for _, tt := range tests {
+		if got := projectPath(tt.owner, tt.repo); got != tt.want {
+			t.Errorf("projectPath(%q, %q) = %q, want %q", tt.owner, tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestGetRepositoryInfo(t *testing.T) {
+	fake := &fakeGitLab{commits: fakeCommits(time.Now().UTC().Truncate(time.Hour), 6, 3), total: true}
+	c := newTestClient(t, fake)
+
+	info := c.GetRepositoryInfo(context.Background(), "group/sub", "widget")
+	if info.Error != "" {
+		t.Fatalf("error = %s", info.Error)
+	}
+
+	// The subgroup path stays a single escaped segment
+	for _, p := range fake.paths {
+		if strings.HasPrefix(p, "/api/v4/projects/") && !strings.HasPrefix(p, "/api/v4/"+fakeProject) {
+			t.Errorf("request to %s, want the project ID escaped", p)
+		}
+	}
+
+	if info.Description != "Widgets" || info.Language != "Go" || info.License != "MIT License" || info.Stars != 42 || info.Forks != 7 {
+		t.Errorf("details = %q %q %q %d stars %d forks", info.Description, info.Language, info.License, info.Stars, info.Forks)
+	}
+	if !info.HasReadme || !info.HasContributingGuidelines || !info.HasSecurityPolicy || !info.HasIssuesTemplate || !info.HasPullRequestTemplate || info.HasCodeOfConduct {
+		t.Errorf("community files: readme %v, contributing %v, security %v, issue template %v, merge request template %v, code of conduct %v; want all but the code of conduct",
+			info.HasReadme, info.HasContributingGuidelines, info.HasSecurityPolicy, info.HasIssuesTemplate, info.HasPullRequestTemplate, info.HasCodeOfConduct)
+	}
+	if info.Commits != 9 {
+		t.Errorf("commits = %d, want 9", info.Commits)
+	}
+	if info.Milestones != 3 {
+		t.Errorf("milestones = %d, want the 3 of both pages", info.Milestones)
+	}
+	if info.TotalContributorsCount != 2 || len(info.ContributorStats) != 2 {
+		t.Errorf("%d contributors with %d stats, want 2", info.TotalContributorsCount, len(info.ContributorStats))
+	}
+
+	var prs []string
+	for _, pr := range info.PullRequests {
+		prs = append(prs, fmt.Sprintf("%d:%s", pr.Number, pr.Status))
+		if pr.ClosedAt == nil {
+			t.Errorf("pull request %d has no close date", pr.Number)
+		}
+	}
+	if got := strings.Join(prs, " "); got != "2:closed 1:merged" {
+		t.Errorf("pull requests %s, want 2:closed 1:merged", got)
+	}
+
+	if len(info.Contributors) != 1 || info.Contributors[0].Login != "alice" || info.Contributors[0].Location != "Lisbon" {
+		t.Errorf("contributors = %+v, want alice's profile", info.Contributors)
+	}
+}
+
+func TestCommitCount(t *testing.T) {
+	newest := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	asOf := newest.AddDate(0, 0, -4)
+
+	tests := []struct {
+		name       string
+		total      bool
+		statistics bool
+		asOf       time.Time
+		want       int
+	}{
+		{"X-Total", true, true, time.Time{}, 9},
+		{"statistics without X-Total", false, true, time.Time{}, 500},
+		{"paging without X-Total or statistics", false, false, time.Time{}, 9},
+		{"as_of pages up to the date", false, true, asOf, 5},
+		{"as_of with X-Total", true, true, asOf, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeGitLab{commits: fakeCommits(newest, 6, 3), total: tt.total, statistics: tt.statistics}
+			c := newTestClient(t, fake)
+			ctx := source.WithAsOf(context.Background(), tt.asOf)
+
+			var p project
+			if _, err := c.get(ctx, projectPath("group/sub", "widget"), nil, &p); err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.commitCount(ctx, "group/sub", "widget", &p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("commits = %d, want %d", got, tt.want)
+			}
+
+			want := ""
+			if !tt.asOf.IsZero() {
+				want = tt.asOf.Format(time.RFC3339)
+			}
+			for _, until := range fake.untils {
+				if until != want {
+					t.Errorf("commit listing until %q, want %q", until, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRepoEligibility(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	// alice committed in the last 2 days, bob before that
+	fake := &fakeGitLab{commits: fakeCommits(now, 2, 3), total: true}
+	c := newTestClient(t, fake)
+
+	tests := []struct {
+		days, minActive int
+		want            bool
+	}{
+		{1, 1, true},
+		{1, 2, false},
+		{10, 2, true},
+	}
+	for _, tt := range tests {
+		ok, reason, err := c.CheckRepoEligibility(context.Background(), "group/sub", "widget", 5, tt.days, tt.minActive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("%d active in %d days: eligible %v (%s), want %v", tt.minActive, tt.days, ok, reason, tt.want)
+		}
+	}
+
+	if ok, reason, _ := c.CheckRepoEligibility(context.Background(), "group/sub", "widget", 6, 10, 1); ok || !strings.Contains(reason, "fewer than 6 commits") {
+		t.Errorf("eligible %v (%s), want too few commits", ok, reason)
+	}
+}
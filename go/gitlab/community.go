@@ -0,0 +1,364 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+type commit struct {
+	AuthorName   string    `json:"author_name"`
+	AuthorEmail  string    `json:"author_email"`
+	AuthoredDate time.Time `json:"authored_date"`
+	Stats        *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+type user struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	AvatarURL    string    `json:"avatar_url"`
+	WebURL       string    `json:"web_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	Bio          string    `json:"bio"`
+	Location     string    `json:"location"`
+	PublicEmail  string    `json:"public_email"`
+	WebsiteURL   string    `json:"website_url"`
+	Organization string    `json:"organization"`
+	Bot          bool      `json:"bot"`
+}
+
+// scanCommits pages through the commits newer than since (all when zero) and up to the
+// snapshot date attached to ctx, newest first, calling fn for each until it returns false
+// or limit commits have been seen
+func (c *Client) scanCommits(ctx context.Context, owner, repo string, since time.Time, limit int, withStats bool, fn func(source.Commit) bool) error {
+	seen := 0
+	for page := 1; page != 0; {
+		query := untilAsOf(ctx, url.Values{"per_page": {strconv.Itoa(perPage)}, "page": {strconv.Itoa(page)}})
+		if !since.IsZero() {
+			query.Set("since", since.UTC().Format(time.RFC3339))
+		}
+		if withStats {
+			query.Set("with_stats", "true")
+		}
+
+		var commits []commit
+		header, err := c.get(ctx, projectPath(owner, repo)+"/repository/commits", query, &commits)
+		if err != nil {
+			return err
+		}
+		for _, cm := range commits {
+			sc := source.Commit{Email: cm.AuthorEmail, Name: cm.AuthorName, Date: cm.AuthoredDate}
+			if cm.Stats != nil {
+				sc.Additions = cm.Stats.Additions
+				sc.Deletions = cm.Stats.Deletions
+			}
+			seen++
+			if !fn(sc) || seen >= limit {
+				return nil
+			}
+		}
+		page = nextPage(header)
+	}
+	return nil
+}
+
+// Contributors returns the commit authors ordered by commit count. GitLab attributes
+// commits by email, so contributors are identified by lowercased email and all of them
+// count as non-anonymous. The contributor list covers the whole history, so a snapshot
+// ranks the authors of the commits made by the snapshot date instead.
+func (c *Client) Contributors(ctx context.Context, owner, repo string) ([]source.Contributor, int, int, error) {
+	if !source.AsOfFrom(ctx).IsZero() {
+		stats, err := c.ContributorStats(ctx, owner, repo)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		contributors, total, nonAnon := source.ContributorsFromStats(stats)
+		return contributors, total, nonAnon, nil
+	}
+
+	type contributor struct {
+		Email   string `json:"email"`
+		Commits int    `json:"commits"`
+	}
+
+	var all []contributor
+	for page := 1; page != 0; {
+		query := url.Values{
+			"order_by": {"commits"},
+			"sort":     {"desc"},
+			"per_page": {strconv.Itoa(perPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		var batch []contributor
+		header, err := c.get(ctx, projectPath(owner, repo)+"/repository/contributors", query, &batch)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		all = append(all, batch...)
+		page = nextPage(header)
+	}
+
+	// Pages are sorted on their own; make the whole list ordered
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Commits > all[j].Commits })
+
+	contributors := make([]source.Contributor, 0, len(all))
+	for _, ct := range all {
+		contributors = append(contributors, source.Contributor{Login: strings.ToLower(ct.Email), Contributions: ct.Commits})
+	}
+	return contributors, len(all), len(all), nil
+}
+
+// RecentContributors returns the emails of the authors that committed in the last days days
+func (c *Client) RecentContributors(ctx context.Context, owner, repo string, days int) ([]string, error) {
+	since := source.Now(ctx).AddDate(0, 0, -days)
+	var commits []source.Commit
+	err := c.scanCommits(ctx, owner, repo, since, maxStatsCommits, false, func(cm source.Commit) bool {
+		commits = append(commits, cm)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return source.RecentAuthors(commits, since), nil
+}
+
+// ContributorStats derives weekly activity from the most recent maxStatsCommits commits
+func (c *Client) ContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
+	var commits []source.Commit
+	err := c.scanCommits(ctx, owner, repo, time.Time{}, maxStatsCommits, true, func(cm source.Commit) bool {
+		commits = append(commits, cm)
+		return true
+	})
+	if err != nil {
+		return []models.ContributorStats{}, err
+	}
+	return source.SummarizeCommits(commits).Stats, nil
+}
+
+// PullRequests returns up to max closed or merged merge requests, newest first, leaving
+// out those closed after the snapshot date attached to ctx. GitLab leaves closed_at empty
+// on merged requests, so their merge time is used instead.
+func (c *Client) PullRequests(ctx context.Context, owner, repo string, max int) ([]models.PullRequestInfo, error) {
+	type mergeRequest struct {
+		IID       int        `json:"iid"`
+		State     string     `json:"state"`
+		CreatedAt *time.Time `json:"created_at"`
+		ClosedAt  *time.Time `json:"closed_at"`
+		MergedAt  *time.Time `json:"merged_at"`
+	}
+
+	asOf := source.AsOfFrom(ctx)
+	var prs []models.PullRequestInfo
+	for page := 1; page != 0; {
+		query := url.Values{
+			"scope":    {"all"},
+			"order_by": {"created_at"},
+			"sort":     {"desc"},
+			"per_page": {strconv.Itoa(perPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		if !asOf.IsZero() {
+			query.Set("created_before", asOf.Format(time.RFC3339))
+		}
+		var batch []mergeRequest
+		header, err := c.get(ctx, projectPath(owner, repo)+"/merge_requests", query, &batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mr := range batch {
+			if mr.State != "closed" && mr.State != "merged" {
+				continue
+			}
+			if max > 0 && len(prs) >= max {
+				return prs, nil
+			}
+
+			pr := models.PullRequestInfo{
+				Number:    mr.IID,
+				Status:    mr.State,
+				CreatedAt: mr.CreatedAt,
+				ClosedAt:  mr.ClosedAt,
+				MergedAt:  mr.MergedAt,
+			}
+			if pr.ClosedAt == nil {
+				pr.ClosedAt = pr.MergedAt
+			}
+			if !source.ClosedBy(pr, asOf) {
+				continue
+			}
+			prs = append(prs, pr)
+		}
+		page = nextPage(header)
+	}
+	return prs, nil
+}
+
+// ContributorDetails fetches the profile of each contributor. Emails are resolved to
+// accounts through the public email search, so authors without a public email on their
+// profile come back with an Error.
+func (c *Client) ContributorDetails(ctx context.Context, logins []string) ([]models.ContributorDetail, error) {
+	if len(logins) == 0 {
+		return nil, nil
+	}
+
+	results := make([]models.ContributorDetail, len(logins))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+
+	for i, login := range logins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, identity string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			u, err := c.lookupUser(ctx, identity)
+			if err != nil {
+				c.logger.Debugf("Failed to fetch GitLab user %s: %v", identity, err)
+				results[idx] = models.ContributorDetail{Login: identity, Error: err.Error()}
+				return
+			}
+
+			detail := models.ContributorDetail{
+				Login:     u.Username,
+				ID:        u.ID,
+				NodeID:    fmt.Sprintf("gid://gitlab/User/%d", u.ID),
+				AvatarURL: u.AvatarURL,
+				HTMLURL:   u.WebURL,
+				Type:      "User",
+				Name:      u.Name,
+				Company:   u.Organization,
+				Blog:      u.WebsiteURL,
+				Location:  u.Location,
+				Email:     u.PublicEmail,
+				Bio:       u.Bio,
+				CreatedAt: u.CreatedAt,
+			}
+			if u.Bot {
+				detail.Type = "Bot"
+			}
+			results[idx] = detail
+		}(i, login)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Error == "" {
+			return results, nil
+		}
+	}
+	return results, fmt.Errorf("all contributor detail requests failed. First error: %s", results[0].Error)
+}
+
+// lookupUser finds the account behind an email or username and fetches its full profile
+func (c *Client) lookupUser(ctx context.Context, identity string) (*user, error) {
+	query := url.Values{"username": {identity}}
+	if strings.Contains(identity, "@") {
+		query = url.Values{"search": {identity}}
+	}
+
+	var matches []user
+	if _, err := c.get(ctx, "users", query, &matches); err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no GitLab account found for %s", identity)
+	}
+
+	var u user
+	if _, err := c.get(ctx, "users/"+strconv.FormatInt(matches[0].ID, 10), nil, &u); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.userIDs[strings.ToLower(u.Username)] = u.ID
+	c.mu.Unlock()
+	return &u, nil
+}
+
+// userID returns the ID of a username, looking it up when it was not seen before
+func (c *Client) userID(ctx context.Context, username string) (int64, error) {
+	c.mu.Lock()
+	id, ok := c.userIDs[strings.ToLower(username)]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	u, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	return u.ID, nil
+}
+
+// FollowGraph returns the follow edges between the given users
+func (c *Client) FollowGraph(ctx context.Context, logins []string) (models.FollowGraph, error) {
+	lists := make(map[string][]string, len(logins))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 5)
+	failed := 0
+
+	for _, login := range logins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(login string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			following, err := c.following(ctx, login)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				c.logger.Debugf("Failed to fetch following list for %s: %v", login, err)
+				failed++
+				return
+			}
+			lists[login] = following
+		}(login)
+	}
+	wg.Wait()
+
+	graph := source.FollowEdges(logins, lists)
+	if failed == len(logins) && len(logins) > 0 {
+		return graph, fmt.Errorf("failed to fetch community following lists for all contributors")
+	}
+	return graph, nil
+}
+
+// following returns the usernames a user follows
+func (c *Client) following(ctx context.Context, username string) ([]string, error) {
+	id, err := c.userID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []string
+	for page := 1; page != 0; {
+		query := url.Values{"per_page": {strconv.Itoa(perPage)}, "page": {strconv.Itoa(page)}}
+		var users []user
+		header, err := c.get(ctx, "users/"+strconv.FormatInt(id, 10)+"/following", query, &users)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			logins = append(logins, u.Username)
+		}
+		page = nextPage(header)
+	}
+	return logins, nil
+}
@@ -0,0 +1,170 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github-extractor/source"
+)
+
+type author struct {
+	Username string `json:"username"`
+}
+
+// login returns the username of a, or "" when the author is missing
+func (a *author) login() string {
+	if a == nil {
+		return ""
+	}
+	return a.Username
+}
+
+type threadItem struct {
+	IID            int       `json:"iid"`
+	Author         *author   `json:"author"`
+	Upvotes        int       `json:"upvotes"`
+	Downvotes      int       `json:"downvotes"`
+	UserNotesCount int       `json:"user_notes_count"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	mergeRequest   bool
+}
+
+type note struct {
+	Author *author `json:"author"`
+	System bool    `json:"system"` // Notes GitLab writes itself, such as label changes
+	Type   string  `json:"type"`   // "DiffNote" for comments on a merge request diff
+}
+
+// Threads returns up to max of the most recently updated issues and merge requests with
+// their first source.MaxResponses notes, and the approvals of merge requests as reviews,
+// five threads at a time. Award emoji on notes are not read; those on the issue or merge
+// request itself count as its reactions.
+func (c *Client) Threads(ctx context.Context, owner, repo string, max int) ([]source.Thread, error) {
+	var items []threadItem
+	for _, kind := range []string{"issues", "merge_requests"} {
+		batch, err := c.listThreadItems(ctx, owner, repo, kind, max)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].UpdatedAt.After(items[j].UpdatedAt) })
+	if len(items) > max {
+		items = items[:max]
+	}
+
+	threads := make([]source.Thread, len(items))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, 5)
+	failed := 0
+	var firstErr error
+
+	for i, item := range items {
+		threads[i].Author = item.Author.login()
+		threads[i].Reactions = item.Upvotes + item.Downvotes
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, item threadItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			responses, err := c.threadResponses(ctx, owner, repo, item)
+			if err != nil {
+				c.logger.Debugf("Failed to fetch the notes of %s/%s!%d: %v", owner, repo, item.IID, err)
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			threads[idx].Responses = responses
+		}(i, item)
+	}
+	wg.Wait()
+
+	if failed == len(items) && len(items) > 0 {
+		return nil, fmt.Errorf("all thread response requests failed. First error: %v", firstErr)
+	}
+	return threads, nil
+}
+
+// listThreadItems returns up to max of the most recently updated issues or merge requests
+func (c *Client) listThreadItems(ctx context.Context, owner, repo, kind string, max int) ([]threadItem, error) {
+	var items []threadItem
+	for page := 1; page != 0 && len(items) < max; {
+		query := url.Values{
+			"state":    {"all"},
+			"order_by": {"updated_at"},
+			"sort":     {"desc"},
+			"per_page": {strconv.Itoa(perPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		var batch []threadItem
+		header, err := c.get(ctx, projectPath(owner, repo)+"/"+kind, query, &batch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			batch[i].mergeRequest = kind == "merge_requests"
+		}
+		items = append(items, batch...)
+		page = nextPage(header)
+	}
+	if len(items) > max {
+		items = items[:max]
+	}
+	return items, nil
+}
+
+// threadResponses reads the notes of an issue or merge request and who approved a
+// merge request
+func (c *Client) threadResponses(ctx context.Context, owner, repo string, item threadItem) ([]source.Response, error) {
+	kind := "issues"
+	if item.mergeRequest {
+		kind = "merge_requests"
+	}
+	base := fmt.Sprintf("%s/%s/%d", projectPath(owner, repo), kind, item.IID)
+
+	var responses []source.Response
+	if item.UserNotesCount > 0 {
+		var notes []note
+		query := url.Values{"per_page": {strconv.Itoa(source.MaxResponses)}, "sort": {"asc"}}
+		if _, err := c.get(ctx, base+"/notes", query, &notes); err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			if n.System {
+				continue
+			}
+			response := source.Response{Kind: source.ResponseComment, Author: n.Author.login()}
+			if n.Type == "DiffNote" {
+				response.Kind = source.ResponseReviewComment
+			}
+			responses = append(responses, response)
+		}
+	}
+	if !item.mergeRequest {
+		return responses, nil
+	}
+
+	var approvals struct {
+		ApprovedBy []struct {
+			User *author `json:"user"`
+		} `json:"approved_by"`
+	}
+	if _, err := c.get(ctx, base+"/approvals", nil, &approvals); err != nil {
+		return nil, err
+	}
+	for _, a := range approvals.ApprovedBy {
+		responses = append(responses, source.Response{Kind: source.ResponseReview, Author: a.User.login()})
+	}
+	return responses, nil
+}
@@ -0,0 +1,311 @@
+package gitlocal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// Repo is a local git repository read through the git command line. Everything is read
+// from HEAD, so a working tree may have uncommitted changes without affecting results.
+type Repo struct {
+	dir string
+}
+
+// locks serializes clones and fetches of the same directory
+var locks sync.Map
+
+// Open opens the repository at dir, which may be a working tree or a bare repository
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	r := &Repo{dir: dir}
+	if _, err := r.git(ctx, nil, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
+	return r, nil
+}
+
+// Sync makes dir a current copy of remote. A missing dir is cloned as a bare repository,
+// a bare repository is fetched again, and a working tree is opened as it is, so a
+// checkout placed there by hand is never touched. The token, when set, authenticates
+// HTTPS remotes without appearing on the command line.
+func Sync(ctx context.Context, remote, dir, token string) (*Repo, error) {
+	mu, _ := locks.LoadOrStore(dir, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	env := authEnv(token)
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return nil, fmt.Errorf("create clone directory: %w", err)
+		}
+		// Clone into a temporary sibling so an interrupted clone is never mistaken for a repository
+		tmp := dir + ".tmp"
+		os.RemoveAll(tmp)
+		if _, err := run(ctx, "", env, "clone", "--bare", "--quiet", remote, tmp); err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("clone %s: %w", remote, err)
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("move clone into place: %w", err)
+		}
+		return &Repo{dir: dir}, nil
+	}
+
+	r, err := Open(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.git(ctx, nil, "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out) == "true" {
+		if _, err := r.git(ctx, env, "fetch", "--quiet", "--prune", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", remote, err)
+		}
+	}
+	return r, nil
+}
+
+// Dir returns the directory of the repository
+func (r *Repo) Dir() string {
+	return r.dir
+}
+
+// CommitCount returns the number of commits reachable from HEAD, merges included
+func (r *Repo) CommitCount(ctx context.Context) (int, error) {
+	out, err := r.git(ctx, nil, asOfArgs(ctx, "rev-list", "--count", "HEAD")...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+// Commits returns the non-merge commits reachable from HEAD, newest first, with their
+// line stats. Authors go through the repository mailmap; Login is left empty since git
+// only knows names and emails.
+func (r *Repo) Commits(ctx context.Context) ([]source.Commit, error) {
+	cmd := r.command(ctx, nil, asOfArgs(ctx, "log", "--no-merges", "--use-mailmap", "--numstat", "--no-renames",
+		"--format=%x1e%aN%x1f%aE%x1f%at", "HEAD")...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	commits, parseErr := parseLog(stdout)
+	if parseErr != nil {
+		// Drain the output so git can exit
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, commandError(err, stderr.String())
+	}
+	return commits, parseErr
+}
+
+// asOfArgs inserts a --before option for the snapshot date attached to ctx after the git
+// subcommand in args. Like the API, it compares commit dates.
+func asOfArgs(ctx context.Context, args ...string) []string {
+	asOf := source.AsOfFrom(ctx)
+	if asOf.IsZero() {
+		return args
+	}
+	return append([]string{args[0], "--before=" + asOf.Format(time.RFC3339)}, args[1:]...)
+}
+
+// parseLog reads the output of the git log call in Commits: a header record per commit
+// followed by one "additions<TAB>deletions<TAB>path" line per changed file
+func parseLog(r io.Reader) ([]source.Commit, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var commits []source.Commit
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x1e") {
+			fields := strings.Split(line[1:], "\x1f")
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("unexpected git log header %q", line)
+			}
+			ts, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected commit time %q", fields[2])
+			}
+			commits = append(commits, source.Commit{
+				Name:  fields[0],
+				Email: fields[1],
+				Date:  time.Unix(ts, 0).UTC(),
+			})
+			continue
+		}
+		if line == "" || len(commits) == 0 {
+			continue
+		}
+
+		// Binary files are listed with "-" for both counts and add no lines
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		c := &commits[len(commits)-1]
+		if n, err := strconv.Atoi(parts[0]); err == nil {
+			c.Additions += n
+		}
+		if n, err := strconv.Atoi(parts[1]); err == nil {
+			c.Deletions += n
+		}
+	}
+	return commits, scanner.Err()
+}
+
+// ContributorStats derives the stats/contributors data from the commit history
+func (r *Repo) ContributorStats(ctx context.Context) ([]models.ContributorStats, error) {
+	commits, err := r.Commits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return source.SummarizeCommits(commits).Stats, nil
+}
+
+// CommunityFiles records which community health files a repository has
+type CommunityFiles struct {
+	Readme              bool
+	License             bool
+	Contributing        bool
+	CodeOfConduct       bool
+	SecurityPolicy      bool
+	IssueTemplate       bool
+	PullRequestTemplate bool
+}
+
+// CommunityFiles looks for community files where GitHub does: in the root, .github
+// and docs directories of the tree at HEAD
+func (r *Repo) CommunityFiles(ctx context.Context) (CommunityFiles, error) {
+	out, err := r.git(ctx, nil, "ls-tree", "-r", "--name-only", "HEAD", "--", ".github", "docs")
+	if err != nil {
+		return CommunityFiles{}, err
+	}
+	root, err := r.git(ctx, nil, "ls-tree", "--name-only", "HEAD")
+	if err != nil {
+		return CommunityFiles{}, err
+	}
+
+	var files CommunityFiles
+	for _, path := range strings.Split(root+out, "\n") {
+		path = strings.ToLower(strings.TrimSpace(path))
+		if path == "" {
+			continue
+		}
+		dir, name := filepath.Split(path)
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i]
+		}
+		dir = strings.TrimSuffix(dir, "/")
+
+		switch {
+		case strings.HasSuffix(dir, "issue_template"):
+			files.IssueTemplate = true
+		case strings.HasSuffix(dir, "pull_request_template"):
+			files.PullRequestTemplate = true
+		case strings.Contains(dir, "/"):
+			// Only files directly in the root, .github and docs count
+		case name == "readme":
+			files.Readme = true
+		case name == "license" || name == "licence" || name == "copying":
+			files.License = true
+		case name == "contributing":
+			files.Contributing = true
+		case name == "code_of_conduct" || name == "code-of-conduct":
+			files.CodeOfConduct = true
+		case name == "security":
+			files.SecurityPolicy = true
+		case name == "issue_template":
+			files.IssueTemplate = true
+		case name == "pull_request_template":
+			files.PullRequestTemplate = true
+		}
+	}
+	return files, nil
+}
+
+// Apply sets the community file flags of info
+func (f CommunityFiles) Apply(info *models.RepositoryInfo) {
+	info.HasReadme = f.Readme
+	info.HasContributingGuidelines = f.Contributing
+	info.HasCodeOfConduct = f.CodeOfConduct
+	info.HasSecurityPolicy = f.SecurityPolicy
+	info.HasIssuesTemplate = f.IssueTemplate
+	info.HasPullRequestTemplate = f.PullRequestTemplate
+	info.HasLicense = info.HasLicense || f.License
+}
+
+// git runs a git command in the repository and returns its output
+func (r *Repo) git(ctx context.Context, env []string, args ...string) (string, error) {
+	return run(ctx, r.dir, env, args...)
+}
+
+func (r *Repo) command(ctx context.Context, env []string, args ...string) *exec.Cmd {
+	return command(ctx, r.dir, env, args...)
+}
+
+func command(ctx context.Context, dir string, env []string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never prompt for credentials; a missing token must fail instead of hanging
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, env...)
+	return cmd
+}
+
+func run(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := command(ctx, dir, env, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", commandError(err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// commandError adds the message git printed to a failed command's error
+func commandError(err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("git: %s: %w", msg, err)
+	}
+	return fmt.Errorf("git: %w", err)
+}
+
+// authEnv passes the token as an HTTP authorization header through the environment,
+// the way GitHub, GitLab and Gitea accept it for HTTPS remotes
+func authEnv(token string) []string {
+	if token == "" {
+		return nil
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
@@ -0,0 +1,196 @@
+package gitlocal
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/source"
+)
+
+// testRepo is a working tree in a temporary directory that tests commit to
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git(nil, "init", "--quiet", "--initial-branch=main")
+	return r
+}
+
+func (r *testRepo) git(env []string, args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Committer", "GIT_AUTHOR_EMAIL=committer@example.com",
+		"GIT_COMMITTER_NAME=Committer", "GIT_COMMITTER_EMAIL=committer@example.com")
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// commit writes files and commits them as author at date
+func (r *testRepo) commit(author string, date time.Time, files map[string]string) {
+	r.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(r.dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	stamp := date.Format(time.RFC3339)
+	r.git(nil, "add", "-A")
+	r.git([]string{"GIT_AUTHOR_DATE=" + stamp, "GIT_COMMITTER_DATE=" + stamp},
+		"commit", "--quiet", "--allow-empty", "--author", author, "-m", "change")
+}
+
+var (
+	day1 = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	day2 = time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	day3 = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func TestCommits(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice <alice@example.com>", day1, map[string]string{"a.txt": "1\n2\n3\n", "logo.bin": "\x00\x01"})
+	r.commit("Bob <bob@old.example.com>", day2, map[string]string{"a.txt": "1\n2\n"})
+	r.git(nil, "checkout", "--quiet", "-b", "topic")
+	r.commit("Alice <alice@example.com>", day3, map[string]string{"b.txt": "b\n"})
+	r.git(nil, "checkout", "--quiet", "main")
+	r.git([]string{"GIT_AUTHOR_DATE=" + day3.Format(time.RFC3339), "GIT_COMMITTER_DATE=" + day3.Format(time.RFC3339)},
+		"merge", "--quiet", "--no-ff", "-m", "merge", "topic")
+	// The mailmap maps the old email of Bob to the new one
+	r.commit("Bob <bob@old.example.com>", day3, map[string]string{".mailmap": "Robert <bob@example.com> <bob@old.example.com>\n"})
+
+	ctx := context.Background()
+	repo, err := Open(ctx, r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := repo.CommitCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("commit count = %d, want 5 with the merge", n)
+	}
+
+	commits, err := repo.Commits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []source.Commit{
+		{Name: "Robert", Email: "bob@example.com", Date: day3, Additions: 1},
+		{Name: "Alice", Email: "alice@example.com", Date: day3, Additions: 1},
+		{Name: "Robert", Email: "bob@example.com", Date: day2, Deletions: 1},
+		{Name: "Alice", Email: "alice@example.com", Date: day1, Additions: 3},
+	}
+	if len(commits) != len(want) {
+		t.Fatalf("commits = %+v, want %d without the merge", commits, len(want))
+	}
+	for i := range want {
+		if commits[i] != want[i] {
+			t.Errorf("commit %d = %+v, want %+v", i, commits[i], want[i])
+		}
+	}
+
+	// A snapshot stops at its date
+	snapshot := source.WithAsOf(ctx, day2)
+	if n, err := repo.CommitCount(snapshot); err != nil || n != 2 {
+		t.Errorf("commit count as of %v = %d, %v, want 2", day2, n, err)
+	}
+	if commits, err := repo.Commits(snapshot); err != nil || len(commits) != 2 {
+		t.Errorf("commits as of %v = %d, %v, want 2", day2, len(commits), err)
+	}
+}
+
+func TestCommunityFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("Alice <alice@example.com>", day1, map[string]string{
+		"README.md":                        "readme",
+		"COPYING":                          "license",
+		"docs/CONTRIBUTING.md":             "contributing",
+		".github/ISSUE_TEMPLATE/bug.md":    "bug",
+		".github/workflows/security.yml":   "not a policy",
+		"docs/guides/CODE_OF_CONDUCT.md":   "too deep",
+		".github/pull_request_template.md": "template",
+		"src/SECURITY.md":                  "not in a community directory",
+	})
+
+	ctx := context.Background()
+	repo, err := Open(ctx, r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := repo.CommunityFiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CommunityFiles{Readme: true, License: true, Contributing: true, IssueTemplate: true, PullRequestTemplate: true}
+	if files != want {
+		t.Errorf("community files = %+v, want %+v", files, want)
+	}
+}
+
+func TestSync(t *testing.T) {
+	remote := newTestRepo(t)
+	remote.commit("Alice <alice@example.com>", day1, map[string]string{"a.txt": "a\n"})
+	ctx := context.Background()
+
+	// A missing directory is cloned bare
+	dir := filepath.Join(t.TempDir(), "clones", "widget.git")
+	repo, err := Sync(ctx, remote.dir, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.CommitCount(ctx); err != nil || n != 1 {
+		t.Errorf("commit count after clone = %d, %v, want 1", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		t.Errorf("clone is not bare: %v", err)
+	}
+	if _, err := os.Stat(dir + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary clone left behind: %v", err)
+	}
+
+	// A bare clone is fetched again
+	remote.commit("Bob <bob@example.com>", day2, map[string]string{"a.txt": "b\n"})
+	if repo, err = Sync(ctx, remote.dir, dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.CommitCount(ctx); err != nil || n != 2 {
+		t.Errorf("commit count after fetch = %d, %v, want 2", n, err)
+	}
+
+	// A working tree is opened as it is
+	checkout := newTestRepo(t)
+	checkout.commit("Carol <carol@example.com>", day1, map[string]string{"c.txt": "c\n"})
+	if repo, err = Sync(ctx, remote.dir, checkout.dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.CommitCount(ctx); err != nil || n != 1 {
+		t.Errorf("commit count of the working tree = %d, %v, want its own 1", n, err)
+	}
+
+	if _, err := Open(ctx, t.TempDir()); err == nil {
+		t.Error("opened a directory that is not a repository")
+	}
+}
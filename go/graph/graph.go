@@ -0,0 +1,295 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github-extractor/models"
+)
+
+// Format is a graph file format the follow graph can be exported as
+type Format string
+
+const (
+	GraphML Format = "graphml"
+	GEXF    Format = "gexf"
+	DOT     Format = "dot"
+)
+
+// ParseFormat returns the format named s, case-insensitively. An empty s selects GraphML.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return GraphML, nil
+	case GraphML, GEXF, DOT:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown graph format %q, expected graphml, gexf or dot", s)
+}
+
+// ContentType returns the MIME type of files in format f
+func (f Format) ContentType() string {
+	if f == DOT {
+		return "text/vnd.graphviz; charset=utf-8"
+	}
+	return "application/xml; charset=utf-8"
+}
+
+// node is a graph member with the profile attributes known for it
+type node struct {
+	ID       string
+	Name     string
+	Company  string
+	Location string
+}
+
+// attributes are the node attributes every format declares, in output order
+var attributes = []string{"name", "company", "location"}
+
+// value returns the node attribute called attr
+func (n node) value(attr string) string {
+	switch attr {
+	case "name":
+		return n.Name
+	case "company":
+		return n.Company
+	default:
+		return n.Location
+	}
+}
+
+// nodes returns the members of the follow graph of info sorted by login, with the
+// profile of those listed among its contributors. Edge endpoints missing from the
+// members are added so every edge refers to a node.
+func nodes(info models.RepositoryInfo) []node {
+	profiles := make(map[string]models.ContributorDetail, len(info.Contributors))
+	for _, c := range info.Contributors {
+		profiles[strings.ToLower(c.Login)] = c
+	}
+
+	seen := make(map[string]struct{})
+	var result []node
+	add := func(login string) {
+		key := strings.ToLower(login)
+		if _, ok := seen[key]; ok || login == "" {
+			return
+		}
+		seen[key] = struct{}{}
+		p := profiles[key]
+		result = append(result, node{ID: login, Name: p.Name, Company: p.Company, Location: p.Location})
+	}
+	for _, m := range info.FollowGraph.Members {
+		add(m)
+	}
+	for _, e := range info.FollowGraph.Edges {
+		add(e.Follower)
+		add(e.Followed)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Write writes the follow graph of info to w in format f
+func Write(w io.Writer, f Format, info models.RepositoryInfo) error {
+	switch f {
+	case GraphML:
+		return writeGraphML(w, info)
+	case GEXF:
+		return writeGEXF(w, info)
+	case DOT:
+		return writeDOT(w, info)
+	}
+	return fmt.Errorf("unknown graph format %q", f)
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+// writeGraphML writes the graph as GraphML, leaving out empty attributes
+func writeGraphML(w io.Writer, info models.RepositoryInfo) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: info.Owner + "/" + info.Repo, EdgeDefault: "directed"},
+	}
+	for _, attr := range attributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attr, For: "node", Name: attr, Type: "string"})
+	}
+	for _, n := range nodes(info) {
+		gn := graphMLNode{ID: n.ID}
+		for _, attr := range attributes {
+			if v := n.value(attr); v != "" {
+				gn.Data = append(gn.Data, graphMLData{Key: attr, Value: v})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+	for _, e := range info.FollowGraph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.Follower, Target: e.Followed})
+	}
+	return writeXML(w, doc)
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string         `xml:"defaultedgetype,attr"`
+	Mode            string         `xml:"mode,attr"`
+	Attributes      gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode     `xml:"nodes>node"`
+	Edges           []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues *gexfAttValues `xml:"attvalues"` // Nil when the node has no attributes
+}
+
+type gexfAttValues struct {
+	Values []gexfAttValue `xml:"attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+// writeGEXF writes the graph as GEXF 1.3, leaving out empty attributes
+func writeGEXF(w io.Writer, info models.RepositoryInfo) error {
+	doc := gexfDocument{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes:      gexfAttributes{Class: "node"},
+		},
+	}
+	for _, attr := range attributes {
+		doc.Graph.Attributes.Attributes = append(doc.Graph.Attributes.Attributes, gexfAttribute{ID: attr, Title: attr, Type: "string"})
+	}
+	for _, n := range nodes(info) {
+		gn := gexfNode{ID: n.ID, Label: n.ID}
+		for _, attr := range attributes {
+			if v := n.value(attr); v != "" {
+				if gn.AttValues == nil {
+					gn.AttValues = &gexfAttValues{}
+				}
+				gn.AttValues.Values = append(gn.AttValues.Values, gexfAttValue{For: attr, Value: v})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+	for i, e := range info.FollowGraph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{ID: strconv.Itoa(i), Source: e.Follower, Target: e.Followed})
+	}
+	return writeXML(w, doc)
+}
+
+// writeXML writes doc as an indented XML document
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeDOT writes the graph as a Graphviz digraph, leaving out empty attributes
+func writeDOT(w io.Writer, info models.RepositoryInfo) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quoteDOT(info.Owner+"/"+info.Repo))
+	for _, n := range nodes(info) {
+		var attrs []string
+		for _, attr := range attributes {
+			if v := n.value(attr); v != "" {
+				attrs = append(attrs, attr+"="+quoteDOT(v))
+			}
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "  %s [%s];\n", quoteDOT(n.ID), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %s;\n", quoteDOT(n.ID))
+		}
+	}
+	for _, e := range info.FollowGraph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", quoteDOT(e.Follower), quoteDOT(e.Followed))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quoteDOT returns s as a double-quoted DOT identifier
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\r", "")
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github-extractor/models"
+)
+
+// fixture has a profile with characters every format must escape, a member without a
+// profile and an edge to a login that is not a member
+func fixture() models.RepositoryInfo {
+	return models.RepositoryInfo{
+		Owner: "acme",
+		Repo:  "widget",
+		Contributors: []models.ContributorDetail{
+			{Login: "alice", Name: `Alice "Al" <Smith> & Co`, Company: `back\slash`, Location: "Line\r\nbreak"},
+			{Login: "Bob", Location: "Paris"},
+		},
+		FollowGraph: models.FollowGraph{
+			Members: []string{"bob", "alice", "carol"},
+			Edges: []models.FollowEdge{
+				{Follower: "alice", Followed: "bob"},
+				{Follower: "carol", Followed: "dave"},
+			},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{"", GraphML, false},
+		{"graphml", GraphML, false},
+		{"GEXF", GEXF, false},
+		{"Dot", DOT, false},
+		{"csv", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, DOT, fixture()); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph "acme/widget" {
+  "alice" [name="Alice \"Al\" <Smith> & Co", company="back\\slash", location="Line\nbreak"];
+  "bob" [location="Paris"];
+  "carol";
+  "dave";
+  "alice" -> "bob";
+  "carol" -> "dave";
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDOTEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, DOT, models.RepositoryInfo{Owner: "acme", Repo: "empty"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "digraph \"acme/empty\" {\n}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// decodedNode is a node read back from a GraphML or GEXF file
+type decodedNode struct {
+	id    string
+	attrs map[string]string
+}
+
+func TestWriteXML(t *testing.T) {
+	want := []decodedNode{
+		{"alice", map[string]string{"name": `Alice "Al" <Smith> & Co`, "company": `back\slash`, "location": "Line\r\nbreak"}},
+		{"bob", map[string]string{"location": "Paris"}},
+		{"carol", map[string]string{}},
+		{"dave", map[string]string{}},
+	}
+	wantEdges := "alice->bob carol->dave"
+
+	tests := []struct {
+		format Format
+		decode func(t *testing.T, data []byte) ([]decodedNode, string)
+	}{
+		{GraphML, decodeGraphML},
+		{GEXF, decodeGEXF},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, tt.format, fixture()); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(b.String(), xml.Header) {
+				t.Error("missing XML declaration")
+			}
+
+			nodes, edges := tt.decode(t, b.Bytes())
+			if len(nodes) != len(want) {
+				t.Fatalf("got %d nodes, want %d", len(nodes), len(want))
+			}
+			for i, n := range nodes {
+				if n.id != want[i].id {
+					t.Errorf("node %d = %s, want %s", i, n.id, want[i].id)
+				}
+				if len(n.attrs) != len(want[i].attrs) {
+					t.Errorf("%s has attributes %v, want %v", n.id, n.attrs, want[i].attrs)
+				}
+				for k, v := range want[i].attrs {
+					if n.attrs[k] != v {
+						t.Errorf("%s %s = %q, want %q", n.id, k, n.attrs[k], v)
+					}
+				}
+			}
+			if edges != wantEdges {
+				t.Errorf("edges = %s, want %s", edges, wantEdges)
+			}
+		})
+	}
+}
+
+func decodeGraphML(t *testing.T, data []byte) ([]decodedNode, string) {
+	var doc graphMLDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v", err)
+	}
+	if len(doc.Keys) != len(attributes) || doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("keys = %+v, edge default %q", doc.Keys, doc.Graph.EdgeDefault)
+	}
+	if strings.Contains(string(data), "<data") && strings.Contains(string(data), "></data>") {
+		t.Error("empty attribute written")
+	}
+
+	var nodes []decodedNode
+	for _, n := range doc.Graph.Nodes {
+		attrs := make(map[string]string)
+		for _, d := range n.Data {
+			attrs[d.Key] = d.Value
+		}
+		nodes = append(nodes, decodedNode{n.ID, attrs})
+	}
+	var edges []string
+	for _, e := range doc.Graph.Edges {
+		edges = append(edges, e.Source+"->"+e.Target)
+	}
+	return nodes, strings.Join(edges, " ")
+}
+
+func decodeGEXF(t *testing.T, data []byte) ([]decodedNode, string) {
+	var doc gexfDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid GEXF: %v", err)
+	}
+	if strings.Contains(string(data), `value=""`) || strings.Contains(string(data), "<attvalues></attvalues>") {
+		t.Error("empty attribute written")
+	}
+
+	var nodes []decodedNode
+	for _, n := range doc.Graph.Nodes {
+		if n.Label != n.ID {
+			t.Errorf("label %q of %s, want the login", n.Label, n.ID)
+		}
+		attrs := make(map[string]string)
+		if n.AttValues != nil {
+			for _, v := range n.AttValues.Values {
+				attrs[v.For] = v.Value
+			}
+		}
+		nodes = append(nodes, decodedNode{n.ID, attrs})
+	}
+	var edges []string
+	for i, e := range doc.Graph.Edges {
+		if e.ID != strconv.Itoa(i) {
+			t.Errorf("edge %d has id %q", i, e.ID)
+		}
+		edges = append(edges, e.Source+"->"+e.Target)
+	}
+	return nodes, strings.Join(edges, " ")
+}
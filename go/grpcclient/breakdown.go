@@ -0,0 +1,199 @@
+package grpcclient
+
+import (
+	pb "github-extractor/proto"
+)
+
+// Breakdown holds the sub-components of each metric with the inputs they were
+// computed from, to explain and debug scores
+type Breakdown struct {
+	Formality     *FormalityBreakdown     `json:"formality,omitempty"`
+	Geodispersion *GeodispersionBreakdown `json:"geodispersion,omitempty"`
+	Longevity     *LongevityBreakdown     `json:"longevity,omitempty"`
+	Cohesion      *CohesionBreakdown      `json:"cohesion,omitempty"`
+	Engagement    *EngagementBreakdown    `json:"engagement,omitempty"`
+	Structure     *StructureBreakdown     `json:"structure,omitempty"`
+}
+
+// FormalityBreakdown lists the community flags behind the formality score
+type FormalityBreakdown struct {
+	Present  []string `json:"present"`
+	Missing  []string `json:"missing"`
+	Score    float64  `json:"score"`     // Sum of the weights of the present flags
+	MaxScore float64  `json:"max_score"` // Sum of all weights
+}
+
+// MatchedLocation is a contributor location matched to the cities dataset
+type MatchedLocation struct {
+	Login     string  `json:"login"`
+	Location  string  `json:"location"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// GeodispersionBreakdown holds the distance statistics behind the geodispersion score
+type GeodispersionBreakdown struct {
+	Contributors       int               `json:"contributors"`
+	MatchedLocations   []MatchedLocation `json:"matched_locations"`
+	Unmatched          int               `json:"unmatched"` // Contributors without a location or with an unmatched one
+	GeographicPairs    int               `json:"geographic_pairs"`
+	GeographicStdDevKm float64           `json:"geographic_std_dev_km"`
+	Geographic         float64           `json:"geographic"`     // Normalized geographic standard deviation
+	CulturalPairs      int               `json:"cultural_pairs"` // Pairs whose countries both have Hofstede scores
+	CulturalStdDev     float64           `json:"cultural_std_dev"`
+	Cultural           float64           `json:"cultural"` // Normalized cultural standard deviation
+}
+
+// LongevityBreakdown holds the four dimensions behind the longevity score
+type LongevityBreakdown struct {
+	PRAcceptanceRate        float64 `json:"pr_acceptance_rate"`
+	MergedPRs               int     `json:"merged_prs"`
+	ClosedPRs               int     `json:"closed_prs"` // Merged PRs included
+	DevelopmentDistribution float64 `json:"development_distribution"`
+	Gini                    float64 `json:"gini"`
+	Committers              int     `json:"committers"` // Contributors with at least one commit
+	Commits                 int     `json:"commits"`
+	ContributorRetention    float64 `json:"contributor_retention"`
+	LongTermContributors    int     `json:"long_term_contributors"` // Active for more than 365 days
+	Contributors            int     `json:"contributors"`
+	TechnicalPulse          float64 `json:"technical_pulse"`
+	ActiveWeeks             int     `json:"active_weeks"` // Weeks with commits in the year before ReferenceDate
+	CommitsLastYear         int     `json:"commits_last_year"`
+	ReferenceDate           string  `json:"reference_date,omitempty"` // Latest commit, ISO 8601
+}
+
+// CohesionBreakdown holds the follow graph counts behind the cohesion score
+type CohesionBreakdown struct {
+	Contributors   int     `json:"contributors"`
+	Following      int     `json:"following"` // Sum of out-degrees, each capped at contributors - 1
+	Followers      int     `json:"followers"` // Sum of in-degrees, each capped at contributors - 1
+	EstimatedEdges float64 `json:"estimated_edges"`
+	PossibleEdges  int     `json:"possible_edges"`
+}
+
+// EngagementBreakdown holds the four parts of the engagement score
+type EngagementBreakdown struct {
+	Threads          int     `json:"threads"`
+	RespondedThreads int     `json:"responded_threads"`
+	ResponseRate     float64 `json:"response_rate"`
+	Participants     int     `json:"participants"` // Contributors who opened or responded to a thread
+	Responders       int     `json:"responders"`   // Participants who responded to another's thread
+	Participation    float64 `json:"participation"`
+	Responses        int     `json:"responses"` // Responses to other participants' threads
+	Intensity        float64 `json:"intensity"`
+	Reactions        int     `json:"reactions"`
+	Posts            int     `json:"posts"` // Threads and comments that can receive reactions
+	Appreciation     float64 `json:"appreciation"`
+}
+
+// StructureBreakdown holds the collaboration graph counts behind the structure score
+type StructureBreakdown struct {
+	Participants     int     `json:"participants"`
+	Edges            int     `json:"edges"` // Pairs of participants that responded to each other
+	LargestComponent int     `json:"largest_component"`
+	Connectedness    float64 `json:"connectedness"`
+	Triangles        int     `json:"triangles"`
+	ConnectedTriples int     `json:"connected_triples"`
+	Clustering       float64 `json:"clustering"`
+}
+
+// breakdownFromProto converts the breakdown of a ProcessResponse; it is nil when the
+// processor service does not send one. Lists are never nil so that they encode as
+// empty JSON arrays.
+func breakdownFromProto(b *pb.MetricBreakdown) *Breakdown {
+	if b == nil {
+		return nil
+	}
+	out := &Breakdown{}
+
+	if f := b.Formality; f != nil {
+		out.Formality = &FormalityBreakdown{
+			Present:  f.Present,
+			Missing:  f.Missing,
+			Score:    f.Score,
+			MaxScore: f.MaxScore,
+		}
+	}
+
+	if g := b.Geodispersion; g != nil {
+		geo := &GeodispersionBreakdown{
+			Contributors:       int(g.Contributors),
+			Unmatched:          int(g.Unmatched),
+			GeographicPairs:    int(g.GeographicPairs),
+			GeographicStdDevKm: g.GeographicStdDevKm,
+			Geographic:         g.Geographic,
+			CulturalPairs:      int(g.CulturalPairs),
+			CulturalStdDev:     g.CulturalStdDev,
+			Cultural:           g.Cultural,
+		}
+		for _, loc := range g.MatchedLocations {
+			geo.MatchedLocations = append(geo.MatchedLocations, MatchedLocation{
+				Login:     loc.Login,
+				Location:  loc.Location,
+				Country:   loc.Country,
+				Latitude:  loc.Latitude,
+				Longitude: loc.Longitude,
+			})
+		}
+		out.Geodispersion = geo
+	}
+
+	if l := b.Longevity; l != nil {
+		out.Longevity = &LongevityBreakdown{
+			PRAcceptanceRate:        l.PrAcceptanceRate,
+			MergedPRs:               int(l.MergedPrs),
+			ClosedPRs:               int(l.ClosedPrs),
+			DevelopmentDistribution: l.DevelopmentDistribution,
+			Gini:                    l.Gini,
+			Committers:              int(l.Committers),
+			Commits:                 int(l.Commits),
+			ContributorRetention:    l.ContributorRetention,
+			LongTermContributors:    int(l.LongTermContributors),
+			Contributors:            int(l.Contributors),
+			TechnicalPulse:          l.TechnicalPulse,
+			ActiveWeeks:             int(l.ActiveWeeks),
+			CommitsLastYear:         int(l.CommitsLastYear),
+			ReferenceDate:           l.ReferenceDate,
+		}
+	}
+
+	if c := b.Cohesion; c != nil {
+		out.Cohesion = &CohesionBreakdown{
+			Contributors:   int(c.Contributors),
+			Following:      int(c.Following),
+			Followers:      int(c.Followers),
+			EstimatedEdges: c.EstimatedEdges,
+			PossibleEdges:  int(c.PossibleEdges),
+		}
+	}
+
+	if e := b.Engagement; e != nil {
+		out.Engagement = &EngagementBreakdown{
+			Threads:          int(e.Threads),
+			RespondedThreads: int(e.RespondedThreads),
+			ResponseRate:     e.ResponseRate,
+			Participants:     int(e.Participants),
+			Responders:       int(e.Responders),
+			Participation:    e.Participation,
+			Responses:        int(e.Responses),
+			Intensity:        e.Intensity,
+			Reactions:        int(e.Reactions),
+			Posts:            int(e.Posts),
+			Appreciation:     e.Appreciation,
+		}
+	}
+
+	if st := b.Structure; st != nil {
+		out.Structure = &StructureBreakdown{
+			Participants:     int(st.Participants),
+			Edges:            int(st.Edges),
+			LargestComponent: int(st.LargestComponent),
+			Connectedness:    st.Connectedness,
+			Triangles:        int(st.Triangles),
+			ConnectedTriples: int(st.ConnectedTriples),
+			Clustering:       st.Clustering,
+		}
+	}
+	return out
+}
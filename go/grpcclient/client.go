@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github-extractor/models"
 	pb "github-extractor/proto"
 
 	"github.com/sirupsen/logrus"
@@ -43,6 +44,10 @@ type ProcessResult struct {
 	Geodispersion float64 `json:"geodispersion"`
 	Longevity     float64 `json:"longevity"`
 	Cohesion      float64 `json:"cohesion"`
+	Engagement    float64 `json:"engagement"`
+	Structure     float64 `json:"structure"`
+	// Breakdown explains the metrics; nil when the processor did not provide it
+	Breakdown *Breakdown `json:"breakdown,omitempty"`
 }
 
 // Process sends repository data to the ProcessorService and returns computed metrics.
@@ -64,9 +69,17 @@ func (pc *ProcessorClient) Process(ctx context.Context, repoProto *pb.Repository
 		Geodispersion: resp.Geodispersion,
 		Longevity:     resp.Longevity,
 		Cohesion:      resp.Cohesion,
+		Engagement:    resp.Engagement,
+		Structure:     resp.Structure,
+		Breakdown:     breakdownFromProto(resp.Breakdown),
 	}, nil
 }
 
+// ProcessRepository converts info to its proto message and processes it
+func (pc *ProcessorClient) ProcessRepository(ctx context.Context, info models.RepositoryInfo) (*ProcessResult, error) {
+	return pc.Process(ctx, pb.RepositoryInfoToProto(info))
+}
+
 // Close gracefully shuts down the gRPC connection.
 func (pc *ProcessorClient) Close() error {
 	if pc.conn != nil {
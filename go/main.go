@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,9 +11,8 @@ import (
 	"time"
 
 	"github-extractor/config"
-	"github-extractor/github"
-	"github-extractor/grpcclient"
 	"github-extractor/server"
+	"github-extractor/store"
 
 	_ "github-extractor/docs"
 	"github-extractor/logger"
@@ -58,22 +58,44 @@ func main() {
 
 	appLogger.Info("GitHub Repository Extractor Server")
 	appLogger.Infof("Using %d CPU cores for parallel processing", numCPU)
+	appLogger.Infof("Using a pool of %d GitHub tokens", len(cfg.GitHubTokens))
+	if cfg.GitHubApp != nil {
+		appLogger.Infof("Authenticating as GitHub App %d", cfg.GitHubApp.ID)
+	}
+	appLogger.Infof("Using the GitHub %s API for pull requests, profiles and follow edges", cfg.GitHubAPI)
 
-	// Create GitHub client
-	ghClient := github.NewClient(cfg.GitHubToken, appLogger)
+	// Create the clients of every configured host
+	sources, err := server.NewSources(cfg, appLogger)
+	if err != nil {
+		appLogger.WithField("error", err).Fatal("Configuration error")
+	}
+	if cfg.CloneDir != "" {
+		appLogger.Infof("Clone mode: reading commits, contributor stats and community files from clones in %s", cfg.CloneDir)
+	}
 
 	// Initialize service with worker pool
-	service := server.NewService(ghClient, appLogger)
+	service, err := server.NewService(sources, cfg.Workers, appLogger)
+	if err != nil {
+		appLogger.WithField("error", err).Fatal("Failed to initialize service")
+	}
 
-	// Initialize gRPC processor client
-	processorClient, err := grpcclient.NewProcessorClient(cfg.GRPCAddress, appLogger)
+	// Compute metrics in process, or through the gRPC processor service
+	processor, closeProcessor, err := server.NewProcessor(cfg, appLogger)
 	if err != nil {
 		appLogger.WithField("error", err).Fatal("Failed to connect to processor gRPC service")
 	}
-	defer processorClient.Close()
+	defer closeProcessor()
+
+	// Open the result store keeping past analyses
+	resultStore, err := store.Open(cfg.StorePath)
+	if err != nil {
+		appLogger.WithField("error", err).Fatal("Failed to open result store")
+	}
+	defer resultStore.Close()
+	appLogger.WithField("path", cfg.StorePath).Info("Result store opened")
 
 	// Initialize handler
-	ghHandler := server.NewHandler(service, appLogger, processorClient)
+	ghHandler := server.NewHandler(service, appLogger, processor, cfg.Thresholds, resultStore)
 
 	// Setup routes
 	router := server.SetupRoutes(ghHandler)
@@ -81,13 +103,21 @@ func main() {
 	// Wrap router with CORS and logging middleware
 	handler := middleware.CORSMiddleware(middleware.LoggingMiddleware(appLogger)(router))
 
+	// Every request context derives from baseCtx, which is cancelled on shutdown
+	// so in-flight extractions stop their outstanding GitHub calls.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
 		Handler:      handler,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 15 * time.Minute, // Increased to handle large repositories
 		IdleTimeout:  60 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
 	}
+	srv.RegisterOnShutdown(cancelBase)
+	srv.RegisterOnShutdown(ghHandler.Shutdown)
 
 	// Start server in a goroutine
 	go func() {
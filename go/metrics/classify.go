@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github-extractor/grpcclient"
+)
+
+// Community categories of the YOSHI decision tree
+const (
+	SimpleProject       = "Simple Project (SP)"
+	CommunityOfPractice = "Community of Practice (CoP)"
+	NetworkOfPractice   = "Network of Practice (NoP)"
+	InformalCommunity   = "Informal Community (IC)"
+	FormalCommunity     = "Formal Community (FC)"
+	ProjectTeam         = "Project Team (PT)"
+	StrategicCommunity  = "Strategic Community (SC)"
+	Workgroup           = "Workgroup (WG)"
+	InformalNetwork     = "Informal Network (IN)"
+	FormalNetwork       = "Formal Network (FN)"
+)
+
+// Thresholds separate low from high values at each node of the decision tree; a
+// metric is low when it is below its threshold
+type Thresholds struct {
+	Geodispersion float64 `json:"geodispersion"`
+	Formality     float64 `json:"formality"`
+	Longevity     float64 `json:"longevity"`
+	Cohesion      float64 `json:"cohesion"`
+}
+
+// Validate checks that every threshold is within the range of its metric: [0, 2] for
+// geodispersion and [0, 1] for the others
+func (t Thresholds) Validate() error {
+	for _, th := range []struct {
+		name  string
+		value float64
+		max   float64
+	}{
+		{"geodispersion", t.Geodispersion, 2},
+		{"formality", t.Formality, 1},
+		{"longevity", t.Longevity, 1},
+		{"cohesion", t.Cohesion, 1},
+	} {
+		if !(th.value >= 0 && th.value <= th.max) {
+			return fmt.Errorf("%s threshold must be between 0 and %g", th.name, th.max)
+		}
+	}
+	return nil
+}
+
+// DecisionStep is a node of the decision tree the classification went through
+type DecisionStep struct {
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Level     string  `json:"level"`  // "low" or "high"
+	Branch    string  `json:"branch"` // Where the level leads
+}
+
+// Classification is the community category of a repository and how it was reached
+type Classification struct {
+	Category string         `json:"category"`
+	Path     []DecisionStep `json:"decision_path"`
+}
+
+// Classify walks the YOSHI decision tree. Geodispersion separates communities of
+// practice from networks of practice; formality then separates informal from formal
+// ones, and formal communities of practice are further split by longevity and cohesion.
+func Classify(result *grpcclient.ProcessResult, t Thresholds) Classification {
+	var c Classification
+	low := func(metric string, value, threshold float64, lowBranch, highBranch string) bool {
+		step := DecisionStep{Metric: metric, Value: value, Threshold: threshold, Level: "high", Branch: highBranch}
+		if value < threshold {
+			step.Level, step.Branch = "low", lowBranch
+		}
+		c.Path = append(c.Path, step)
+		return value < threshold
+	}
+
+	if !low("Geodispersion", result.Geodispersion, t.Geodispersion, CommunityOfPractice, NetworkOfPractice) {
+		if low("Formality", result.Formality, t.Formality, InformalNetwork, FormalNetwork) {
+			c.Category = InformalNetwork
+		} else {
+			c.Category = FormalNetwork
+		}
+		return c
+	}
+
+	switch {
+	case low("Formality", result.Formality, t.Formality, InformalCommunity, FormalCommunity):
+		c.Category = InformalCommunity
+	case low("Longevity", result.Longevity, t.Longevity, ProjectTeam, "Go to Cohesion node"):
+		c.Category = ProjectTeam
+	case low("Cohesion", result.Cohesion, t.Cohesion, StrategicCommunity, Workgroup):
+		c.Category = StrategicCommunity
+	default:
+		c.Category = Workgroup
+	}
+	return c
+}
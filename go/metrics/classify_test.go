@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github-extractor/grpcclient"
+)
+
+// defaultThresholds are the GUI defaults of gui/javascript/app.js
+var defaultThresholds = Thresholds{Geodispersion: 0.25, Formality: 0.50, Longevity: 0.40, Cohesion: 0.40}
+
+// The expected categories and paths follow classifyCommunity in gui/javascript/app.js,
+// where a metric is low when strictly below its threshold
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		result grpcclient.ProcessResult
+		want   string
+		path   string // Metric and level of every step
+	}{
+		{
+			name:   "informal network",
+			result: grpcclient.ProcessResult{Geodispersion: 0.9, Formality: 0.1},
+			want:   InformalNetwork,
+			path:   "Geodispersion:high Formality:low",
+		},
+		{
+			name:   "formal network",
+			result: grpcclient.ProcessResult{Geodispersion: 1.6, Formality: 0.8, Longevity: 0, Cohesion: 0},
+			want:   FormalNetwork,
+			path:   "Geodispersion:high Formality:high",
+		},
+		{
+			name:   "informal community",
+			result: grpcclient.ProcessResult{Geodispersion: 0.1, Formality: 0.2, Longevity: 0.9, Cohesion: 0.9},
+			want:   InformalCommunity,
+			path:   "Geodispersion:low Formality:low",
+		},
+		{
+			name:   "project team",
+			result: grpcclient.ProcessResult{Geodispersion: 0.1, Formality: 0.7, Longevity: 0.2, Cohesion: 0.9},
+			want:   ProjectTeam,
+			path:   "Geodispersion:low Formality:high Longevity:low",
+		},
+		{
+			name:   "strategic community",
+			result: grpcclient.ProcessResult{Geodispersion: 0.1, Formality: 0.7, Longevity: 0.6, Cohesion: 0.1},
+			want:   StrategicCommunity,
+			path:   "Geodispersion:low Formality:high Longevity:high Cohesion:low",
+		},
+		{
+			name:   "workgroup",
+			result: grpcclient.ProcessResult{Geodispersion: 0.1, Formality: 0.7, Longevity: 0.6, Cohesion: 0.6},
+			want:   Workgroup,
+			path:   "Geodispersion:low Formality:high Longevity:high Cohesion:high",
+		},
+		{
+			name:   "values equal to the thresholds are high",
+			result: grpcclient.ProcessResult{Geodispersion: 0.25, Formality: 0.50},
+			want:   FormalNetwork,
+			path:   "Geodispersion:high Formality:high",
+		},
+		{
+			name:   "zero metrics",
+			result: grpcclient.ProcessResult{},
+			want:   InformalCommunity,
+			path:   "Geodispersion:low Formality:low",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Classify(&tt.result, defaultThresholds)
+			if c.Category != tt.want {
+				t.Errorf("category = %s, want %s", c.Category, tt.want)
+			}
+
+			var path []string
+			for _, step := range c.Path {
+				path = append(path, step.Metric+":"+step.Level)
+			}
+			if got := strings.Join(path, " "); got != tt.path {
+				t.Errorf("path = %s, want %s", got, tt.path)
+			}
+			if last := c.Path[len(c.Path)-1]; last.Branch != c.Category && last.Branch != "Go to Cohesion node" {
+				t.Errorf("last branch = %s, want the category", last.Branch)
+			}
+		})
+	}
+}
+
+func TestClassifyThresholds(t *testing.T) {
+	result := &grpcclient.ProcessResult{Geodispersion: 0.3, Formality: 0.7, Longevity: 0.6, Cohesion: 0.6}
+	if got := Classify(result, defaultThresholds).Category; got != FormalNetwork {
+		t.Errorf("default thresholds: %s, want %s", got, FormalNetwork)
+	}
+	raised := defaultThresholds
+	raised.Geodispersion = 1.5
+	c := Classify(result, raised)
+	if c.Category != Workgroup {
+		t.Errorf("raised geodispersion threshold: %s, want %s", c.Category, Workgroup)
+	}
+	if step := c.Path[0]; step.Value != 0.3 || step.Threshold != 1.5 {
+		t.Errorf("first step = %+v, want the value and threshold used", step)
+	}
+}
+
+func TestThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       Thresholds
+		wantErr string
+	}{
+		{"defaults", defaultThresholds, ""},
+		{"bounds", Thresholds{Geodispersion: 2, Formality: 1, Longevity: 0, Cohesion: 1}, ""},
+		{"geodispersion above 1", Thresholds{Geodispersion: 1.5}, ""},
+		{"geodispersion above 2", Thresholds{Geodispersion: 2.1}, "geodispersion threshold must be between 0 and 2"},
+		{"formality above 1", Thresholds{Formality: 1.1}, "formality threshold must be between 0 and 1"},
+		{"negative longevity", Thresholds{Longevity: -0.1}, "longevity threshold must be between 0 and 1"},
+		{"cohesion above 1", Thresholds{Cohesion: 2}, "cohesion threshold must be between 0 and 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.t.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+// Cohesion is the completeness of the directed follow graph among the contributors,
+// in [0, 1]. Each contributor's in-community followers and following counts are its
+// in- and out-degree; the edge count is estimated as the mean of their sums, which
+// tolerates partially retrieved follow lists.
+func Cohesion(info models.RepositoryInfo) (float64, *grpcclient.CohesionBreakdown) {
+	n := len(info.Contributors)
+	b := &grpcclient.CohesionBreakdown{Contributors: n}
+	if n < 2 {
+		return 0, b
+	}
+
+	maxDegree := n - 1
+	for _, c := range info.Contributors {
+		b.Following += clamp(c.Following, 0, maxDegree)
+		b.Followers += clamp(c.Followers, 0, maxDegree)
+	}
+
+	b.EstimatedEdges = float64(b.Following+b.Followers) / 2
+	b.PossibleEdges = n * (n - 1)
+	return clampFloat(b.EstimatedEdges/float64(b.PossibleEdges), 0, 1), b
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+func clampFloat(v, lo, hi float64) float64 {
+	return min(max(v, lo), hi)
+}
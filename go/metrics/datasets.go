@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dataset files, shared with the Python processor
+const (
+	citiesFile   = "cities.csv"
+	hofstedeFile = "cultural_distance_hosftede.csv"
+)
+
+// hofstedeDimensions are the cultural dimensions compared between countries
+var hofstedeDimensions = []string{"pdi", "idv", "mas", "uai", "lto", "ivr"}
+
+// city is a row of the cities dataset
+type city struct {
+	name        string
+	stateName   string
+	stateCode   string
+	countryName string
+	latitude    float64
+	longitude   float64
+	population  int64
+	capital     bool
+}
+
+// cityIndex looks cities up by lowercased name, state (name or code), country name and
+// country code. Every list keeps the order of the dataset.
+type cityIndex struct {
+	byCity        map[string][]*city
+	byState       map[string][]*city
+	byCountry     map[string][]*city
+	byCountryCode map[string][]*city
+}
+
+// culture holds the Hofstede scores of a country, nil where the dataset has none
+type culture map[string]*float64
+
+// datasets loads the datasets on first use and keeps them
+type datasets struct {
+	dir string
+
+	mu       sync.Mutex
+	cities   *cityIndex
+	hofstede map[string]culture
+}
+
+func (d *datasets) cityIndex() (*cityIndex, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cities == nil {
+		index, err := loadCities(filepath.Join(d.dir, citiesFile))
+		if err != nil {
+			return nil, err
+		}
+		d.cities = index
+	}
+	return d.cities, nil
+}
+
+func (d *datasets) cultures() (map[string]culture, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.hofstede == nil {
+		hofstede, err := loadHofstede(filepath.Join(d.dir, hofstedeFile))
+		if err != nil {
+			return nil, err
+		}
+		d.hofstede = hofstede
+	}
+	return d.hofstede, nil
+}
+
+func loadCities(path string) (*cityIndex, error) {
+	index := &cityIndex{
+		byCity:        make(map[string][]*city),
+		byState:       make(map[string][]*city),
+		byCountry:     make(map[string][]*city),
+		byCountryCode: make(map[string][]*city),
+	}
+	err := readCSV(path, func(row map[string]string) error {
+		lat, err := strconv.ParseFloat(row["latitude"], 64)
+		if err != nil {
+			return fmt.Errorf("invalid latitude %q", row["latitude"])
+		}
+		lon, err := strconv.ParseFloat(row["longitude"], 64)
+		if err != nil {
+			return fmt.Errorf("invalid longitude %q", row["longitude"])
+		}
+		c := &city{
+			name:        row["name"],
+			stateName:   row["state_name"],
+			stateCode:   row["state_code"],
+			countryName: row["country_name"],
+			latitude:    lat,
+			longitude:   lon,
+			capital:     row["type"] == "capital",
+		}
+		if p := row["population"]; p != "" {
+			if c.population, err = strconv.ParseInt(p, 10, 64); err != nil {
+				return fmt.Errorf("invalid population %q", p)
+			}
+		}
+
+		add := func(m map[string][]*city, key string) {
+			if key != "" {
+				m[key] = append(m[key], c)
+			}
+		}
+		add(index.byCity, strings.ToLower(c.name))
+		add(index.byCountryCode, strings.ToLower(row["country_code"]))
+		add(index.byState, strings.ToLower(c.stateName))
+		add(index.byState, strings.ToLower(c.stateCode))
+		add(index.byCountry, strings.ToLower(c.countryName))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load cities dataset: %w", err)
+	}
+	return index, nil
+}
+
+func loadHofstede(path string) (map[string]culture, error) {
+	cultures := make(map[string]culture)
+	err := readCSV(path, func(row map[string]string) error {
+		scores := make(culture, len(hofstedeDimensions))
+		for _, dim := range hofstedeDimensions {
+			if row[dim] == "" {
+				scores[dim] = nil
+				continue
+			}
+			v, err := strconv.ParseFloat(row[dim], 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s score %q", dim, row[dim])
+			}
+			scores[dim] = &v
+		}
+		cultures[strings.ToLower(row["country"])] = scores
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load Hofstede dataset: %w", err)
+	}
+	return cultures, nil
+}
+
+// readCSV calls fn with every row of the CSV file at path, keyed by the header
+func readCSV(path string, fn func(row map[string]string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read header of %s: %w", path, err)
+	}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("%s line %d: %w", path, lineOf(r), err)
+		}
+	}
+}
+
+func lineOf(r *csv.Reader) int {
+	line, _ := r.FieldPos(0)
+	return line
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+// Engagement is the mean of four shares describing how the community takes part in
+// its recent issues, pull requests and discussions, in [0, 1]:
+//   - response rate: threads someone other than the author responded to
+//   - participation: participants who responded to someone else's thread
+//   - intensity: responses / (responses + threads), 0.5 at one response per thread
+//   - appreciation: reactions / (reactions + posts), 0.5 at one reaction per post
+func Engagement(info models.RepositoryInfo) (float64, *grpcclient.EngagementBreakdown) {
+	e := info.Engagement
+	b := &grpcclient.EngagementBreakdown{
+		Threads:          e.Threads,
+		RespondedThreads: e.RespondedThreads,
+		Participants:     len(e.Contributors),
+	}
+
+	responders := make(map[string]struct{})
+	for _, edge := range e.Collaboration {
+		responders[strings.ToLower(edge.From)] = struct{}{}
+		b.Responses += edge.Weight
+	}
+	b.Responders = len(responders)
+
+	for _, c := range e.Contributors {
+		b.Reactions += c.ReactionsReceived
+		b.Posts += c.Threads + c.Comments + c.ReviewComments + c.DiscussionComments
+	}
+
+	if b.Threads > 0 {
+		b.ResponseRate = float64(b.RespondedThreads) / float64(b.Threads)
+		b.Intensity = float64(b.Responses) / float64(b.Responses+b.Threads)
+	}
+	if b.Participants > 0 {
+		b.Participation = float64(b.Responders) / float64(b.Participants)
+	}
+	if b.Reactions+b.Posts > 0 {
+		b.Appreciation = float64(b.Reactions) / float64(b.Reactions+b.Posts)
+	}
+	return (b.ResponseRate + b.Participation + b.Intensity + b.Appreciation) / 4, b
+}
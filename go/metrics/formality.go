@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+// formalityWeights weighs the community flags, named after their RepositoryInfo JSON
+// fields; they add up to 10
+var formalityWeights = []struct {
+	flag   string
+	weight float64
+	has    func(models.RepositoryInfo) bool
+}{
+	{"has_code_of_conduct", 1.8, func(r models.RepositoryInfo) bool { return r.HasCodeOfConduct }},
+	{"has_readme", 0.2, func(r models.RepositoryInfo) bool { return r.HasReadme }},
+	{"has_description", 0.2, func(r models.RepositoryInfo) bool { return r.HasDescription }},
+	{"has_contributing_guidelines", 1.8, func(r models.RepositoryInfo) bool { return r.HasContributingGuidelines }},
+	{"has_license", 0.5, func(r models.RepositoryInfo) bool { return r.HasLicense }},
+	{"has_security_policy", 0.7, func(r models.RepositoryInfo) bool { return r.HasSecurityPolicy }},
+	{"has_issues_template", 1.8, func(r models.RepositoryInfo) bool { return r.HasIssuesTemplate }},
+	{"has_pull_request_template", 1.5, func(r models.RepositoryInfo) bool { return r.HasPullRequestTemplate }},
+	{"has_wiki_page", 0.5, func(r models.RepositoryInfo) bool { return r.HasWikiPage }},
+	{"has_milestones", 1.0, func(r models.RepositoryInfo) bool { return r.HasMilestones }},
+}
+
+// Formality is the weighted share of community flags the repository has, in [0, 1]
+func Formality(info models.RepositoryInfo) (float64, *grpcclient.FormalityBreakdown) {
+	// Sum in the order of the Python weights so the floating point results match
+	b := &grpcclient.FormalityBreakdown{Present: []string{}, Missing: []string{}}
+	for _, w := range formalityWeights {
+		if w.has(info) {
+			b.Score += w.weight
+			b.Present = append(b.Present, w.flag)
+		} else {
+			b.Missing = append(b.Missing, w.flag)
+		}
+		b.MaxScore += w.weight
+	}
+	return b.Score / b.MaxScore, b
+}
@@ -0,0 +1,382 @@
+package metrics
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+const (
+	// maxGeoDistanceKm is half the circumference of the Earth
+	maxGeoDistanceKm = 20015.0
+	// maxCulturalDistance is the empirical maximum over the six Hofstede dimensions
+	maxCulturalDistance = 200.0
+	earthRadiusKm       = 6371.0
+	degToRad            = math.Pi / 180.0
+)
+
+var (
+	parentheses = regexp.MustCompile(`[()]`)
+
+	// locationNoise is removed from locations, in this order, before parsing
+	locationNoise = []string{"home", "remote", "online", "near", "light years away", "$rax"}
+
+	usStateCodes = set(
+		"al", "ak", "az", "ar", "ca", "co", "ct", "de", "fl", "ga",
+		"hi", "id", "il", "in", "ia", "ks", "ky", "la", "me", "md",
+		"ma", "mi", "mn", "ms", "mo", "mt", "ne", "nv", "nh", "nj",
+		"nm", "ny", "nc", "nd", "oh", "ok", "or", "pa", "ri", "sc",
+		"sd", "tn", "tx", "ut", "vt", "va", "wa", "wv", "wi", "wy",
+	)
+
+	usStateNames = set(
+		"alabama", "alaska", "arizona", "arkansas", "california", "colorado",
+		"connecticut", "delaware", "florida", "georgia", "hawaii", "idaho",
+		"illinois", "indiana", "iowa", "kansas", "kentucky", "louisiana",
+		"maine", "maryland", "massachusetts", "michigan", "minnesota",
+		"mississippi", "missouri", "montana", "nebraska", "nevada",
+		"new hampshire", "new jersey", "new mexico", "new york",
+		"north carolina", "north dakota", "ohio", "oklahoma", "oregon",
+		"pennsylvania", "rhode island", "south carolina", "south dakota",
+		"tennessee", "texas", "utah", "vermont", "virginia", "washington",
+		"west virginia", "wisconsin", "wyoming",
+	)
+
+	// countryCodes are ISO 3166-1 alpha-2 codes and common variations
+	countryCodes = set(
+		"us", "usa", "uk", "gb", "fr", "de", "it", "es", "pt", "nl", "be", "ch",
+		"at", "dk", "se", "no", "fi", "pl", "cz", "hu", "ro", "bg", "gr", "tr",
+		"ru", "ua", "cn", "jp", "kr", "in", "au", "nz", "br", "ar", "mx", "ca",
+	)
+
+	countryVariations = map[string]string{
+		"italia":      "italy",
+		"deutschland": "germany",
+		"españa":      "spain",
+		"brasil":      "brazil",
+	}
+
+	countryCodeNames = map[string]string{
+		"us": "united states", "usa": "united states",
+		"uk": "united kingdom", "gb": "united kingdom",
+		"fr": "france", "de": "germany", "it": "italy", "es": "spain",
+		"nl": "netherlands", "be": "belgium", "ch": "switzerland",
+		"at": "austria", "pt": "portugal",
+	}
+)
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// location is a contributor location matched to the cities dataset
+type location struct {
+	country   string
+	latitude  float64
+	longitude float64
+}
+
+// Geodispersion is the sum of the standard deviations of the pairwise geographical and
+// cultural distances between the contributors whose location could be matched, each
+// normalized by its maximum, in [0, 2]. It is 0 with fewer than two matched locations.
+func (c *Calculator) Geodispersion(info models.RepositoryInfo) (float64, *grpcclient.GeodispersionBreakdown, error) {
+	b := &grpcclient.GeodispersionBreakdown{
+		Contributors:     len(info.Contributors),
+		MatchedLocations: []grpcclient.MatchedLocation{},
+	}
+	var index *cityIndex
+	var locations []location
+	for _, contributor := range info.Contributors {
+		if contributor.Location == "" {
+			b.Unmatched++
+			continue
+		}
+		if index == nil {
+			var err error
+			if index, err = c.datasets.cityIndex(); err != nil {
+				return 0, nil, err
+			}
+		}
+		loc, ok := index.match(contributor.Location)
+		if !ok {
+			b.Unmatched++
+			continue
+		}
+		locations = append(locations, loc)
+		b.MatchedLocations = append(b.MatchedLocations, grpcclient.MatchedLocation{
+			Login:     contributor.Login,
+			Location:  contributor.Location,
+			Country:   loc.country,
+			Latitude:  loc.latitude,
+			Longitude: loc.longitude,
+		})
+	}
+	c.logger.Debugf("Geodispersion of %s/%s: %d of %d contributor locations matched",
+		info.Owner, info.Repo, len(locations), len(info.Contributors))
+	if len(locations) < 2 {
+		return 0, b, nil
+	}
+
+	cultures, err := c.datasets.cultures()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	geographic := geographicDistances(locations)
+	b.GeographicPairs = len(geographic)
+	b.GeographicStdDevKm = stdDev(geographic)
+	b.Geographic = b.GeographicStdDevKm / maxGeoDistanceKm
+
+	cultural := culturalDistances(locations, cultures)
+	b.CulturalPairs = len(cultural)
+	b.CulturalStdDev = stdDev(cultural)
+	b.Cultural = b.CulturalStdDev / maxCulturalDistance
+
+	return b.Geographic + b.Cultural, b, nil
+}
+
+// normalizeLocation lowercases a location, collapses its whitespace and drops
+// parentheses and noise words
+func normalizeLocation(loc string) string {
+	loc = parentheses.ReplaceAllString(loc, " ")
+	normalized := strings.Join(strings.Fields(strings.ToLower(loc)), " ")
+	for _, noise := range locationNoise {
+		normalized = strings.ReplaceAll(normalized, noise, "")
+	}
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// locationParts is a location split into city, state and country, any of them empty
+type locationParts struct {
+	city, state, country string
+}
+
+// parseLocation splits a free-form location into its parts, recognising US states,
+// country codes and "Country, City" orders
+func (idx *cityIndex) parseLocation(loc string) locationParts {
+	loc = normalizeLocation(loc)
+	if loc == "" {
+		return locationParts{}
+	}
+
+	var parts []string
+	for _, p := range strings.Split(strings.ReplaceAll(loc, "/", ","), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	// Without delimiters, a trailing country code or name still separates the country
+	if len(parts) == 1 && strings.Contains(parts[0], " ") {
+		words := strings.Fields(parts[0])
+		last := words[len(words)-1]
+		if len(words) > 1 && (countryCodes[last] || idx.isCountry(last)) {
+			parts = []string{strings.Join(words[:len(words)-1], " "), last}
+		}
+	}
+
+	variation := func(s string) string {
+		if v, ok := countryVariations[s]; ok {
+			return v
+		}
+		return s
+	}
+	countryName := func(s string) string {
+		if name, ok := countryCodeNames[s]; ok {
+			return name
+		}
+		return s
+	}
+
+	var result locationParts
+	switch {
+	case len(parts) == 1:
+		// A single part may be a city, state or country; matching tries them all
+		single := variation(parts[0])
+		if countryCodes[single] {
+			single = countryName(single)
+		}
+		result.city = single
+	case len(parts) == 2:
+		first, second := variation(parts[0]), variation(parts[1])
+		switch {
+		case usStateCodes[second], usStateNames[second]:
+			result = locationParts{city: first, state: second, country: "united states"}
+		case countryCodes[second]:
+			result = locationParts{city: first, country: countryName(second)}
+		case idx.isCountry(first):
+			result = locationParts{city: second, country: first}
+		default:
+			result = locationParts{city: first, country: second}
+		}
+	case len(parts) >= 3:
+		for i := range parts {
+			parts[i] = variation(parts[i])
+		}
+		if idx.isCountry(parts[0]) {
+			result = locationParts{country: parts[0], state: parts[1], city: parts[2]}
+		} else {
+			result.city = parts[0]
+			if !countryCodes[parts[1]] {
+				result.state = parts[1]
+			}
+			result.country = countryName(parts[2])
+		}
+	}
+	return result
+}
+
+func (idx *cityIndex) isCountry(s string) bool {
+	return len(idx.byCountry[s]) > 0 || len(idx.byCountryCode[s]) > 0
+}
+
+// match resolves a location to the coordinates of a city: the city within the given
+// state or country, else the largest city of a state or the capital of a country
+func (idx *cityIndex) match(loc string) (location, bool) {
+	parts := idx.parseLocation(loc)
+
+	if parts.city != "" && parts.state != "" {
+		best := largest(idx.byCity[parts.city], func(c *city) bool {
+			return strings.ToLower(c.stateCode) == parts.state || strings.ToLower(c.stateName) == parts.state
+		})
+		if best != nil {
+			return best.location(), true
+		}
+	}
+
+	if parts.city != "" && parts.country != "" && parts.state == "" {
+		inCountry := func(c *city) bool { return strings.ToLower(c.countryName) == parts.country }
+		if best := largest(idx.byCity[parts.city], inCountry); best != nil {
+			return best.location(), true
+		}
+		// The "city" may be a state or province of the country
+		if best := largest(idx.byState[parts.city], inCountry); best != nil {
+			return best.location(), true
+		}
+		if capital := capitalOf(idx.byCountry[parts.country]); capital != nil {
+			return capital.location(), true
+		}
+	}
+
+	if parts.city != "" && parts.state == "" && parts.country == "" {
+		if capital := capitalOf(idx.byCountry[parts.city]); capital != nil {
+			return capital.location(), true
+		}
+		if capital := capitalOf(idx.byState[parts.city]); capital != nil {
+			return capital.location(), true
+		}
+		if best := largest(idx.byCity[parts.city], nil); best != nil {
+			return best.location(), true
+		}
+	}
+
+	return location{}, false
+}
+
+// largest returns the most populated city accepted by keep, the first one on ties
+func largest(cities []*city, keep func(*city) bool) *city {
+	var best *city
+	for _, c := range cities {
+		if keep != nil && !keep(c) {
+			continue
+		}
+		if best == nil || c.population > best.population {
+			best = c
+		}
+	}
+	return best
+}
+
+// capitalOf returns the first capital among cities, else the most populated one
+func capitalOf(cities []*city) *city {
+	for _, c := range cities {
+		if c.capital {
+			return c
+		}
+	}
+	return largest(cities, nil)
+}
+
+func (c *city) location() location {
+	return location{
+		country:   strings.ToLower(c.countryName),
+		latitude:  c.latitude,
+		longitude: c.longitude,
+	}
+}
+
+// haversine returns the great-circle distance between two points in kilometres
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1, lon1, lat2, lon2 = lat1*degToRad, lon1*degToRad, lat2*degToRad, lon2*degToRad
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((lon2 - lon1) / 2)
+	// The conversions keep the compiler from fusing multiply-adds, as in Longevity
+	a := float64(sinLat*sinLat) + float64(math.Cos(lat1)*math.Cos(lat2)*float64(sinLon*sinLon))
+	return 2 * math.Asin(math.Sqrt(a)) * earthRadiusKm
+}
+
+func geographicDistances(locations []location) []float64 {
+	var distances []float64
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			distances = append(distances, haversine(
+				locations[i].latitude, locations[i].longitude,
+				locations[j].latitude, locations[j].longitude,
+			))
+		}
+	}
+	return distances
+}
+
+// culturalDistances returns the Euclidean distances between the Hofstede scores of
+// every pair of locations, over the dimensions both countries have. Pairs with a
+// country missing from the dataset are skipped.
+func culturalDistances(locations []location, cultures map[string]culture) []float64 {
+	var distances []float64
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			c1, c2 := cultures[locations[i].country], cultures[locations[j].country]
+			if len(c1) == 0 || len(c2) == 0 {
+				continue
+			}
+			var sum float64
+			var dims int
+			for _, dim := range hofstedeDimensions {
+				if v1, v2 := c1[dim], c2[dim]; v1 != nil && v2 != nil {
+					d := *v1 - *v2
+					sum += float64(d * d)
+					dims++
+				}
+			}
+			if dims > 0 {
+				distances = append(distances, math.Sqrt(sum))
+			}
+		}
+	}
+	return distances
+}
+
+// stdDev returns the population standard deviation, 0 for fewer than two values
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		d := v - mean
+		variance += float64(d * d)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+// Longevity weighs the four longevity dimensions:
+// 0.30 development distribution + 0.30 contributor retention + 0.25 PR acceptance
+// rate + 0.15 technical pulse, in [0, 1]
+func Longevity(info models.RepositoryInfo) (float64, *grpcclient.LongevityBreakdown) {
+	b := &grpcclient.LongevityBreakdown{}
+	prAcceptanceRate(b, info.PullRequests)
+	developmentDistribution(b, info.ContributorStats)
+	contributorRetention(b, info.ContributorStats)
+	technicalPulse(b, info.ContributorStats)
+
+	// The conversions keep the compiler from fusing the multiply-adds, which would round
+	// differently from Python on some architectures
+	return float64(0.30*b.DevelopmentDistribution) + float64(0.30*b.ContributorRetention) +
+		float64(0.25*b.PRAcceptanceRate) + float64(0.15*b.TechnicalPulse), b
+}
+
+// prAcceptanceRate sets the share of closed pull requests that were merged; merged
+// pull requests count as closed
+func prAcceptanceRate(b *grpcclient.LongevityBreakdown, prs []models.PullRequestInfo) {
+	for _, pr := range prs {
+		if status := strings.ToLower(pr.Status); status != "closed" && status != "merged" {
+			continue
+		}
+		b.ClosedPRs++
+		if pr.MergedAt != nil {
+			b.MergedPRs++
+		}
+	}
+	if b.ClosedPRs > 0 {
+		b.PRAcceptanceRate = float64(b.MergedPRs) / float64(b.ClosedPRs)
+	}
+}
+
+// developmentDistribution sets 1 minus the Gini coefficient of the commit counts of
+// the contributors with commits; 0 with fewer than two of them
+func developmentDistribution(b *grpcclient.LongevityBreakdown, stats []models.ContributorStats) {
+	var counts []int
+	for _, s := range stats {
+		if s.Total > 0 {
+			counts = append(counts, s.Total)
+			b.Commits += s.Total
+		}
+	}
+	n := len(counts)
+	b.Committers = n
+	if n < 2 {
+		return
+	}
+	sort.Ints(counts)
+
+	// G = sum((2i - n - 1) * x_i) / (n * sum(x_i)), with i starting at 1
+	var numerator, sum int64
+	for i, x := range counts {
+		numerator += int64(2*(i+1)-n-1) * int64(x)
+		sum += int64(x)
+	}
+	b.Gini = float64(numerator) / float64(int64(n)*sum)
+	b.DevelopmentDistribution = 1 - b.Gini
+}
+
+// contributorRetention sets the share of contributors whose first and last commits
+// are more than 365 days apart, among those whose commit dates are known
+func contributorRetention(b *grpcclient.LongevityBreakdown, stats []models.ContributorStats) {
+	for _, s := range stats {
+		if s.FirstCommit.IsZero() || s.LastCommit.IsZero() {
+			continue
+		}
+		b.Contributors++
+		if tenureDays(s.FirstCommit, s.LastCommit) > 365 {
+			b.LongTermContributors++
+		}
+	}
+	if b.Contributors > 0 {
+		b.ContributorRetention = float64(b.LongTermContributors) / float64(b.Contributors)
+	}
+}
+
+// tenureDays counts the whole days from first to last, rounding down like a Python
+// timedelta. Timestamps travel to the processor with second precision.
+func tenureDays(first, last time.Time) int64 {
+	seconds := last.Unix() - first.Unix()
+	days := seconds / 86400
+	if seconds%86400 < 0 {
+		days--
+	}
+	return days
+}
+
+// technicalPulse sets the share of the 52 weeks before the latest commit of the
+// repository that had commits, counting UTC ISO weeks; 0 when no commit date is known
+func technicalPulse(b *grpcclient.LongevityBreakdown, stats []models.ContributorStats) {
+	var reference time.Time
+	for _, s := range stats {
+		if s.LastCommit.After(reference) {
+			reference = s.LastCommit
+		}
+	}
+	if reference.IsZero() {
+		return
+	}
+	b.ReferenceDate = reference.UTC().Format(time.RFC3339)
+	since := reference.Unix() - 365*86400
+
+	type isoWeek struct{ year, week int }
+	active := make(map[isoWeek]bool)
+	for _, s := range stats {
+		for _, w := range s.Weeks {
+			if w.WeekTimestamp >= since && w.Commits > 0 {
+				year, week := time.Unix(w.WeekTimestamp, 0).UTC().ISOWeek()
+				active[isoWeek{year, week}] = true
+				b.CommitsLastYear += w.Commits
+			}
+		}
+	}
+	b.ActiveWeeks = len(active)
+	b.TechnicalPulse = float64(b.ActiveWeeks) / 52.0
+}
@@ -0,0 +1,53 @@
+// Package metrics computes the community metrics of an extracted repository in
+// process. The calculators are ports of the Python ones behind the processor gRPC
+// service and give the same results on the same repository.
+package metrics
+
+import (
+	"context"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Calculator computes formality, geodispersion, longevity, cohesion, engagement and
+// structure
+type Calculator struct {
+	datasets *datasets
+	logger   *logrus.Logger
+}
+
+// NewCalculator returns a calculator reading the location and culture datasets from
+// datasetsDir. The datasets are loaded the first time geodispersion needs them.
+func NewCalculator(datasetsDir string, logger *logrus.Logger) *Calculator {
+	return &Calculator{
+		datasets: &datasets{dir: datasetsDir},
+		logger:   logger,
+	}
+}
+
+// ProcessRepository computes the metrics of info
+func (c *Calculator) ProcessRepository(ctx context.Context, info models.RepositoryInfo) (*grpcclient.ProcessResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var result grpcclient.ProcessResult
+	var b grpcclient.Breakdown
+	var err error
+	if result.Geodispersion, b.Geodispersion, err = c.Geodispersion(info); err != nil {
+		return nil, err
+	}
+	result.Formality, b.Formality = Formality(info)
+	result.Longevity, b.Longevity = Longevity(info)
+	result.Cohesion, b.Cohesion = Cohesion(info)
+	result.Engagement, b.Engagement = Engagement(info)
+	result.Structure, b.Structure = Structure(info)
+	result.Breakdown = &b
+
+	c.logger.Debugf("Metrics of %s/%s: formality=%.4f geodispersion=%.4f longevity=%.4f cohesion=%.4f engagement=%.4f structure=%.4f",
+		info.Owner, info.Repo, result.Formality, result.Geodispersion, result.Longevity, result.Cohesion, result.Engagement, result.Structure)
+	return &result, nil
+}
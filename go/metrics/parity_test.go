@@ -0,0 +1,297 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/proto"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// parityCities is a cities dataset with the cities of parityRepository
+const parityCities = `name,state_name,state_code,country_name,country_code,latitude,longitude,population,type
+Lisbon,Lisbon,11,Portugal,PT,38.71667,-9.13333,517802,capital
+Tokyo,Tokyo,13,Japan,JP,35.6895,139.69171,8336599,capital
+Berlin,Berlin,BE,Germany,DE,52.52437,13.41053,3426354,capital
+São Paulo,São Paulo,SP,Brazil,BR,-23.5475,-46.63611,10021295,
+`
+
+// parityScript converts the repository the way the processor service does and prints
+// the breakdown of every Python calculator. protojson writes int64 fields as strings.
+const parityScript = `
+import json, sys
+from types import SimpleNamespace
+from calculators import (
+    CohesionCalculator, EngagementCalculator, FormalityCalculator,
+    GeodispersionCalculator, LongevityCalculator, StructureCalculator,
+)
+from repository import repository_data
+
+def message(value):
+    if isinstance(value, dict):
+        return SimpleNamespace(**{k: message(v) for k, v in value.items()})
+    if isinstance(value, list):
+        return [message(v) for v in value]
+    return value
+
+data = json.load(sys.stdin)
+for stat in data["contributor_stats"]:
+    for week in stat["weeks"]:
+        week["week"] = int(week["week"])
+repo_data = repository_data(message(data))
+json.dump({
+    "formality": FormalityCalculator.breakdown(repo_data),
+    "geodispersion": GeodispersionCalculator.breakdown(repo_data),
+    "longevity": LongevityCalculator.breakdown(repo_data),
+    "cohesion": CohesionCalculator.breakdown(repo_data),
+    "engagement": EngagementCalculator.breakdown(repo_data),
+    "structure": StructureCalculator.breakdown(repo_data),
+}, sys.stdout)
+`
+
+// parityRepository exercises every metric along with the cases the two implementations
+// used to disagree on: unmerged pull requests, weeks that are a single ISO week in
+// time zones west of UTC, and a contributor whose commit dates are unknown
+func parityRepository() models.RepositoryInfo {
+	day := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, time.UTC) }
+	at := func(t time.Time) *time.Time { return &t }
+	week := func(t time.Time, commits int) models.Week {
+		return models.Week{WeekTimestamp: t.Unix(), Additions: 10 * commits, Deletions: commits, Commits: commits}
+	}
+
+	return models.RepositoryInfo{
+		Owner:                     "acme",
+		Repo:                      "widget",
+		Description:               "Widgets",
+		HasDescription:            true,
+		HasReadme:                 true,
+		HasLicense:                true,
+		HasContributingGuidelines: true,
+		HasMilestones:             true,
+		Contributors: []models.ContributorDetail{
+			{Login: "alice", Location: "Lisbon", Followers: 2, Following: 1, CreatedAt: day(2015, 1, 1, 0)},
+			{Login: "bob", Location: "Tokyo, Japan", Followers: 1, Following: 2, CreatedAt: day(2016, 1, 1, 0)},
+			{Login: "carol", Location: "Berlin", Following: 1, CreatedAt: day(2017, 1, 1, 0)},
+			{Login: "dave", Location: "São Paulo, Brazil", CreatedAt: day(2018, 1, 1, 0)},
+			{Login: "erin", Location: "Atlantis", CreatedAt: day(2019, 1, 1, 0)},
+		},
+		ContributorStats: []models.ContributorStats{
+			{
+				Author: "alice", Total: 30,
+				FirstCommit: day(2022, 1, 2, 0), LastCommit: day(2024, 3, 3, 0),
+				Weeks: []models.Week{week(day(2022, 1, 2, 0), 10), week(day(2023, 6, 4, 0), 8), week(day(2024, 3, 3, 0), 12)},
+			},
+			{
+				// Sunday noon and Monday 03:00 UTC: two ISO weeks in UTC, one in Los Angeles
+				Author: "bob", Total: 7,
+				FirstCommit: day(2023, 12, 10, 12), LastCommit: day(2023, 12, 11, 3),
+				Weeks: []models.Week{week(day(2023, 12, 10, 12), 3), week(day(2023, 12, 11, 3), 4)},
+			},
+			{
+				// Counted as a committer, but without commit dates
+				Author: "carol", Total: 5, Weeks: []models.Week{},
+			},
+		},
+		PullRequests: []models.PullRequestInfo{
+			{Number: 1, Status: "merged", CreatedAt: at(day(2024, 1, 1, 0)), ClosedAt: at(day(2024, 1, 2, 0)), MergedAt: at(day(2024, 1, 2, 0))},
+			{Number: 2, Status: "closed", CreatedAt: at(day(2024, 1, 3, 0)), ClosedAt: at(day(2024, 1, 4, 0))},
+			{Number: 3, Status: "closed", CreatedAt: at(day(2024, 1, 5, 0)), ClosedAt: at(day(2024, 1, 6, 0))},
+			{Number: 4, Status: "merged", CreatedAt: at(day(2024, 1, 7, 0)), ClosedAt: at(day(2024, 1, 8, 0)), MergedAt: at(day(2024, 1, 8, 0))},
+			{Number: 5, Status: "open", CreatedAt: at(day(2024, 1, 9, 0))},
+		},
+		Engagement: models.Engagement{
+			Threads:          4,
+			RespondedThreads: 3,
+			Contributors: []models.ContributorEngagement{
+				{Login: "alice", Threads: 2, Comments: 5, Reviews: 2, ReactionsReceived: 4},
+				{Login: "bob", Threads: 1, Comments: 3, ReviewComments: 2, ReactionsReceived: 1},
+				{Login: "carol", Threads: 1, DiscussionComments: 1},
+			},
+			Collaboration: []models.CollaborationEdge{
+				{From: "alice", To: "bob", Weight: 3},
+				{From: "bob", To: "alice", Weight: 2},
+				{From: "bob", To: "carol", Weight: 1},
+				{From: "carol", To: "alice", Weight: 1},
+			},
+		},
+	}
+}
+
+// TestPythonParity runs the Go calculators and the Python ones of the processor service
+// on the same repository and expects the same breakdowns. The repository reaches
+// Python the way the server sends it: mapped to the proto message, then converted by
+// python/repository.py. Python runs in Los Angeles time to catch local time bucketing.
+func TestPythonParity(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+
+	// A copy of the calculators next to a datasets directory holding parityCities
+	root := filepath.Join("..", "..", "python")
+	dir := t.TempDir()
+	for _, pattern := range []string{"repository.py", filepath.Join("calculators", "*.py"), filepath.Join("datasets", hofstedeFile)} {
+		files, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil || len(files) == 0 {
+			t.Fatalf("no %s under %s", pattern, root)
+		}
+		for _, f := range files {
+			rel, _ := filepath.Rel(root, f)
+			copyFile(t, f, filepath.Join(dir, rel))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "datasets", citiesFile), []byte(parityCities), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := parityRepository()
+	input, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(proto.RepositoryInfoToProto(info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(python, "-c", parityScript)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TZ=America/Los_Angeles", "PYTHONDONTWRITEBYTECODE=1")
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("python calculators: %v\n%s", err, stderr.String())
+	}
+	var want map[string]map[string]interface{}
+	if err := json.Unmarshal(output, &want); err != nil {
+		t.Fatalf("decode %s: %v", output, err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	result, err := NewCalculator(filepath.Join(dir, "datasets"), logger).ProcessRepository(context.Background(), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scores map[string]interface{}
+	var breakdowns map[string]map[string]interface{}
+	roundTrip(t, result, &scores)
+	roundTrip(t, result.Breakdown, &breakdowns)
+
+	for metric, fields := range want {
+		got := breakdowns[metric]
+		got[metric] = scores[metric]
+		// Go and C round sines and cosines differently
+		tolerance := 0.0
+		if metric == "geodispersion" {
+			tolerance = 1e-12
+		}
+		for field, w := range fields {
+			g, ok := got[field]
+			if !ok && w == "" {
+				continue // Empty strings are omitted
+			}
+			if !same(g, w, tolerance) {
+				t.Errorf("%s.%s: Go %v, Python %v", metric, field, g, w)
+			}
+		}
+	}
+
+	// The fixture covers the cases it was written for, and pins the Python longevity:
+	// unmerged pull requests are not accepted, the pulse counts UTC weeks and the
+	// contributor without commit dates is left out of the retention
+	longevity := want["longevity"]
+	if longevity["merged_prs"] != 2.0 || longevity["closed_prs"] != 4.0 {
+		t.Errorf("pull requests %v merged of %v closed, want 2 of 4", longevity["merged_prs"], longevity["closed_prs"])
+	}
+	if longevity["active_weeks"] != 4.0 || longevity["contributors"] != 2.0 || longevity["committers"] != 3.0 {
+		t.Errorf("%v active weeks, %v contributors with dates, %v committers; want 4, 2 and 3",
+			longevity["active_weeks"], longevity["contributors"], longevity["committers"])
+	}
+	pinned := map[string]float64{
+		"pr_acceptance_rate":    0.5,
+		"contributor_retention": 0.5,
+		"technical_pulse":       4.0 / 52,
+		"longevity":             0.46749084249084255,
+	}
+	for field, p := range pinned {
+		if !same(longevity[field], p, 1e-12) {
+			t.Errorf("python longevity.%s = %v, want %v", field, longevity[field], p)
+		}
+	}
+	if n := len(want["geodispersion"]["matched_locations"].([]interface{})); n != 4 {
+		t.Errorf("%d matched locations, want 4", n)
+	}
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// roundTrip decodes the JSON encoding of v into out
+func roundTrip(t *testing.T, v, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// same compares decoded JSON values, numbers within tolerance of each other relative to
+// their size
+func same(a, b interface{}, tolerance float64) bool {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		return ok && math.Abs(a-b) <= tolerance*math.Max(1, math.Abs(b))
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !same(a[i], b[i], tolerance) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k := range a {
+			if !same(a[k], b[k], tolerance) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		// Go encodes empty lists as null
+		list, ok := b.([]interface{})
+		return b == nil || ok && len(list) == 0
+	default:
+		return a == b
+	}
+}
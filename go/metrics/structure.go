@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"strings"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+// Structure describes how well the collaboration graph holds the community together, in
+// [0, 1]. Participants are linked when either responded to a thread of the other; the
+// score is the mean of connectedness, the share of participants in the largest connected
+// component, and clustering, the share of connected triples that close into triangles.
+func Structure(info models.RepositoryInfo) (float64, *grpcclient.StructureBreakdown) {
+	b := &grpcclient.StructureBreakdown{Participants: len(info.Engagement.Contributors)}
+
+	adjacency := make(map[string]map[string]struct{}, b.Participants)
+	for _, c := range info.Engagement.Contributors {
+		adjacency[strings.ToLower(c.Login)] = make(map[string]struct{})
+	}
+	for _, edge := range info.Engagement.Collaboration {
+		from, to := strings.ToLower(edge.From), strings.ToLower(edge.To)
+		if from == to || adjacency[from] == nil || adjacency[to] == nil {
+			continue
+		}
+		if _, ok := adjacency[from][to]; !ok {
+			adjacency[from][to] = struct{}{}
+			adjacency[to][from] = struct{}{}
+			b.Edges++
+		}
+	}
+
+	// Connected components by depth-first search
+	visited := make(map[string]bool, len(adjacency))
+	for start := range adjacency {
+		if visited[start] {
+			continue
+		}
+		size := 0
+		stack := []string{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			for next := range adjacency[node] {
+				if !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		b.LargestComponent = max(b.LargestComponent, size)
+	}
+
+	// Each triangle is counted once, from its lexically smallest node
+	for node, neighbors := range adjacency {
+		degree := len(neighbors)
+		b.ConnectedTriples += degree * (degree - 1) / 2
+		for u := range neighbors {
+			if u <= node {
+				continue
+			}
+			for w := range adjacency[u] {
+				if w <= u {
+					continue
+				}
+				if _, ok := neighbors[w]; ok {
+					b.Triangles++
+				}
+			}
+		}
+	}
+
+	if b.Participants >= 2 {
+		b.Connectedness = float64(b.LargestComponent) / float64(b.Participants)
+	}
+	if b.ConnectedTriples > 0 {
+		b.Clustering = float64(3*b.Triangles) / float64(b.ConnectedTriples)
+	}
+	return (b.Connectedness + b.Clustering) / 2, b
+}
@@ -0,0 +1,28 @@
+package models
+
+// Engagement summarizes the discussions of the most recently updated issues, pull
+// requests and discussions of a repository
+type Engagement struct {
+	Threads          int                     `json:"threads"`           // Issues, pull requests and discussions inspected
+	RespondedThreads int                     `json:"responded_threads"` // Threads someone other than the author responded to
+	Contributors     []ContributorEngagement `json:"contributors"`      // Everyone who opened or responded to a thread
+	Collaboration    []CollaborationEdge     `json:"collaboration"`     // Who responded to whom
+}
+
+// ContributorEngagement counts how a contributor took part in the inspected threads
+type ContributorEngagement struct {
+	Login              string `json:"login"`
+	Threads            int    `json:"threads"`             // Threads opened
+	Comments           int    `json:"comments"`            // Comments on issue and pull request conversations
+	ReviewComments     int    `json:"review_comments"`     // Inline comments on pull request diffs
+	Reviews            int    `json:"reviews"`             // Pull request reviews
+	DiscussionComments int    `json:"discussion_comments"` // Comments and replies in discussions
+	ReactionsReceived  int    `json:"reactions_received"`  // Reactions on the contributor's threads and comments
+}
+
+// CollaborationEdge records that a contributor responded to threads opened by another
+type CollaborationEdge struct {
+	From   string `json:"from"`   // Commenter or reviewer
+	To     string `json:"to"`     // Author of the threads
+	Weight int    `json:"weight"` // Number of responses
+}
@@ -0,0 +1,13 @@
+package models
+
+// FollowGraph is the directed follow graph among the selected contributors of a repository
+type FollowGraph struct {
+	Members []string     `json:"members"` // Logins whose following lists were read
+	Edges   []FollowEdge `json:"edges"`   // Follow relations between members
+}
+
+// FollowEdge records that one member follows another
+type FollowEdge struct {
+	Follower string `json:"follower"`
+	Followed string `json:"followed"`
+}
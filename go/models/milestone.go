@@ -0,0 +1,11 @@
+package models
+
+import (
+	"time"
+)
+
+// MilestoneInfo holds the dates of a milestone
+type MilestoneInfo struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"` // Nil while open, and on hosts that do not report it
+}
@@ -0,0 +1,18 @@
+package models
+
+// SelectionPolicy chooses the contributors whose profiles and follow lists are fetched
+type SelectionPolicy struct {
+	Strategy   string  `json:"strategy"`             // all, top, sqrt, percentile or active
+	N          int     `json:"n,omitempty"`          // Contributors kept by "top"
+	Percentile float64 `json:"percentile,omitempty"` // Percentile of the contribution counts "percentile" keeps contributors at or above
+	// Days also selects everyone who committed in the last Days days; 0 adds nobody.
+	// With "active" they are the only contributors selected.
+	Days int `json:"days,omitempty"`
+}
+
+// ContributorSelection records the policy an extraction selected contributors with and
+// the logins it selected
+type ContributorSelection struct {
+	Policy SelectionPolicy `json:"policy"`
+	Logins []string        `json:"logins"`
+}
@@ -68,10 +68,15 @@ func RepositoryInfoToProto(info models.RepositoryInfo) *Repository {
 	// Map contributor stats
 	for _, cs := range info.ContributorStats {
 		protoStats := &ContributorStats{
-			Author:      cs.Author,
-			Total:       int32(cs.Total),
-			FirstCommit: cs.FirstCommit.Format(time.RFC3339),
-			LastCommit:  cs.LastCommit.Format(time.RFC3339),
+			Author: cs.Author,
+			Total:  int32(cs.Total),
+		}
+		// Like merged_at, unknown commit dates are left empty rather than sent as year 1
+		if !cs.FirstCommit.IsZero() {
+			protoStats.FirstCommit = cs.FirstCommit.Format(time.RFC3339)
+		}
+		if !cs.LastCommit.IsZero() {
+			protoStats.LastCommit = cs.LastCommit.Format(time.RFC3339)
 		}
 		for _, w := range cs.Weeks {
 			protoStats.Weeks = append(protoStats.Weeks, &WeekStats{
@@ -96,5 +101,29 @@ func RepositoryInfoToProto(info models.RepositoryInfo) *Repository {
 		repo.PullRequests = append(repo.PullRequests, protoPR)
 	}
 
+	// Map engagement
+	repo.Engagement = &Engagement{
+		Threads:          int32(info.Engagement.Threads),
+		RespondedThreads: int32(info.Engagement.RespondedThreads),
+	}
+	for _, c := range info.Engagement.Contributors {
+		repo.Engagement.Contributors = append(repo.Engagement.Contributors, &ContributorEngagement{
+			Login:              c.Login,
+			Threads:            int32(c.Threads),
+			Comments:           int32(c.Comments),
+			ReviewComments:     int32(c.ReviewComments),
+			Reviews:            int32(c.Reviews),
+			DiscussionComments: int32(c.DiscussionComments),
+			ReactionsReceived:  int32(c.ReactionsReceived),
+		})
+	}
+	for _, e := range info.Engagement.Collaboration {
+		repo.Engagement.Collaboration = append(repo.Engagement.Collaboration, &CollaborationEdge{
+			From:   e.From,
+			To:     e.To,
+			Weight: int32(e.Weight),
+		})
+	}
+
 	return repo
 }
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github-extractor/grpcclient"
+	"github-extractor/metrics"
+	"github-extractor/models"
+	"github-extractor/source"
+	"github-extractor/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordAnalysis stores a processed repository in the result store and returns the analysis ID.
+// Failures are only logged: a broken store must not fail the request that produced the result.
+func recordAnalysis(st *store.Store, logger *logrus.Logger, req ExtractRequest, params EligibilityParams, thresholds metrics.Thresholds, info models.RepositoryInfo, result *grpcclient.ProcessResult) string {
+	if st == nil {
+		return ""
+	}
+
+	// Keep the thresholds actually used, not just the ones the caller sent
+	req.MinCommits = &params.MinCommits
+	req.Days = &params.Days
+	req.MinActive = &params.MinActive
+	req.Thresholds = &ThresholdOverrides{
+		Geodispersion: &thresholds.Geodispersion,
+		Formality:     &thresholds.Formality,
+		Longevity:     &thresholds.Longevity,
+		Cohesion:      &thresholds.Cohesion,
+	}
+	policy := info.Selection.Policy
+	req.Selection = &SelectionRequest{Strategy: policy.Strategy, N: policy.N, Percentile: policy.Percentile, Days: &policy.Days}
+	parameters, err := json.Marshal(req)
+	if err != nil {
+		logger.Errorf("Error encoding analysis parameters for %s/%s: %v", info.Owner, info.Repo, err)
+		return ""
+	}
+
+	analysis := &store.Analysis{
+		Host:       source.NormalizeHost(req.Host),
+		Owner:      info.Owner,
+		Repo:       info.Repo,
+		Parameters: parameters,
+		Repository: info,
+		Result:     result,
+	}
+	if err := st.Save(analysis); err != nil {
+		logger.Errorf("Error saving analysis for %s/%s: %v", info.Owner, info.Repo, err)
+		return ""
+	}
+
+	logger.Infof("Saved analysis %s for %s/%s", analysis.ID, info.Owner, info.Repo)
+	return analysis.ID
+}
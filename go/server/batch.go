@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// maxBatchSize caps the number of repositories accepted by a single batch request
+const maxBatchSize = 1000
+
+// BatchResult holds the outcome for a single repository of a batch request
+type BatchResult struct {
+	Host       string                 `json:"host,omitempty"`
+	Owner      string                 `json:"owner"`
+	Repo       string                 `json:"repo"`
+	Done       bool                   `json:"done"` // Whether the repository has been checked and extracted
+	Eligible   bool                   `json:"eligible"`
+	Reason     string                 `json:"reason,omitempty"` // Why the repository is not eligible
+	Repository *models.RepositoryInfo `json:"repository,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// BatchResponse describes an asynchronous batch extraction started with /extract/batch.
+// Results are in input order and filled in as repositories finish.
+type BatchResponse struct {
+	ID         string        `json:"id,omitempty"`
+	Status     JobStatus     `json:"status,omitempty"`
+	CreatedAt  *time.Time    `json:"created_at,omitempty"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Results    []BatchResult `json:"results"`
+	Total      int           `json:"total"`
+	Done       int           `json:"done"` // Repositories finished so far
+	Eligible   int           `json:"eligible"`
+	Failed     int           `json:"failed"`
+	Error      string        `json:"error,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// ProcessBatch runs the repositories through the worker pool, at most as many at once as
+// there are workers, and calls onResult with the index and outcome of each as it finishes.
+// Each request is checked for eligibility with its own thresholds before being extracted.
+// It returns once every repository has finished or ctx is cancelled.
+func (s *Service) ProcessBatch(ctx context.Context, reqs []ExtractRequest, onResult func(int, BatchResult)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.workers)
+	for i, req := range reqs {
+		result := BatchResult{Host: req.Host, Owner: req.Owner, Repo: req.Repo, Done: true}
+
+		minCommits, days, minActive, err := resolveEligibilityParams(req)
+		if err != nil {
+			result.Error = err.Error()
+			onResult(i, result)
+			continue
+		}
+		selection, err := resolveSelection(req)
+		if err != nil {
+			result.Error = err.Error()
+			onResult(i, result)
+			continue
+		}
+		asOf, err := resolveAsOf(req)
+		if err != nil {
+			result.Error = err.Error()
+			onResult(i, result)
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(idx int, result BatchResult, params EligibilityParams, selection models.SelectionPolicy, asOf time.Time, engagement bool) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx := source.WithEngagement(source.WithAsOf(source.WithSelection(ctx, selection), asOf), engagement)
+			res := s.CheckAndProcessRepository(ctx, result.Host, result.Owner, result.Repo, params)
+			switch {
+			case res.Err != nil:
+				result.Error = res.Err.Error()
+			case !res.Eligible:
+				result.Reason = res.Reason
+			default:
+				info := res.Info
+				result.Eligible = true
+				result.Repository = &info
+				result.Error = info.Error
+			}
+			onResult(idx, result)
+		}(i, result, EligibilityParams{MinCommits: minCommits, Days: days, MinActive: minActive}, selection, asOf, req.Engagement)
+	}
+	wg.Wait()
+}
+
+// SubmitBatch registers a batch extraction of reqs and starts it in the background. A
+// batch counts as a single pending job: it returns errTooManyJobs when maxPending jobs
+// and batches are already queued or running.
+func (m *JobManager) SubmitBatch(reqs []ExtractRequest) (BatchResponse, error) {
+	id, err := newJobID()
+	if err != nil {
+		return BatchResponse{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	created := time.Now()
+	batch := &BatchResponse{
+		ID:        id,
+		Status:    JobQueued,
+		CreatedAt: &created,
+		Results:   make([]BatchResult, len(reqs)),
+		Total:     len(reqs),
+		cancel:    cancel,
+	}
+	for i, req := range reqs {
+		batch.Results[i] = BatchResult{Host: req.Host, Owner: req.Owner, Repo: req.Repo}
+	}
+
+	m.mu.Lock()
+	if m.pending >= m.maxPending {
+		m.mu.Unlock()
+		cancel()
+		return BatchResponse{}, errTooManyJobs
+	}
+	m.pruneLocked()
+	m.batches[id] = batch
+	m.pending++
+	snapshot := batch.snapshot()
+	m.mu.Unlock()
+
+	go m.runBatch(ctx, batch, reqs)
+
+	m.logger.Infof("Batch %s queued with %d repositories", id, len(reqs))
+	return snapshot, nil
+}
+
+// GetBatch returns a snapshot of the batch with the given ID
+func (m *JobManager) GetBatch(id string) (BatchResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batch, ok := m.batches[id]
+	if !ok {
+		return BatchResponse{}, errJobNotFound
+	}
+	return batch.snapshot(), nil
+}
+
+// CancelBatch stops a queued or running batch; the repositories already finished keep
+// their results
+func (m *JobManager) CancelBatch(id string) (BatchResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batch, ok := m.batches[id]
+	if !ok {
+		return BatchResponse{}, errJobNotFound
+	}
+	if batch.Status.Finished() {
+		return batch.snapshot(), errJobFinished
+	}
+
+	batch.cancel()
+	m.finishBatchLocked(batch, JobCancelled)
+	m.logger.Infof("Batch %s cancelled", id)
+	return batch.snapshot(), nil
+}
+
+// runBatch extracts the repositories of a batch and records each result as it comes in
+func (m *JobManager) runBatch(ctx context.Context, batch *BatchResponse, reqs []ExtractRequest) {
+	defer batch.cancel()
+
+	m.mu.Lock()
+	if !batch.Status.Finished() {
+		batch.Status = JobRunning
+	}
+	m.mu.Unlock()
+
+	m.service.ProcessBatch(ctx, reqs, func(i int, res BatchResult) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if batch.Status.Finished() {
+			return
+		}
+		batch.Results[i] = res
+		batch.Done++
+		if res.Eligible {
+			batch.Eligible++
+		}
+		if res.Error != "" {
+			batch.Failed++
+		}
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finishBatchLocked(batch, JobCompleted)
+}
+
+// finishBatchLocked records the final state of a batch unless it already has one
+func (m *JobManager) finishBatchLocked(batch *BatchResponse, status JobStatus) {
+	if batch.Status.Finished() {
+		return
+	}
+	now := time.Now()
+	m.pending--
+	batch.Status = status
+	batch.FinishedAt = &now
+}
+
+// snapshot copies the batch so it can be read without holding the manager lock
+func (b *BatchResponse) snapshot() BatchResponse {
+	c := *b
+	c.Results = append([]BatchResult(nil), b.Results...)
+	return c
+}
+
+// batchColumns are the column names a CSV header row may use
+var batchColumns = map[string]bool{
+	"owner":      true,
+	"repo":       true,
+	"repository": true,
+	"name":       true,
+	"full_name":  true,
+	"owner/repo": true,
+}
+
+// ParseBatchCSV reads repositories from a CSV with either "owner,repo" columns or a single
+// "owner/repo" column. A header row is optional; the first row is taken for one when all
+// its columns are known column names, such as "owner,repo" or "repository". The host and
+// thresholds in defaults apply to every row.
+func ParseBatchCSV(r io.Reader, defaults ExtractRequest) ([]ExtractRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var reqs []ExtractRequest
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		// Skip the optional header
+		if first {
+			first = false
+			if isBatchHeader(record) {
+				continue
+			}
+		}
+
+		var owner, repo string
+		switch {
+		case len(record) >= 2:
+			owner, repo = strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		case len(record) == 1:
+			owner, repo, _ = strings.Cut(strings.TrimSpace(record[0]), "/")
+		}
+
+		// Skip blank lines
+		if owner == "" && repo == "" {
+			continue
+		}
+		if owner == "" || repo == "" {
+			return nil, fmt.Errorf("line %d: expected owner and repo", line)
+		}
+
+		req := defaults
+		req.Owner = owner
+		req.Repo = repo
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+// isBatchHeader reports whether every non-empty column of a CSV row is a known column name
+func isBatchHeader(record []string) bool {
+	named := false
+	for _, field := range record {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !batchColumns[field] {
+			return false
+		}
+		named = true
+	}
+	return named
+}
+
+// validateBatch checks the size of a batch and the required fields of every entry
+func validateBatch(reqs []ExtractRequest) error {
+	if len(reqs) == 0 {
+		return fmt.Errorf("no repositories provided")
+	}
+	if len(reqs) > maxBatchSize {
+		return fmt.Errorf("too many repositories: %d (max %d)", len(reqs), maxBatchSize)
+	}
+	for i, req := range reqs {
+		if req.Owner == "" {
+			return fmt.Errorf("repository %d: owner is required", i)
+		}
+		if req.Repo == "" {
+			return fmt.Errorf("repository %d: repo is required", i)
+		}
+	}
+	return nil
+}
+
+// parseOptionalInt parses a form value into an optional int
+func parseOptionalInt(name, value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &n, nil
+}
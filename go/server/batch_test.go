@@ -0,0 +1,163 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBatchCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string // owner/repo of each request
+		wantErr string
+	}{
+		{
+			name:  "header and two columns",
+			input: "owner,repo\ngolang,go\nmicrosoft,vscode\n",
+			want:  []string{"golang/go", "microsoft/vscode"},
+		},
+		{
+			name:  "repository header",
+			input: "repository\ngolang/go\n",
+			want:  []string{"golang/go"},
+		},
+		{
+			name:  "owner/repo header",
+			input: "owner/repo\ngolang/go\n",
+			want:  []string{"golang/go"},
+		},
+		{
+			name:  "header in another case",
+			input: "Owner,Repository\ngolang,go\n",
+			want:  []string{"golang/go"},
+		},
+		{
+			name:  "first row with one unknown column is data",
+			input: "name,go\n",
+			want:  []string{"name/go"},
+		},
+		{
+			name:  "no header",
+			input: "golang,go\n",
+			want:  []string{"golang/go"},
+		},
+		{
+			name:  "single owner/repo column",
+			input: "golang/go\nkubernetes/kubernetes\n",
+			want:  []string{"golang/go", "kubernetes/kubernetes"},
+		},
+		{
+			name:  "quoted fields and spaces",
+			input: "\"owner\",\"repo\"\n\"golang\", \"go\"\n \"a,b\",c\n",
+			want:  []string{"golang/go", "a,b/c"},
+		},
+		{
+			name:  "blank lines are skipped",
+			input: "golang,go\n\n,\nmicrosoft,vscode\n",
+			want:  []string{"golang/go", "microsoft/vscode"},
+		},
+		{
+			name:  "extra columns are ignored",
+			input: "golang,go,ignored\n",
+			want:  []string{"golang/go"},
+		},
+		{
+			name:  "owner header only on the first row",
+			input: "golang,go\nowner,repo\n",
+			want:  []string{"golang/go", "owner/repo"},
+		},
+		{
+			name:    "missing repo",
+			input:   "golang,go\nmicrosoft,\n",
+			wantErr: "line 2: expected owner and repo",
+		},
+		{
+			name:    "missing slash",
+			input:   "golang\n",
+			wantErr: "line 1: expected owner and repo",
+		},
+		{
+			name:    "bare quote",
+			input:   "gol\"ang,go\n",
+			wantErr: "invalid CSV",
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := ParseBatchCSV(strings.NewReader(tt.input), ExtractRequest{Host: "gitlab.com"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, req := range reqs {
+				got = append(got, req.Owner+"/"+req.Repo)
+				if req.Host != "gitlab.com" {
+					t.Errorf("%s/%s: host = %q, want the default gitlab.com", req.Owner, req.Repo, req.Host)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBatchCSVDefaults(t *testing.T) {
+	minCommits := 5
+	reqs, err := ParseBatchCSV(strings.NewReader("a,b\nc,d\n"), ExtractRequest{MinCommits: &minCommits})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range reqs {
+		if req.MinCommits == nil || *req.MinCommits != 5 {
+			t.Errorf("%s/%s: min_commits = %v, want 5", req.Owner, req.Repo, req.MinCommits)
+		}
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	tooMany := make([]ExtractRequest, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = ExtractRequest{Owner: "o", Repo: "r"}
+	}
+
+	tests := []struct {
+		name    string
+		reqs    []ExtractRequest
+		wantErr string
+	}{
+		{"valid", []ExtractRequest{{Owner: "o", Repo: "r"}}, ""},
+		{"empty", nil, "no repositories provided"},
+		{"too many", tooMany, "too many repositories"},
+		{"missing owner", []ExtractRequest{{Owner: "o", Repo: "r"}, {Repo: "r"}}, "repository 1: owner is required"},
+		{"missing repo", []ExtractRequest{{Owner: "o"}}, "repository 0: repo is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBatch(tt.reqs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
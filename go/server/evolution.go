@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-extractor/metrics"
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// evolutionIntervals maps the intervals the evolution of a repository can be sampled at
+// to their length in months
+var evolutionIntervals = map[string]int{
+	"month":   1,
+	"quarter": 3,
+	"half":    6,
+	"year":    12,
+}
+
+const (
+	defaultEvolutionInterval = "quarter"
+	// maxEvolutionPoints caps the number of dates the metrics are computed at
+	maxEvolutionPoints = 400
+)
+
+// EvolutionRequest is an extraction request sampled at regular intervals
+type EvolutionRequest struct {
+	ExtractRequest
+	// Interval between two points: month, quarter (default), half or year
+	Interval string `json:"interval,omitempty"`
+}
+
+// EvolutionPoint holds the metrics and classification of a repository at one date.
+// Contributors counts the authors of the weekly statistics, but formality, geodispersion
+// and cohesion only see the contributors selected for the extraction date who had
+// committed by AsOf: the selection is not made again for every point. Formality counts the
+// milestones created by AsOf but the community files of the extraction date, which have
+// no history.
+type EvolutionPoint struct {
+	AsOf          time.Time `json:"as_of"`
+	Commits       int       `json:"commits"`       // Commits of the weekly statistics by AsOf
+	Contributors  int       `json:"contributors"`  // Authors with commits by AsOf
+	PullRequests  int       `json:"pull_requests"` // Pull requests closed by AsOf
+	Formality     float64   `json:"formality"`
+	Geodispersion float64   `json:"geodispersion"`
+	Longevity     *float64  `json:"longevity"` // Null when Incomplete
+	Cohesion      float64   `json:"cohesion"`
+	Category      *string   `json:"category"` // Null when Incomplete
+	// Incomplete is set when AsOf predates the oldest pull request of the extraction
+	// and the extraction stopped at its limit, so the pull requests of the point and the
+	// longevity they weigh in are unknown
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// EvolutionResponse represents the response of the /evolution endpoint
+type EvolutionResponse struct {
+	Owner         string              `json:"owner,omitempty"`
+	Repo          string              `json:"repo,omitempty"`
+	Interval      string              `json:"interval,omitempty"`
+	Points        []EvolutionPoint    `json:"points,omitempty"` // Oldest first; the last one is the extraction date
+	SimpleProject bool                `json:"simple_project,omitempty"`
+	Thresholds    *metrics.Thresholds `json:"thresholds,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// resolveInterval returns the interval of a request and its length in months
+func resolveInterval(interval string) (string, int, error) {
+	if interval == "" {
+		interval = defaultEvolutionInterval
+	}
+	months, ok := evolutionIntervals[interval]
+	if !ok {
+		return "", 0, fmt.Errorf("interval must be month, quarter, half or year")
+	}
+	return interval, months, nil
+}
+
+// evolutionDates returns the ends of the calendar periods of months months between start
+// and end, such as the first day of every quarter, followed by end itself
+func evolutionDates(start, end time.Time, months int) ([]time.Time, error) {
+	start, end = start.UTC(), end.UTC().Truncate(time.Second)
+	period := (int(start.Month()) - 1) / months * months
+	next := time.Date(start.Year(), time.Month(period+1), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+
+	var dates []time.Time
+	for ; next.Before(end); next = next.AddDate(0, months, 0) {
+		dates = append(dates, next)
+		if len(dates) >= maxEvolutionPoints {
+			return nil, fmt.Errorf("more than %d points: use a longer interval", maxEvolutionPoints)
+		}
+	}
+	return append(dates, end), nil
+}
+
+// evolutionStart is the date the evolution of a repository starts at: its first commit
+// week in the statistics, or its creation when they have none
+func evolutionStart(info models.RepositoryInfo) time.Time {
+	var start time.Time
+	for _, s := range info.ContributorStats {
+		if !s.FirstCommit.IsZero() && (start.IsZero() || s.FirstCommit.Before(start)) {
+			start = s.FirstCommit
+		}
+	}
+	if start.IsZero() {
+		return info.CreatedAt
+	}
+	return start
+}
+
+// pullRequestsSince returns the creation date of the oldest pull request of an
+// extraction that stopped at source.MaxPullRequests, before which pull requests are
+// missing, or the zero time when the extraction holds all of them
+func pullRequestsSince(info models.RepositoryInfo) time.Time {
+	if len(info.PullRequests) < source.MaxPullRequests {
+		return time.Time{}
+	}
+	var since time.Time
+	for _, pr := range info.PullRequests {
+		if pr.CreatedAt != nil && (since.IsZero() || pr.CreatedAt.Before(since)) {
+			since = *pr.CreatedAt
+		}
+	}
+	return since
+}
+
+// evolve computes the metrics and classification of an extracted repository at each of
+// the dates, reusing the extraction cut down to every date with source.InfoAsOf. Points
+// before the pull requests of a truncated extraction have no longevity or category.
+func evolve(ctx context.Context, processor Processor, info models.RepositoryInfo, dates []time.Time, thresholds metrics.Thresholds) ([]EvolutionPoint, error) {
+	since := pullRequestsSince(info)
+	points := make([]EvolutionPoint, 0, len(dates))
+	for _, date := range dates {
+		snapshot := source.InfoAsOf(info, date)
+		result, err := processor.ProcessRepository(ctx, snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("processing as of %s: %w", date.Format(time.RFC3339), err)
+		}
+
+		point := EvolutionPoint{
+			AsOf:          date,
+			Commits:       snapshot.Commits,
+			Contributors:  snapshot.TotalContributorsCount,
+			PullRequests:  len(snapshot.PullRequests),
+			Formality:     result.Formality,
+			Geodispersion: result.Geodispersion,
+			Cohesion:      result.Cohesion,
+			Incomplete:    !since.IsZero() && !date.After(since),
+		}
+		if !point.Incomplete {
+			category := metrics.Classify(result, thresholds).Category
+			point.Longevity, point.Category = &result.Longevity, &category
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
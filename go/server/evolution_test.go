@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github-extractor/grpcclient"
+	"github-extractor/metrics"
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// fixedProcessor returns the same metrics for every repository and keeps the
+// repositories it was given
+type fixedProcessor struct {
+	result  grpcclient.ProcessResult
+	release chan struct{} // When set, processing waits until it is closed
+
+	mu    sync.Mutex
+	infos []models.RepositoryInfo
+}
+
+func (p *fixedProcessor) ProcessRepository(ctx context.Context, info models.RepositoryInfo) (*grpcclient.ProcessResult, error) {
+	if p.release != nil {
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.infos = append(p.infos, info)
+	result := p.result
+	return &result, nil
+}
+
+// processed returns the repositories the processor was given
+func (p *fixedProcessor) processed() []models.RepositoryInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.RepositoryInfo(nil), p.infos...)
+}
+
+func TestEvolutionDates(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	end := time.Date(2021, 8, 10, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		start  time.Time
+		months int
+		want   []time.Time
+	}{
+		{"quarters", day(2020, 11, 5), 3, []time.Time{day(2021, 1, 1), day(2021, 4, 1), day(2021, 7, 1), end}},
+		{"years", day(2020, 11, 5), 12, []time.Time{day(2021, 1, 1), end}},
+		{"start in the last period", day(2021, 8, 1), 1, []time.Time{end}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evolutionDates(tt.start, end, tt.months)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("date %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := evolutionDates(day(1900, 1, 1), end, 1); err == nil {
+		t.Error("expected an error past the point limit")
+	}
+}
+
+func TestEvolveFlagsTruncatedPullRequests(t *testing.T) {
+	first := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pullRequests := func(n int) []models.PullRequestInfo {
+		prs := make([]models.PullRequestInfo, n)
+		for i := range prs {
+			created := first.AddDate(0, 0, i)
+			closed := created.Add(time.Hour)
+			prs[i] = models.PullRequestInfo{Number: i + 1, Status: "closed", CreatedAt: &created, ClosedAt: &closed, MergedAt: &closed}
+		}
+		return prs
+	}
+	before, after := first.AddDate(0, -1, 0), first.AddDate(0, 1, 0)
+	processor := &fixedProcessor{result: grpcclient.ProcessResult{Formality: 0.5, Longevity: 0.7}}
+	thresholds := metrics.Thresholds{Geodispersion: 0.25, Formality: 0.5, Longevity: 0.4, Cohesion: 0.4}
+
+	tests := []struct {
+		name       string
+		prs        int
+		incomplete []bool // Of the points before and after the first pull request
+	}{
+		{"all pull requests", source.MaxPullRequests - 1, []bool{false, false}},
+		{"truncated", source.MaxPullRequests, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := models.RepositoryInfo{PullRequests: pullRequests(tt.prs)}
+			points, err := evolve(context.Background(), processor, info, []time.Time{before, after}, thresholds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, p := range points {
+				if p.Incomplete != tt.incomplete[i] {
+					t.Errorf("point %d: incomplete = %v, want %v", i, p.Incomplete, tt.incomplete[i])
+				}
+				if p.Incomplete {
+					if p.Longevity != nil || p.Category != nil {
+						t.Errorf("point %d: longevity %v and category %v, want null", i, p.Longevity, p.Category)
+					}
+				} else if p.Longevity == nil || *p.Longevity != 0.7 || p.Category == nil {
+					t.Errorf("point %d: longevity %v and category %v, want 0.7 and a category", i, p.Longevity, p.Category)
+				}
+				if p.Formality != 0.5 {
+					t.Errorf("point %d: formality = %v, want 0.5", i, p.Formality)
+				}
+			}
+		})
+	}
+}
+
+func TestEvolveCountsMilestonesAtEachPoint(t *testing.T) {
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := models.RepositoryInfo{}
+	source.SetMilestones(&info, []models.MilestoneInfo{{CreatedAt: created}})
+	processor := &fixedProcessor{}
+
+	dates := []time.Time{created.AddDate(0, -1, 0), created.AddDate(0, 1, 0)}
+	if _, err := evolve(context.Background(), processor, info, dates, metrics.Thresholds{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{0, 1} {
+		got := processor.processed()[i]
+		if got.Milestones != want || got.HasMilestones != (want > 0) {
+			t.Errorf("point %d: %d milestones (has %v), want %d", i, got.Milestones, got.HasMilestones, want)
+		}
+	}
+}
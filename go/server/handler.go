@@ -1,25 +1,69 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"runtime"
+	"strconv"
+	"time"
 
+	"github-extractor/github"
+	"github-extractor/graph"
 	"github-extractor/grpcclient"
+	"github-extractor/metrics"
 	"github-extractor/models"
-	pb "github-extractor/proto"
+	"github-extractor/source"
+	"github-extractor/store"
 
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
 // ExtractRequest represents the incoming HTTP request payload
 type ExtractRequest struct {
+	Host       string `json:"host,omitempty"` // Host the repository lives on, e.g. gitlab.com; defaults to github.com
 	Owner      string `json:"owner"`
 	Repo       string `json:"repo"`
 	MinCommits *int   `json:"min_commits,omitempty"`
 	Days       *int   `json:"days,omitempty"`
 	MinActive  *int   `json:"min_active,omitempty"`
+	// Thresholds overrides the deployment's community classification thresholds
+	Thresholds *ThresholdOverrides `json:"thresholds,omitempty"`
+	// Selection chooses the contributors whose profiles are fetched; defaults to the top
+	// sqrt(total) contributors plus those active in the last 90 days
+	Selection *SelectionRequest `json:"selection,omitempty"`
+	// AsOf extracts the repository as it was at that date: later commits, pull requests
+	// and milestones are left out and the eligibility and activity windows end there
+	AsOf *time.Time `json:"as_of,omitempty"`
+	// Engagement reads the recent issue, pull request and discussion threads the
+	// engagement and structure scores are computed from; they are 0 without it
+	Engagement bool `json:"engagement,omitempty"`
+}
+
+// SelectionRequest is the contributor selection policy of a request. Unlike
+// models.SelectionPolicy it tells an unset Days, which keeps the default recent
+// window, from 0, which adds nobody.
+type SelectionRequest struct {
+	Strategy   string  `json:"strategy"`             // all, top, sqrt, percentile or active
+	N          int     `json:"n,omitempty"`          // Contributors kept by "top"
+	Percentile float64 `json:"percentile,omitempty"` // Percentile of the contribution counts "percentile" keeps contributors at or above
+	// Days also selects everyone who committed in the last Days days. Unset adds nobody,
+	// except for "active", whose window it is and which defaults to 90 days.
+	Days *int `json:"days,omitempty"`
+}
+
+// ThresholdOverrides replaces some of the classification thresholds for one request;
+// unset ones keep the deployment's value
+type ThresholdOverrides struct {
+	Geodispersion *float64 `json:"geodispersion,omitempty"`
+	Formality     *float64 `json:"formality,omitempty"`
+	Longevity     *float64 `json:"longevity,omitempty"`
+	Cohesion      *float64 `json:"cohesion,omitempty"`
 }
 
 const (
@@ -57,6 +101,62 @@ func resolveEligibilityParams(req ExtractRequest) (int, int, int, error) {
 	return minCommits, days, minActive, nil
 }
 
+// resolveSelection returns the contributor selection policy of a request
+func resolveSelection(req ExtractRequest) (models.SelectionPolicy, error) {
+	if req.Selection == nil {
+		return source.DefaultSelection, nil
+	}
+	p := models.SelectionPolicy{
+		Strategy:   req.Selection.Strategy,
+		N:          req.Selection.N,
+		Percentile: req.Selection.Percentile,
+	}
+	if req.Selection.Days != nil {
+		p.Days = *req.Selection.Days
+	} else if p.Strategy == source.SelectActive {
+		p.Days = source.RecentDays
+	}
+	if err := source.ValidateSelection(p); err != nil {
+		return models.SelectionPolicy{}, err
+	}
+	return p, nil
+}
+
+// resolveAsOf returns the snapshot date of a request, zero for the present
+func resolveAsOf(req ExtractRequest) (time.Time, error) {
+	if req.AsOf == nil {
+		return time.Time{}, nil
+	}
+	if req.AsOf.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("as_of must not be in the future")
+	}
+	if req.Engagement {
+		return time.Time{}, fmt.Errorf("engagement cannot be combined with as_of: threads are only available as they are now")
+	}
+	return *req.AsOf, nil
+}
+
+// resolveThresholds applies the overrides of a request to the deployment thresholds
+func resolveThresholds(defaults metrics.Thresholds, o *ThresholdOverrides) (metrics.Thresholds, error) {
+	t := defaults
+	if o != nil {
+		for _, override := range []struct {
+			value  *float64
+			target *float64
+		}{
+			{o.Geodispersion, &t.Geodispersion},
+			{o.Formality, &t.Formality},
+			{o.Longevity, &t.Longevity},
+			{o.Cohesion, &t.Cohesion},
+		} {
+			if override.value != nil {
+				*override.target = *override.value
+			}
+		}
+	}
+	return t, t.Validate()
+}
+
 // ExtractResponse represents the response containing repository information
 type ExtractResponse struct {
 	Repository models.RepositoryInfo `json:"repository"`
@@ -65,26 +165,46 @@ type ExtractResponse struct {
 
 // ExtractResponseLimits represents the response containing rate limits information
 type ExtractResponseLimits struct {
-	Remaining int    `json:"remaining"`
-	Error     string `json:"error,omitempty"`
+	Remaining int                  `json:"remaining"` // Core requests left across all tokens
+	Tokens    []github.TokenStatus `json:"tokens,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// Processor computes the metrics of an extracted repository, either through the
+// processor gRPC service (*grpcclient.ProcessorClient) or in process (*metrics.Calculator)
+type Processor interface {
+	ProcessRepository(ctx context.Context, info models.RepositoryInfo) (*grpcclient.ProcessResult, error)
 }
 
 // Handler handles HTTP requests for repository extraction
 type Handler struct {
-	service         *Service
-	logger          *logrus.Logger
-	processorClient *grpcclient.ProcessorClient
+	service   *Service
+	logger    *logrus.Logger
+	processor Processor
+	// thresholds classify repositories whose request does not override them
+	thresholds metrics.Thresholds
+	store      *store.Store
+	jobs       *JobManager
 }
 
-// NewHandler creates a new HTTP handler
-func NewHandler(service *Service, logger *logrus.Logger, processorClient *grpcclient.ProcessorClient) *Handler {
+// NewHandler creates a new HTTP handler classifying repositories with thresholds
+// unless a request overrides them
+func NewHandler(service *Service, logger *logrus.Logger, processor Processor, thresholds metrics.Thresholds, resultStore *store.Store) *Handler {
 	return &Handler{
-		service:         service,
-		logger:          logger,
-		processorClient: processorClient,
+		service:    service,
+		logger:     logger,
+		processor:  processor,
+		thresholds: thresholds,
+		store:      resultStore,
+		jobs:       NewJobManager(service, processor, resultStore, logger),
 	}
 }
 
+// Shutdown cancels the background jobs still running
+func (h *Handler) Shutdown() {
+	h.jobs.Shutdown()
+}
+
 // HealthCheckHandler handles health check requests
 // @Summary Health check
 // @Description Checks if the server is running and returns the number of cores.
@@ -100,22 +220,61 @@ func (h *Handler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetRemainingRequestsHandler handles the count of the remaining requests available
 // @Summary Get remaining requests
-// @Description Gives the number of the remaining GitHub API requests available
+// @Description Gives the number of the remaining GitHub API requests available, in total and per pooled token
 // @Tags remaining
 // @Produce json
+// @Param host query string false "GitHub or GitHub Enterprise host, defaults to github.com"
 // @Success 200 {object} ExtractResponseLimits
 // @Failure 400 {object} ExtractResponse "Invalid request"
 // @Router /remaining [get]
 func (h *Handler) GetRemainingRequestsHandler(w http.ResponseWriter, r *http.Request) {
-	gh := h.service.ghClient
-	rate, err := gh.GetRemainingRequests()
+	gh, err := h.service.GitHubClient(r.URL.Query().Get("host"))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tokens, err := gh.TokenStatus(r.Context())
 
 	if err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	remaining := 0
+	for _, t := range tokens {
+		remaining += t.Core.Remaining
 	}
 
 	h.respondWithJSON(w, http.StatusOK, ExtractResponseLimits{
-		Remaining: rate,
+		Remaining: remaining,
+		Tokens:    tokens,
+	})
+}
+
+// StatsResponse represents the response containing GitHub client statistics
+type StatsResponse struct {
+	Cache     github.CacheStats    `json:"cache"`
+	Throttled github.ThrottleStats `json:"throttled"`
+}
+
+// StatsHandler handles the GET request for the GitHub client statistics
+// @Summary Get client statistics
+// @Description Reports the response cache hits and misses and the time spent throttled by rate limits since startup.
+// @Tags remaining
+// @Produce json
+// @Param host query string false "GitHub or GitHub Enterprise host, defaults to github.com"
+// @Success 200 {object} StatsResponse
+// @Failure 400 {object} map[string]string "Unknown host"
+// @Router /stats [get]
+func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	gh, err := h.service.GitHubClient(r.URL.Query().Get("host"))
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, StatsResponse{
+		Cache:     gh.CacheStats(),
+		Throttled: gh.ThrottleStats(),
 	})
 }
 
@@ -160,12 +319,27 @@ func (h *Handler) ExtractHandler(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	selection, err := resolveSelection(req)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asOf, err := resolveAsOf(req)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx := source.WithAsOf(r.Context(), asOf)
 
-	// Use the client from the service
-	gh := h.service.ghClient
+	// Pick the source of the requested host
+	src, err := h.service.Source(req.Host)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Run eligibility checks using user-provided thresholds or defaults.
-	ok, reason, err := gh.CheckRepoEligibility(req.Owner, req.Repo, minCommits, days, minActive)
+	ok, reason, err := src.CheckRepoEligibility(ctx, req.Owner, req.Repo, minCommits, days, minActive)
 	if err != nil {
 		h.logger.Errorf("Error checking eligibility for %s/%s: %v", req.Owner, req.Repo, err)
 		http.Error(w, "internal error checking repository eligibility: "+err.Error(), http.StatusInternalServerError)
@@ -181,7 +355,7 @@ func (h *Handler) ExtractHandler(w http.ResponseWriter, r *http.Request) {
 
 	// --- existing code continues only if checks passed ---
 	// Process repository using the service (will be assigned to a free worker)
-	result := h.service.ProcessRepository(req.Owner, req.Repo)
+	result := h.service.ProcessRepository(source.WithEngagement(source.WithSelection(ctx, selection), req.Engagement), req.Host, req.Owner, req.Repo)
 
 	// Respond with JSON
 	h.respondWithJSON(w, http.StatusOK, ExtractResponse{
@@ -189,6 +363,190 @@ func (h *Handler) ExtractHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// BatchHandler handles the POST request for extracting a list of repositories
+// @Summary Start a batch extraction
+// @Description Queues the eligibility check and extraction of every repository of an uploaded CSV (multipart field "file" or text/csv body) or a JSON array of extraction requests, and returns the batch immediately. Poll GET /extract/batch/{id} for the results. CSV host, thresholds, contributor selection and snapshot date are read from the host, min_commits, days, min_active, selection, selection_n, selection_percentile, selection_days and as_of form or query values.
+// @Tags repository
+// @Accept json
+// @Accept mpfd
+// @Accept text/csv
+// @Produce json
+// @Param request body []ExtractRequest true "Repositories to extract"
+// @Success 202 {object} BatchResponse
+// @Failure 400 {object} BatchResponse "Invalid request"
+// @Failure 503 {object} BatchResponse "Too many pending jobs"
+// @Router /extract/batch [post]
+func (h *Handler) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reqs, err := parseBatchRequest(r)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, BatchResponse{Error: err.Error()})
+		return
+	}
+	if err := validateBatch(reqs); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, BatchResponse{Error: err.Error()})
+		return
+	}
+
+	batch, err := h.jobs.SubmitBatch(reqs)
+	if errors.Is(err, errTooManyJobs) {
+		w.Header().Set("Retry-After", strconv.Itoa(jobRetryAfter))
+		h.respondWithJSON(w, http.StatusServiceUnavailable, BatchResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		h.respondWithJSON(w, http.StatusInternalServerError, BatchResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Location", "/extract/batch/"+batch.ID)
+	h.respondWithJSON(w, http.StatusAccepted, batch)
+}
+
+// GetBatchHandler handles the GET request for the status of a batch extraction
+// @Summary Get batch status
+// @Description Returns the status of a batch extraction and the results of the repositories finished so far, in input order.
+// @Tags repository
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} BatchResponse
+// @Failure 404 {object} BatchResponse "Batch not found"
+// @Router /extract/batch/{id} [get]
+func (h *Handler) GetBatchHandler(w http.ResponseWriter, r *http.Request) {
+	batch, err := h.jobs.GetBatch(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithJSON(w, http.StatusNotFound, BatchResponse{Error: "batch not found"})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, batch)
+}
+
+// CancelBatchHandler handles the DELETE request for cancelling a batch extraction
+// @Summary Cancel a batch
+// @Description Cancels a queued or running batch extraction; the repositories already finished keep their results.
+// @Tags repository
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} BatchResponse
+// @Failure 404 {object} BatchResponse "Batch not found"
+// @Failure 409 {object} BatchResponse "Batch already finished"
+// @Router /extract/batch/{id} [delete]
+func (h *Handler) CancelBatchHandler(w http.ResponseWriter, r *http.Request) {
+	batch, err := h.jobs.CancelBatch(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, errJobNotFound):
+		h.respondWithJSON(w, http.StatusNotFound, BatchResponse{Error: "batch not found"})
+		return
+	case errors.Is(err, errJobFinished):
+		h.respondWithJSON(w, http.StatusConflict, BatchResponse{Error: "batch already finished"})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, batch)
+}
+
+// parseBatchRequest decodes the repositories of a batch request according to its content type
+func parseBatchRequest(r *http.Request) ([]ExtractRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, fmt.Errorf("invalid multipart form: %v", err)
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("file is required: %v", err)
+		}
+		defer file.Close()
+
+		defaults, err := batchDefaults(r)
+		if err != nil {
+			return nil, err
+		}
+		return ParseBatchCSV(file, defaults)
+
+	case "text/csv":
+		defaults, err := batchDefaults(r)
+		if err != nil {
+			return nil, err
+		}
+		return ParseBatchCSV(r.Body, defaults)
+
+	default:
+		var reqs []ExtractRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			return nil, fmt.Errorf("Invalid JSON: %v", err)
+		}
+		return reqs, nil
+	}
+}
+
+// batchDefaults reads the host, eligibility thresholds, contributor selection, snapshot date and engagement applied to every row of a CSV batch
+func batchDefaults(r *http.Request) (ExtractRequest, error) {
+	var req ExtractRequest
+	var err error
+
+	req.Host = r.FormValue("host")
+	if req.MinCommits, err = parseOptionalInt("min_commits", r.FormValue("min_commits")); err != nil {
+		return req, err
+	}
+	if req.Days, err = parseOptionalInt("days", r.FormValue("days")); err != nil {
+		return req, err
+	}
+	if req.MinActive, err = parseOptionalInt("min_active", r.FormValue("min_active")); err != nil {
+		return req, err
+	}
+	if req.Selection, err = parseSelection(r); err != nil {
+		return req, err
+	}
+	if v := r.FormValue("as_of"); v != "" {
+		asOf, err := source.ParseAsOf(v)
+		if err != nil {
+			return req, err
+		}
+		req.AsOf = &asOf
+	}
+	if v := r.FormValue("engagement"); v != "" {
+		if req.Engagement, err = strconv.ParseBool(v); err != nil {
+			return req, fmt.Errorf("engagement must be true or false")
+		}
+	}
+	return req, nil
+}
+
+// parseSelection reads a contributor selection policy from the selection, selection_n,
+// selection_percentile and selection_days form values; it is nil when selection is unset
+func parseSelection(r *http.Request) (*SelectionRequest, error) {
+	strategy := r.FormValue("selection")
+	if strategy == "" {
+		return nil, nil
+	}
+
+	p := &SelectionRequest{Strategy: strategy}
+	n, err := parseOptionalInt("selection_n", r.FormValue("selection_n"))
+	if err != nil {
+		return nil, err
+	}
+	if n != nil {
+		p.N = *n
+	}
+	if p.Days, err = parseOptionalInt("selection_days", r.FormValue("selection_days")); err != nil {
+		return nil, err
+	}
+	if v := r.FormValue("selection_percentile"); v != "" {
+		if p.Percentile, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("selection_percentile must be a number")
+		}
+	}
+	return p, nil
+}
+
 // respondWithJSON writes a JSON response
 func (h *Handler) respondWithJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -211,14 +569,41 @@ type ProcessHandlerResponse struct {
 	Geodispersion float64 `json:"geodispersion"`
 	Longevity     float64 `json:"longevity"`
 	Cohesion      float64 `json:"cohesion"`
-	SimpleProject bool    `json:"simple_project,omitempty"`
-	Category      string  `json:"category,omitempty"`
-	Error         string  `json:"error,omitempty"`
+	// Engagement and Structure are the original YOSHI dimensions the decision tree does not use
+	Engagement float64 `json:"engagement"`
+	Structure  float64 `json:"structure"`
+	// Breakdown holds the sub-components of the metrics and the inputs they used
+	Breakdown     *grpcclient.Breakdown `json:"breakdown,omitempty"`
+	SimpleProject bool                  `json:"simple_project,omitempty"`
+	Category      string                `json:"category,omitempty"`
+	// DecisionPath lists the decision tree nodes that led to Category
+	DecisionPath []metrics.DecisionStep `json:"decision_path,omitempty"`
+	Thresholds   *metrics.Thresholds    `json:"thresholds,omitempty"`  // Thresholds the repository was classified with
+	AnalysisID   string                 `json:"analysis_id,omitempty"` // ID of the stored analysis, see /analyses
+	Error        string                 `json:"error,omitempty"`
+}
+
+// newProcessResponse returns the metrics of a repository along with its community
+// category under thresholds
+func newProcessResponse(result *grpcclient.ProcessResult, thresholds metrics.Thresholds) *ProcessHandlerResponse {
+	class := metrics.Classify(result, thresholds)
+	return &ProcessHandlerResponse{
+		Formality:     result.Formality,
+		Geodispersion: result.Geodispersion,
+		Longevity:     result.Longevity,
+		Cohesion:      result.Cohesion,
+		Engagement:    result.Engagement,
+		Structure:     result.Structure,
+		Breakdown:     result.Breakdown,
+		Category:      class.Category,
+		DecisionPath:  class.Path,
+		Thresholds:    &thresholds,
+	}
 }
 
 // ProcessHandler handles the POST request for extracting and processing repository metrics
 // @Summary Process repository metrics
-// @Description Extracts repository data and computes formality, geodispersion, and longevity metrics.
+// @Description Extracts repository data, computes formality, geodispersion, longevity, cohesion, engagement and structure, classifies the community and breaks each metric down into its sub-components.
 // @Tags repository
 // @Accept json
 // @Produce json
@@ -254,15 +639,35 @@ func (h *Handler) ProcessHandler(w http.ResponseWriter, r *http.Request) {
 		h.respondWithJSON(w, http.StatusBadRequest, ProcessHandlerResponse{Error: err.Error()})
 		return
 	}
+	thresholds, err := resolveThresholds(h.thresholds, req.Thresholds)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, ProcessHandlerResponse{Error: err.Error()})
+		return
+	}
+	selection, err := resolveSelection(req)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, ProcessHandlerResponse{Error: err.Error()})
+		return
+	}
+	asOf, err := resolveAsOf(req)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, ProcessHandlerResponse{Error: err.Error()})
+		return
+	}
+	ctx := source.WithAsOf(r.Context(), asOf)
 
-	if h.processorClient == nil {
+	if h.processor == nil {
 		h.respondWithJSON(w, http.StatusInternalServerError, ProcessHandlerResponse{Error: "processor service not configured"})
 		return
 	}
 
-	gh := h.service.ghClient
+	src, err := h.service.Source(req.Host)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, ProcessHandlerResponse{Error: err.Error()})
+		return
+	}
 
-	ok, reason, err := gh.CheckRepoEligibility(req.Owner, req.Repo, minCommits, days, minActive)
+	ok, reason, err := src.CheckRepoEligibility(ctx, req.Owner, req.Repo, minCommits, days, minActive)
 	if err != nil {
 		h.logger.Errorf("Error checking eligibility for %s/%s: %v", req.Owner, req.Repo, err)
 		h.respondWithJSON(w, http.StatusInternalServerError, ProcessHandlerResponse{Error: "internal error checking repository eligibility: " + err.Error()})
@@ -272,33 +677,465 @@ func (h *Handler) ProcessHandler(w http.ResponseWriter, r *http.Request) {
 		h.logger.Infof("Repository %s/%s not eligible: %s", req.Owner, req.Repo, reason)
 		h.respondWithJSON(w, http.StatusOK, ProcessHandlerResponse{
 			SimpleProject: true,
-			Category:      "Simple Project (SP)",
+			Category:      metrics.SimpleProject,
 		})
 		return
 	}
 
 	// Extract repository info (same as /extract)
-	repoInfo := h.service.ProcessRepository(req.Owner, req.Repo)
+	repoInfo := h.service.ProcessRepository(source.WithEngagement(source.WithSelection(ctx, selection), req.Engagement), req.Host, req.Owner, req.Repo)
 	if repoInfo.Error != "" {
 		h.respondWithJSON(w, http.StatusInternalServerError, ProcessHandlerResponse{Error: fmt.Sprintf("extraction failed: %s", repoInfo.Error)})
 		return
 	}
 
-	// Convert to proto message
-	repoProto := pb.RepositoryInfoToProto(repoInfo)
-
-	// Call gRPC ProcessorService
-	metrics, err := h.processorClient.Process(r.Context(), repoProto)
+	// Compute the metrics
+	result, err := h.processor.ProcessRepository(r.Context(), repoInfo)
 	if err != nil {
-		h.logger.Errorf("gRPC Process failed for %s/%s: %v", req.Owner, req.Repo, err)
+		h.logger.Errorf("Processing failed for %s/%s: %v", req.Owner, req.Repo, err)
 		h.respondWithJSON(w, http.StatusInternalServerError, ProcessHandlerResponse{Error: fmt.Sprintf("processing failed: %v", err)})
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, ProcessHandlerResponse{
-		Formality:     metrics.Formality,
-		Geodispersion: metrics.Geodispersion,
-		Longevity:     metrics.Longevity,
-		Cohesion:      metrics.Cohesion,
+	resp := newProcessResponse(result, thresholds)
+	resp.AnalysisID = recordAnalysis(h.store, h.logger, req, EligibilityParams{
+		MinCommits: minCommits,
+		Days:       days,
+		MinActive:  minActive,
+	}, thresholds, repoInfo, result)
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+// EvolutionHandler handles the POST request for the evolution of repository metrics
+// @Summary Community evolution
+// @Description Extracts a repository once, then computes formality, geodispersion, longevity and cohesion and classifies the community at the end of every month, quarter, half or year since its first commit, from the weekly contributor statistics, pull requests and contributors active by each date.
+// @Tags repository
+// @Accept json
+// @Produce json
+// @Param request body EvolutionRequest true "Repository evolution request"
+// @Success 200 {object} EvolutionResponse
+// @Failure 400 {object} EvolutionResponse "Invalid request"
+// @Failure 500 {object} EvolutionResponse "Internal server error"
+// @Router /evolution [post]
+func (h *Handler) EvolutionHandler(w http.ResponseWriter, r *http.Request) {
+	var req EvolutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	if req.Owner == "" {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: "owner is required"})
+		return
+	}
+	if req.Repo == "" {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: "repo is required"})
+		return
+	}
+
+	minCommits, days, minActive, err := resolveEligibilityParams(req.ExtractRequest)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	thresholds, err := resolveThresholds(h.thresholds, req.Thresholds)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	selection, err := resolveSelection(req.ExtractRequest)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	asOf, err := resolveAsOf(req.ExtractRequest)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	interval, months, err := resolveInterval(req.Interval)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	if req.Engagement {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: "engagement is not available over time: threads are only available as they are now"})
+		return
+	}
+	ctx := source.WithAsOf(r.Context(), asOf)
+
+	if h.processor == nil {
+		h.respondWithJSON(w, http.StatusInternalServerError, EvolutionResponse{Error: "processor service not configured"})
+		return
+	}
+
+	src, err := h.service.Source(req.Host)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+
+	ok, reason, err := src.CheckRepoEligibility(ctx, req.Owner, req.Repo, minCommits, days, minActive)
+	if err != nil {
+		h.logger.Errorf("Error checking eligibility for %s/%s: %v", req.Owner, req.Repo, err)
+		h.respondWithJSON(w, http.StatusInternalServerError, EvolutionResponse{Error: "internal error checking repository eligibility: " + err.Error()})
+		return
+	}
+	if !ok {
+		h.logger.Infof("Repository %s/%s not eligible: %s", req.Owner, req.Repo, reason)
+		h.respondWithJSON(w, http.StatusOK, EvolutionResponse{Owner: req.Owner, Repo: req.Repo, SimpleProject: true})
+		return
+	}
+
+	// One extraction serves every point
+	repoInfo := h.service.ProcessRepository(source.WithSelection(ctx, selection), req.Host, req.Owner, req.Repo)
+	if repoInfo.Error != "" {
+		h.respondWithJSON(w, http.StatusInternalServerError, EvolutionResponse{Error: fmt.Sprintf("extraction failed: %s", repoInfo.Error)})
+		return
+	}
+
+	dates, err := evolutionDates(evolutionStart(repoInfo), source.Now(ctx), months)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, EvolutionResponse{Error: err.Error()})
+		return
+	}
+	points, err := evolve(ctx, h.processor, repoInfo, dates, thresholds)
+	if err != nil {
+		h.logger.Errorf("Evolution failed for %s/%s: %v", req.Owner, req.Repo, err)
+		h.respondWithJSON(w, http.StatusInternalServerError, EvolutionResponse{Error: fmt.Sprintf("processing failed: %v", err)})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, EvolutionResponse{
+		Owner:      req.Owner,
+		Repo:       req.Repo,
+		Interval:   interval,
+		Points:     points,
+		Thresholds: &thresholds,
 	})
 }
+
+// CreateJobHandler handles the POST request for starting an asynchronous process job
+// @Summary Start a process job
+// @Description Queues the eligibility check, extraction and metrics processing of a repository and returns the job immediately.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body ExtractRequest true "Repository process request"
+// @Success 202 {object} Job
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Too many pending jobs"
+// @Router /jobs [post]
+func (h *Handler) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req ExtractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	if req.Owner == "" {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": "owner is required"})
+		return
+	}
+	if req.Repo == "" {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": "repo is required"})
+		return
+	}
+
+	minCommits, days, minActive, err := resolveEligibilityParams(req)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	thresholds, err := resolveThresholds(h.thresholds, req.Thresholds)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	selection, err := resolveSelection(req)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	asOf, err := resolveAsOf(req)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, err := h.service.Source(req.Host); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if h.processor == nil {
+		h.respondWithJSON(w, http.StatusInternalServerError, map[string]string{"error": "processor service not configured"})
+		return
+	}
+
+	job, err := h.jobs.Submit(req, EligibilityParams{MinCommits: minCommits, Days: days, MinActive: minActive}, selection, asOf, thresholds)
+	if errors.Is(err, errTooManyJobs) {
+		w.Header().Set("Retry-After", strconv.Itoa(jobRetryAfter))
+		h.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.respondWithJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	h.respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// GetJobHandler handles the GET request for the status of a job
+// @Summary Get job status
+// @Description Returns the status, stage, progress and, once completed, the result of a job.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} Job
+// @Failure 404 {object} map[string]string "Job not found"
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, job)
+}
+
+// CancelJobHandler handles the DELETE request for cancelling a job
+// @Summary Cancel a job
+// @Description Cancels a queued or running job.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} Job
+// @Failure 404 {object} map[string]string "Job not found"
+// @Failure 409 {object} map[string]string "Job already finished"
+// @Router /jobs/{id} [delete]
+func (h *Handler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Cancel(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, errJobNotFound):
+		h.respondWithJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, errJobFinished):
+		h.respondWithJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, job)
+}
+
+// JobEventsHandler streams the progress of a job as server-sent events
+// @Summary Stream job progress
+// @Description Streams the job progress as server-sent events. Each "progress" event carries a JobEvent; the final "done" event carries the finished job.
+// @Tags jobs
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Success 200 {object} JobEvent
+// @Failure 404 {object} map[string]string "Job not found"
+// @Router /jobs/{id}/events [get]
+func (h *Handler) JobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	events, job, updated, err := h.jobs.Events(id, 0)
+	if err != nil {
+		h.respondWithJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Streams may outlive the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	next := 0
+	for {
+		for _, ev := range events {
+			h.writeEvent(w, "progress", ev)
+		}
+		next += len(events)
+
+		if job.Status.Finished() {
+			h.writeEvent(w, "done", job)
+			_ = rc.Flush()
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			h.logger.Errorf("Streaming not supported for job %s: %v", id, err)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-updated:
+		}
+
+		events, job, updated, err = h.jobs.Events(id, next)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes a single server-sent event with a JSON payload
+func (h *Handler) writeEvent(w http.ResponseWriter, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.Errorf("Error encoding %s event: %v", event, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// ListAnalysesHandler handles the GET request for the stored analyses of a repository
+// @Summary List past analyses
+// @Description Lists the stored analyses of a repository, newest first, without the repository snapshots.
+// @Tags analyses
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param repo path string true "Repository name"
+// @Param host query string false "Repository host, defaults to github.com"
+// @Success 200 {array} store.AnalysisSummary
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /analyses/{owner}/{repo} [get]
+func (h *Handler) ListAnalysesHandler(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		h.respondWithStoreError(w, errStoreNotConfigured)
+		return
+	}
+
+	vars := mux.Vars(r)
+	analyses, err := h.store.List(source.NormalizeHost(r.URL.Query().Get("host")), vars["owner"], vars["repo"])
+	if err != nil {
+		h.logger.Errorf("Error listing analyses for %s/%s: %v", vars["owner"], vars["repo"], err)
+		h.respondWithStoreError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, analyses)
+}
+
+// GetAnalysisHandler handles the GET request for a single stored analysis
+// @Summary Get a past analysis
+// @Description Returns a stored analysis with its repository snapshot, metrics and parameters.
+// @Tags analyses
+// @Produce json
+// @Param owner path string true "Repository owner"
+// @Param repo path string true "Repository name"
+// @Param host query string false "Repository host, defaults to github.com"
+// @Param id path string true "Analysis ID"
+// @Success 200 {object} store.Analysis
+// @Failure 404 {object} map[string]string "Analysis not found"
+// @Router /analyses/{owner}/{repo}/{id} [get]
+func (h *Handler) GetAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	analysis, err := h.getAnalysis(r)
+	if err != nil {
+		h.respondWithStoreError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, analysis)
+}
+
+// AnalysisGraphHandler handles the GET request for the follow graph of a stored analysis
+// @Summary Export the follow graph of a past analysis
+// @Description Exports who follows whom among the selected contributors as GraphML, GEXF or DOT. Nodes carry the name, company and location of contributors whose profile has them.
+// @Tags analyses
+// @Produce xml
+// @Produce plain
+// @Param owner path string true "Repository owner"
+// @Param repo path string true "Repository name"
+// @Param host query string false "Repository host, defaults to github.com"
+// @Param id path string true "Analysis ID"
+// @Param format query string false "graphml (default), gexf or dot"
+// @Success 200 {string} string "Graph file"
+// @Failure 400 {object} map[string]string "Unknown format"
+// @Failure 404 {object} map[string]string "Analysis not found"
+// @Router /analyses/{owner}/{repo}/{id}/graph [get]
+func (h *Handler) AnalysisGraphHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := graph.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	analysis, err := h.getAnalysis(r)
+	if err != nil {
+		h.respondWithStoreError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := graph.Write(&buf, format, analysis.Repository); err != nil {
+		h.logger.Errorf("Error exporting the follow graph of analysis %s: %v", analysis.ID, err)
+		h.respondWithJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s-%s.%s", analysis.Owner, analysis.Repo, analysis.ID, format)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// DeleteAnalysisHandler handles the DELETE request for a single stored analysis
+// @Summary Delete a past analysis
+// @Tags analyses
+// @Param owner path string true "Repository owner"
+// @Param repo path string true "Repository name"
+// @Param host query string false "Repository host, defaults to github.com"
+// @Param id path string true "Analysis ID"
+// @Success 204
+// @Failure 404 {object} map[string]string "Analysis not found"
+// @Router /analyses/{owner}/{repo}/{id} [delete]
+func (h *Handler) DeleteAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		h.respondWithStoreError(w, errStoreNotConfigured)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := h.store.Delete(source.NormalizeHost(r.URL.Query().Get("host")), vars["owner"], vars["repo"], vars["id"]); err != nil {
+		h.respondWithStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var errStoreNotConfigured = errors.New("result store not configured")
+
+// getAnalysis loads the analysis addressed by the request path
+func (h *Handler) getAnalysis(r *http.Request) (*store.Analysis, error) {
+	if h.store == nil {
+		return nil, errStoreNotConfigured
+	}
+	vars := mux.Vars(r)
+	return h.store.Get(source.NormalizeHost(r.URL.Query().Get("host")), vars["owner"], vars["repo"], vars["id"])
+}
+
+// respondWithStoreError maps a store error to the matching HTTP status
+func (h *Handler) respondWithStoreError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, store.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	h.respondWithJSON(w, status, map[string]string{"error": err.Error()})
+}
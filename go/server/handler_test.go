@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+func TestResolveSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    models.SelectionPolicy
+		wantErr string
+	}{
+		{
+			name: "no selection",
+			body: `{}`,
+			want: source.DefaultSelection,
+		},
+		{
+			name: "days unset adds nobody",
+			body: `{"selection":{"strategy":"top","n":10}}`,
+			want: models.SelectionPolicy{Strategy: source.SelectTop, N: 10},
+		},
+		{
+			name: "days set adds the recent contributors",
+			body: `{"selection":{"strategy":"sqrt","days":30}}`,
+			want: models.SelectionPolicy{Strategy: source.SelectSqrt, Days: 30},
+		},
+		{
+			name: "days 0 adds nobody",
+			body: `{"selection":{"strategy":"top","n":5,"days":0}}`,
+			want: models.SelectionPolicy{Strategy: source.SelectTop, N: 5},
+		},
+		{
+			name: "active defaults to the recent window",
+			body: `{"selection":{"strategy":"active"}}`,
+			want: models.SelectionPolicy{Strategy: source.SelectActive, Days: source.RecentDays},
+		},
+		{
+			name:    "active with days 0",
+			body:    `{"selection":{"strategy":"active","days":0}}`,
+			wantErr: "days must be greater than 0",
+		},
+		{
+			name:    "unknown strategy",
+			body:    `{"selection":{"strategy":"random"}}`,
+			wantErr: "unknown selection strategy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req ExtractRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveSelection(req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("policy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAsOf(t *testing.T) {
+	past := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		req     ExtractRequest
+		want    time.Time
+		wantErr string
+	}{
+		{"present", ExtractRequest{}, time.Time{}, ""},
+		{"present with engagement", ExtractRequest{Engagement: true}, time.Time{}, ""},
+		{"past", ExtractRequest{AsOf: &past}, past, ""},
+		{"future", ExtractRequest{AsOf: &future}, time.Time{}, "as_of must not be in the future"},
+		{"past with engagement", ExtractRequest{AsOf: &past, Engagement: true}, time.Time{}, "engagement cannot be combined with as_of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAsOf(tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("got %s, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+}
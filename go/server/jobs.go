@@ -0,0 +1,377 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/metrics"
+	"github-extractor/models"
+	"github-extractor/source"
+	"github-extractor/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Stages a job moves through
+const (
+	StageEligibility = "eligibility"
+	StageScheduling  = "scheduling"
+	StageExtraction  = "extraction"
+	StageProcessing  = "processing"
+)
+
+// jobStages lists the stages in execution order, used to compute job progress
+var jobStages = []string{StageEligibility, StageScheduling, StageExtraction, StageProcessing}
+
+// stageMessages describes each stage in the job event stream
+var stageMessages = map[string]string{
+	StageEligibility: "checking eligibility",
+	StageScheduling:  "estimating API cost and waiting for quota",
+	StageExtraction:  "extracting repository data",
+	StageProcessing:  "gRPC processing",
+}
+
+// jobRetention is how long finished jobs are kept before being pruned
+const jobRetention = time.Hour
+
+// maxPendingJobs caps the jobs that are queued or running at once; further
+// submissions are refused until some finish
+const maxPendingJobs = 500
+
+// jobRetryAfter is the number of seconds clients are told to wait when the job
+// queue is full
+const jobRetryAfter = 30
+
+// JobStatus describes the lifecycle state of a job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Finished reports whether the status is terminal
+func (s JobStatus) Finished() bool {
+	return s == JobCompleted || s == JobFailed || s == JobCancelled
+}
+
+// Job is an asynchronous process request tracked by the JobManager
+type Job struct {
+	ID         string                  `json:"id"`
+	Host       string                  `json:"host,omitempty"`
+	Owner      string                  `json:"owner"`
+	Repo       string                  `json:"repo"`
+	Status     JobStatus               `json:"status"`
+	Stage      string                  `json:"stage,omitempty"`
+	Progress   float64                 `json:"progress"` // Fraction of completed stages, from 0 to 1
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Result     *ProcessHandlerResponse `json:"result,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+
+	cancel  context.CancelFunc
+	events  []JobEvent
+	updated chan struct{} // Closed and replaced whenever the job changes
+}
+
+// JobEvent is a progress event recorded while a job runs
+type JobEvent struct {
+	Time    time.Time `json:"time"`
+	Stage   string    `json:"stage"`
+	Message string    `json:"message"`
+	Current int       `json:"current,omitempty"`
+	Total   int       `json:"total,omitempty"`
+}
+
+// JobManager runs process requests and batch extractions in the background on the
+// service worker pool
+type JobManager struct {
+	service   *Service
+	processor Processor
+	store     *store.Store
+	logger    *logrus.Logger
+
+	mu         sync.Mutex
+	jobs       map[string]*Job
+	batches    map[string]*BatchResponse
+	pending    int // Jobs and batches not finished yet
+	maxPending int
+}
+
+var (
+	errJobNotFound = errors.New("job not found")
+	errJobFinished = errors.New("job already finished")
+	errTooManyJobs = errors.New("too many pending jobs, retry later")
+)
+
+// NewJobManager creates a new job manager
+func NewJobManager(service *Service, processor Processor, resultStore *store.Store, logger *logrus.Logger) *JobManager {
+	return &JobManager{
+		service:    service,
+		processor:  processor,
+		store:      resultStore,
+		logger:     logger,
+		jobs:       make(map[string]*Job),
+		batches:    make(map[string]*BatchResponse),
+		maxPending: maxPendingJobs,
+	}
+}
+
+// Submit registers a new job for the request and starts it in the background. A non-zero
+// asOf extracts the repository as it was at that date. It returns errTooManyJobs when
+// maxPending jobs are already queued or running.
+func (m *JobManager) Submit(req ExtractRequest, params EligibilityParams, selection models.SelectionPolicy, asOf time.Time, thresholds metrics.Thresholds) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(source.WithEngagement(source.WithAsOf(source.WithSelection(context.Background(), selection), asOf), req.Engagement))
+	job := &Job{
+		ID:        id,
+		Host:      req.Host,
+		Owner:     req.Owner,
+		Repo:      req.Repo,
+		Status:    JobQueued,
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+		updated:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	if m.pending >= m.maxPending {
+		m.mu.Unlock()
+		cancel()
+		return Job{}, errTooManyJobs
+	}
+	m.pruneLocked()
+	m.jobs[id] = job
+	m.pending++
+	snapshot := *job
+	m.mu.Unlock()
+
+	go m.run(ctx, job, req, params, thresholds)
+
+	m.logger.Infof("Job %s queued for %s/%s", id, req.Owner, req.Repo)
+	return snapshot, nil
+}
+
+// Get returns a snapshot of the job with the given ID
+func (m *JobManager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+	return *job, nil
+}
+
+// Events returns the job events recorded from index from onwards, a snapshot of the job
+// and a channel that is closed on the next change to the job
+func (m *JobManager) Events(id string, from int) ([]JobEvent, Job, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, Job{}, nil, errJobNotFound
+	}
+	if from > len(job.events) {
+		from = len(job.events)
+	}
+	events := append([]JobEvent(nil), job.events[from:]...)
+	return events, *job, job.updated, nil
+}
+
+// Cancel stops a queued or running job
+func (m *JobManager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+	if job.Status.Finished() {
+		return *job, errJobFinished
+	}
+
+	job.cancel()
+	m.finishLocked(job, JobCancelled, nil, "job cancelled")
+	m.logger.Infof("Job %s cancelled", id)
+	return *job, nil
+}
+
+// Shutdown cancels every unfinished job and batch
+func (m *JobManager) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if !job.Status.Finished() {
+			job.cancel()
+			m.finishLocked(job, JobCancelled, nil, "server shutting down")
+		}
+	}
+	for _, batch := range m.batches {
+		if !batch.Status.Finished() {
+			batch.cancel()
+			m.finishBatchLocked(batch, JobCancelled)
+		}
+	}
+}
+
+// run executes the eligibility, extraction and processing stages of a job
+func (m *JobManager) run(ctx context.Context, job *Job, req ExtractRequest, params EligibilityParams, thresholds metrics.Thresholds) {
+	defer job.cancel()
+
+	progressCtx := github.WithProgress(ctx, func(ev github.ProgressEvent) {
+		m.addEvent(job, JobEvent{Stage: ev.Stage, Message: ev.Message, Current: ev.Current, Total: ev.Total})
+	})
+
+	res := m.service.submit(RepositoryRequest{
+		Ctx:         progressCtx,
+		Host:        job.Host,
+		Owner:       job.Owner,
+		Repo:        job.Repo,
+		Eligibility: &params,
+		OnStage:     func(stage string) { m.setStage(job, stage) },
+	})
+
+	switch {
+	case res.Err != nil:
+		m.finish(job, JobFailed, nil, res.Err.Error())
+		return
+	case !res.Eligible:
+		m.logger.Infof("Job %s: repository %s/%s not eligible: %s", job.ID, job.Owner, job.Repo, res.Reason)
+		m.finish(job, JobCompleted, &ProcessHandlerResponse{
+			SimpleProject: true,
+			Category:      metrics.SimpleProject,
+		}, "")
+		return
+	case res.Info.Error != "":
+		m.finish(job, JobFailed, nil, fmt.Sprintf("extraction failed: %s", res.Info.Error))
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	m.setStage(job, StageProcessing)
+
+	result, err := m.processor.ProcessRepository(ctx, res.Info)
+	if err != nil {
+		m.logger.Errorf("Job %s: processing failed for %s/%s: %v", job.ID, job.Owner, job.Repo, err)
+		m.finish(job, JobFailed, nil, fmt.Sprintf("processing failed: %v", err))
+		return
+	}
+
+	resp := newProcessResponse(result, thresholds)
+	resp.AnalysisID = recordAnalysis(m.store, m.logger, req, params, thresholds, res.Info, result)
+	m.finish(job, JobCompleted, resp, "")
+}
+
+// setStage marks the job as running the given stage
+func (m *JobManager) setStage(job *Job, stage string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job.Status.Finished() {
+		return
+	}
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = JobRunning
+	job.Stage = stage
+	for i, s := range jobStages {
+		if s == stage {
+			job.Progress = float64(i) / float64(len(jobStages))
+		}
+	}
+	m.addEventLocked(job, JobEvent{Stage: stage, Message: stageMessages[stage]})
+}
+
+// addEvent records a progress event for the job
+func (m *JobManager) addEvent(job *Job, ev JobEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addEventLocked(job, ev)
+}
+
+func (m *JobManager) addEventLocked(job *Job, ev JobEvent) {
+	if job.Status.Finished() {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	job.events = append(job.events, ev)
+	m.notifyLocked(job)
+}
+
+// notifyLocked wakes up everyone waiting for a change to the job
+func (m *JobManager) notifyLocked(job *Job) {
+	close(job.updated)
+	job.updated = make(chan struct{})
+}
+
+// finish records the final state of a job unless it already has one
+func (m *JobManager) finish(job *Job, status JobStatus, result *ProcessHandlerResponse, errMsg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finishLocked(job, status, result, errMsg)
+}
+
+func (m *JobManager) finishLocked(job *Job, status JobStatus, result *ProcessHandlerResponse, errMsg string) {
+	if job.Status.Finished() {
+		return
+	}
+	now := time.Now()
+	m.pending--
+	job.Status = status
+	job.FinishedAt = &now
+	job.Result = result
+	job.Error = errMsg
+	if status == JobCompleted {
+		job.Progress = 1
+	}
+	m.notifyLocked(job)
+}
+
+// pruneLocked drops finished jobs and batches older than jobRetention
+func (m *JobManager) pruneLocked() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+	for id, batch := range m.batches {
+		if batch.FinishedAt != nil && batch.FinishedAt.Before(cutoff) {
+			delete(m.batches, id)
+		}
+	}
+}
+
+// newJobID returns a random hexadecimal job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+	"github-extractor/metrics"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Jobs beyond the pending limit are refused until a pending one finishes
+func TestJobManagerPendingLimit(t *testing.T) {
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without workers, submitted jobs stay queued
+	service := &Service{sources: source.NewRegistry(client), jobQueue: make(chan RepositoryRequest), logger: logger}
+	m := NewJobManager(service, &fixedProcessor{}, nil, logger)
+	m.maxPending = 2
+	t.Cleanup(m.Shutdown)
+
+	submit := func() (Job, error) {
+		req := ExtractRequest{Owner: "acme", Repo: "gadget"}
+		return m.Submit(req, EligibilityParams{MinCommits: 1, Days: 1, MinActive: 1}, source.DefaultSelection, time.Time{}, metrics.Thresholds{})
+	}
+
+	first, err := submit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submit(); !errors.Is(err, errTooManyJobs) {
+		t.Fatalf("third job: error = %v, want errTooManyJobs", err)
+	}
+
+	if _, err := m.Cancel(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submit(); err != nil {
+		t.Errorf("job after a cancellation: %v", err)
+	}
+}
@@ -10,8 +10,21 @@ func SetupRoutes(handler *Handler) *mux.Router {
 
 	r.HandleFunc("/health", handler.HealthCheckHandler).Methods("GET")
 	r.HandleFunc("/remaining", handler.GetRemainingRequestsHandler).Methods("GET")
+	r.HandleFunc("/stats", handler.StatsHandler).Methods("GET")
 	r.HandleFunc("/extract", handler.ExtractHandler).Methods("POST")
+	r.HandleFunc("/extract/batch", handler.BatchHandler).Methods("POST")
+	r.HandleFunc("/extract/batch/{id}", handler.GetBatchHandler).Methods("GET")
+	r.HandleFunc("/extract/batch/{id}", handler.CancelBatchHandler).Methods("DELETE")
 	r.HandleFunc("/process", handler.ProcessHandler).Methods("POST")
+	r.HandleFunc("/evolution", handler.EvolutionHandler).Methods("POST")
+	r.HandleFunc("/jobs", handler.CreateJobHandler).Methods("POST")
+	r.HandleFunc("/jobs/{id}", handler.GetJobHandler).Methods("GET")
+	r.HandleFunc("/jobs/{id}", handler.CancelJobHandler).Methods("DELETE")
+	r.HandleFunc("/jobs/{id}/events", handler.JobEventsHandler).Methods("GET")
+	r.HandleFunc("/analyses/{owner}/{repo}", handler.ListAnalysesHandler).Methods("GET")
+	r.HandleFunc("/analyses/{owner}/{repo}/{id}", handler.GetAnalysisHandler).Methods("GET")
+	r.HandleFunc("/analyses/{owner}/{repo}/{id}", handler.DeleteAnalysisHandler).Methods("DELETE")
+	r.HandleFunc("/analyses/{owner}/{repo}/{id}/graph", handler.AnalysisGraphHandler).Methods("GET")
 
 	// Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
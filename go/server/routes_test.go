@@ -0,0 +1,440 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+	"github-extractor/grpcclient"
+	"github-extractor/metrics"
+	"github-extractor/source"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// gadget is a repository of alice and bob with a commit a day over the last 100 days,
+// weekly statistics over the same period and ten closed pull requests
+func gadget(now time.Time) githubtest.Repository {
+	r := githubtest.Repository{
+		Owner:     "acme",
+		Repo:      "gadget",
+		CreatedAt: now.AddDate(-1, 0, 0),
+		UpdatedAt: now,
+		Files:     []string{"README.md"},
+		Contributors: []githubtest.Contributor{
+			{Login: "alice", Contributions: 50},
+			{Login: "bob", Contributions: 50},
+		},
+	}
+
+	authors := []string{"alice", "bob"}
+	for i := 0; i < 100; i++ {
+		r.Commits = append(r.Commits, githubtest.Commit{
+			SHA:         fmt.Sprintf("%040x", i),
+			AuthorLogin: authors[i%2],
+			Date:        now.AddDate(0, 0, -i),
+		})
+	}
+
+	// bob only joins in the last 40 days
+	sunday := now.AddDate(0, 0, -int(now.Weekday())).Truncate(24 * time.Hour)
+	for i, login := range authors {
+		stats := githubtest.ContributorStats{Author: login}
+		for w := 14; w > 0; w-- {
+			if i == 1 && w > 6 {
+				continue
+			}
+			stats.Weeks = append(stats.Weeks, githubtest.Week{Start: sunday.AddDate(0, 0, -7*w), Commits: 3})
+		}
+		r.Stats = append(r.Stats, stats)
+	}
+
+	for i := 0; i < 10; i++ {
+		created := now.AddDate(0, 0, -5*i-1)
+		closed := created.Add(time.Hour)
+		r.PullRequests = append(r.PullRequests, githubtest.PullRequest{Number: i + 1, CreatedAt: created, ClosedAt: &closed, MergedAt: &closed})
+	}
+	return r
+}
+
+// newTestRouter serves the routes of a handler extracting from a githubtest.Server that
+// holds gadget, and processing with a fixedProcessor
+func newTestRouter(t *testing.T) (*mux.Router, *githubtest.Server, *fixedProcessor) {
+	t.Helper()
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	fake.AddRepository(gadget(time.Now()))
+	fake.AddUser(githubtest.User{Login: "alice", Location: "Lisbon", Follows: []string{"bob"}})
+	fake.AddUser(githubtest.User{Login: "bob", Location: "Tokyo"})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewService(source.NewRegistry(client), 2, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	processor := &fixedProcessor{result: grpcclient.ProcessResult{Formality: 0.6, Geodispersion: 0.3, Longevity: 0.5, Cohesion: 0.2}}
+	thresholds := metrics.Thresholds{Geodispersion: 0.25, Formality: 0.5, Longevity: 0.4, Cohesion: 0.4}
+	handler := NewHandler(service, logger, processor, thresholds, nil)
+	t.Cleanup(handler.Shutdown)
+	return SetupRoutes(handler), fake, processor
+}
+
+// do serves a request with a JSON body, when given, and decodes the JSON response into out
+func do(t *testing.T, router http.Handler, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, r))
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestProcessHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         map[string]interface{}
+		wantStatus   int
+		wantCategory string
+		wantError    bool
+	}{
+		{
+			name:         "eligible",
+			body:         map[string]interface{}{"owner": "acme", "repo": "gadget", "min_commits": 50, "days": 30, "min_active": 2, "selection": map[string]string{"strategy": "all"}},
+			wantStatus:   http.StatusOK,
+			wantCategory: "Formal Network (FN)",
+		},
+		{
+			name:         "not eligible",
+			body:         map[string]interface{}{"owner": "acme", "repo": "gadget", "min_commits": 500},
+			wantStatus:   http.StatusOK,
+			wantCategory: metrics.SimpleProject,
+		},
+		{
+			name:       "missing repo",
+			body:       map[string]interface{}{"owner": "acme"},
+			wantStatus: http.StatusBadRequest,
+			wantError:  true,
+		},
+		{
+			name:       "unknown repository",
+			body:       map[string]interface{}{"owner": "acme", "repo": "missing"},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, _ := newTestRouter(t)
+			var resp ProcessHandlerResponse
+			if status := do(t, router, http.MethodPost, "/process", tt.body, &resp); status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", status, tt.wantStatus, resp.Error)
+			}
+			if (resp.Error != "") != tt.wantError {
+				t.Errorf("error = %q", resp.Error)
+			}
+			if resp.Category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", resp.Category, tt.wantCategory)
+			}
+		})
+	}
+}
+
+// The processor receives the repository as the fake serves it
+func TestProcessHandlerExtracts(t *testing.T) {
+	router, fake, processor := newTestRouter(t)
+	body := map[string]interface{}{"owner": "acme", "repo": "gadget", "selection": map[string]string{"strategy": "all"}}
+	if status := do(t, router, http.MethodPost, "/process", body, nil); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	infos := processor.processed()
+	if len(infos) != 1 {
+		t.Fatalf("processor called %d times, want once", len(infos))
+	}
+	info := infos[0]
+	if info.Commits != 100 || len(info.PullRequests) != 10 || info.TotalContributorsCount != 2 || info.ContributorsWithLocationCount != 2 {
+		t.Errorf("got %d commits, %d pull requests, %d contributors, %d with a location; want 100, 10, 2 and 2",
+			info.Commits, len(info.PullRequests), info.TotalContributorsCount, info.ContributorsWithLocationCount)
+	}
+	if len(info.FollowGraph.Edges) != 1 {
+		t.Errorf("follow edges = %v, want alice following bob", info.FollowGraph.Edges)
+	}
+	if n := fake.RequestCount("/repos/acme/gadget/issues"); n != 0 {
+		t.Errorf("%d thread requests without engagement, want none", n)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	var job Job
+	body := map[string]interface{}{"owner": "acme", "repo": "gadget", "min_commits": 50}
+	if status := do(t, router, http.MethodPost, "/jobs", body, &job); status != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", status)
+	}
+	if job.ID == "" || job.Owner != "acme" || job.Repo != "gadget" {
+		t.Fatalf("job = %+v", job)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !job.Status.Finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s after 10s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if status := do(t, router, http.MethodGet, "/jobs/"+job.ID, nil, &job); status != http.StatusOK {
+			t.Fatalf("GET status = %d", status)
+		}
+	}
+	if job.Status != JobCompleted || job.Result == nil || job.Result.Category == "" {
+		t.Fatalf("job finished %s with %+v: %s", job.Status, job.Result, job.Error)
+	}
+	if job.Progress != 1 {
+		t.Errorf("progress = %v, want 1", job.Progress)
+	}
+
+	if status := do(t, router, http.MethodGet, "/jobs/unknown", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown job status = %d, want 404", status)
+	}
+	if status := do(t, router, http.MethodPost, "/jobs", map[string]string{"owner": "acme"}, nil); status != http.StatusBadRequest {
+		t.Errorf("missing repo status = %d, want 400", status)
+	}
+}
+
+// sseEvent is an event read from a server-sent event stream
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvents sends the events of stream to a channel, closed when the stream ends
+func readEvents(stream io.Reader) <-chan sseEvent {
+	events := make(chan sseEvent)
+	go func() {
+		defer close(events)
+		var ev sseEvent
+		scanner := bufio.NewScanner(stream)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.name != "" {
+					events <- ev
+				}
+				ev = sseEvent{}
+			case strings.HasPrefix(line, "event: "):
+				ev.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// The event stream follows a running job and closes once it finishes
+func TestJobEvents(t *testing.T) {
+	router, _, processor := newTestRouter(t)
+	processor.release = make(chan struct{})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	var job Job
+	body := map[string]interface{}{"owner": "acme", "repo": "gadget", "min_commits": 50}
+	if status := do(t, router, http.MethodPost, "/jobs", body, &job); status != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", status)
+	}
+
+	resp, err := http.Get(srv.URL + "/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d with content type %q, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := readEvents(resp.Body)
+	next := func() (sseEvent, bool) {
+		t.Helper()
+		select {
+		case ev, ok := <-events:
+			return ev, ok
+		case <-time.After(10 * time.Second):
+			t.Fatal("no event after 10s")
+			return sseEvent{}, false
+		}
+	}
+
+	// Progress arrives while the job runs, up to the processing it is held at
+	var stages []string
+	for {
+		ev, ok := next()
+		if !ok || ev.name != "progress" {
+			t.Fatalf("event %q %s before processing, want progress", ev.name, ev.data)
+		}
+		var progress JobEvent
+		if err := json.Unmarshal([]byte(ev.data), &progress); err != nil {
+			t.Fatalf("decode %q: %v", ev.data, err)
+		}
+		stages = append(stages, progress.Stage)
+		if progress.Stage == StageProcessing {
+			break
+		}
+	}
+	if stages[0] != StageEligibility {
+		t.Errorf("stages %v, want eligibility first", stages)
+	}
+
+	close(processor.release)
+	ev, ok := next()
+	for ok && ev.name == "progress" {
+		ev, ok = next()
+	}
+	if !ok || ev.name != "done" {
+		t.Fatalf("event %q after processing, want done", ev.name)
+	}
+	var done Job
+	if err := json.Unmarshal([]byte(ev.data), &done); err != nil {
+		t.Fatalf("decode %q: %v", ev.data, err)
+	}
+	if done.ID != job.ID || done.Status != JobCompleted || done.Result == nil {
+		t.Errorf("done = %+v, want the completed job", done)
+	}
+	if ev, ok := next(); ok {
+		t.Errorf("event %q after done, want the stream closed", ev.name)
+	}
+
+	resp, err = http.Get(srv.URL + "/jobs/unknown/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown job status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	var batch BatchResponse
+	body := []map[string]interface{}{
+		{"owner": "acme", "repo": "gadget", "min_commits": 50},
+		{"owner": "acme", "repo": "gadget", "min_commits": 500},
+		{"owner": "acme", "repo": "gadget", "min_commits": -1},
+	}
+	if status := do(t, router, http.MethodPost, "/extract/batch", body, &batch); status != http.StatusAccepted {
+		t.Fatalf("status = %d (%s), want 202", status, batch.Error)
+	}
+	if batch.ID == "" || batch.Total != 3 || len(batch.Results) != 3 {
+		t.Fatalf("batch = %+v", batch)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !batch.Status.Finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("batch still %s after 10s", batch.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if status := do(t, router, http.MethodGet, "/extract/batch/"+batch.ID, nil, &batch); status != http.StatusOK {
+			t.Fatalf("GET status = %d", status)
+		}
+	}
+	if batch.Status != JobCompleted || batch.Done != 3 || batch.Eligible != 1 || batch.Failed != 1 {
+		t.Fatalf("batch finished %s with %d done, %d eligible and %d failed; want completed, 3, 1 and 1",
+			batch.Status, batch.Done, batch.Eligible, batch.Failed)
+	}
+	if r := batch.Results[0]; !r.Eligible || r.Repository == nil || r.Repository.Commits != 100 {
+		t.Errorf("first result = %+v, want the extracted gadget", r)
+	}
+	if r := batch.Results[1]; r.Eligible || r.Reason == "" {
+		t.Errorf("second result = %+v, want a reason it is not eligible", r)
+	}
+	if r := batch.Results[2]; r.Error == "" {
+		t.Errorf("third result = %+v, want an invalid min_commits error", r)
+	}
+
+	if status := do(t, router, http.MethodDelete, "/extract/batch/"+batch.ID, nil, nil); status != http.StatusConflict {
+		t.Errorf("cancel of a finished batch: status = %d, want 409", status)
+	}
+	if status := do(t, router, http.MethodGet, "/extract/batch/unknown", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown batch status = %d, want 404", status)
+	}
+}
+
+func TestEvolutionHandler(t *testing.T) {
+	router, _, processor := newTestRouter(t)
+
+	var resp EvolutionResponse
+	body := map[string]interface{}{"owner": "acme", "repo": "gadget", "interval": "month", "selection": map[string]string{"strategy": "all"}}
+	if status := do(t, router, http.MethodPost, "/evolution", body, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d (%s)", status, resp.Error)
+	}
+	if resp.Interval != "month" || resp.Thresholds == nil {
+		t.Errorf("interval %q, thresholds %v", resp.Interval, resp.Thresholds)
+	}
+	if len(resp.Points) < 3 || len(resp.Points) > 5 {
+		t.Fatalf("%d points over 14 weeks of months, want 3 to 5", len(resp.Points))
+	}
+	if got := len(processor.processed()); got != len(resp.Points) {
+		t.Errorf("processor called %d times for %d points", got, len(resp.Points))
+	}
+
+	first, last := resp.Points[0], resp.Points[len(resp.Points)-1]
+	if last.Commits != 3*14+3*6 || last.Contributors != 2 || last.PullRequests != 10 {
+		t.Errorf("last point = %+v, want 60 commits, 2 contributors and 10 pull requests", last)
+	}
+	if first.Commits >= last.Commits {
+		t.Errorf("first point has %d commits, want fewer than the last %d", first.Commits, last.Commits)
+	}
+	for i, p := range resp.Points {
+		if p.Incomplete || p.Longevity == nil || p.Category == nil {
+			t.Errorf("point %d = %+v, want a complete point", i, p)
+		}
+		if i > 0 && !p.AsOf.After(resp.Points[i-1].AsOf) {
+			t.Errorf("point %d is not after the previous one", i)
+		}
+	}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"unknown interval", map[string]interface{}{"owner": "acme", "repo": "gadget", "interval": "week"}},
+		{"engagement", map[string]interface{}{"owner": "acme", "repo": "gadget", "engagement": true}},
+	}
+	for _, tt := range tests {
+		var resp EvolutionResponse
+		if status := do(t, router, http.MethodPost, "/evolution", tt.body, &resp); status != http.StatusBadRequest || resp.Error == "" {
+			t.Errorf("%s: status %d, error %q; want a 400", tt.name, status, resp.Error)
+		}
+	}
+}
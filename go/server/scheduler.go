@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github-extractor/github"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fallbackCost is assumed for repositories whose size could not be probed
+var fallbackCost = github.Cost{Core: 500, Search: 10}
+
+// quotaPollInterval is how often a waiting job rechecks the quota when GitHub
+// did not report a reset time
+const quotaPollInterval = 30 * time.Second
+
+// Scheduler admits extractions only when the token pool has enough quota left
+// for their estimated cost, so jobs wait for the rate limit to reset instead of
+// failing halfway through.
+//
+// Admitted jobs keep their whole estimate reserved until they finish, even
+// though GitHub already counts the requests they have made; the budget is
+// therefore conservative while jobs run.
+type Scheduler struct {
+	ghClient *github.Client
+	logger   *logrus.Logger
+
+	mu       sync.Mutex
+	reserved map[string]int // Requests reserved by running jobs, per rate limit resource
+	released chan struct{}  // Closed and replaced whenever a reservation is released
+}
+
+// NewScheduler creates a scheduler drawing on the quota of ghClient's token pool
+func NewScheduler(ghClient *github.Client, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		ghClient: ghClient,
+		logger:   logger,
+		reserved: make(map[string]int),
+		released: make(chan struct{}),
+	}
+}
+
+// Estimate returns the expected cost of extracting a repository, falling back to
+// a default estimate when the repository cannot be probed
+func (s *Scheduler) Estimate(ctx context.Context, owner, repo string) github.Cost {
+	cost, err := s.ghClient.EstimateCost(ctx, owner, repo)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Warnf("Could not estimate the cost of %s/%s, assuming %d requests: %v", owner, repo, fallbackCost.Core, err)
+		}
+		return fallbackCost
+	}
+	return cost
+}
+
+// Admit blocks until the quota left, minus what running jobs have reserved, covers
+// cost, then reserves it. The returned function releases the reservation and must be
+// called when the extraction ends. Costs larger than a whole quota window are capped
+// to the limit so they can still run after a reset.
+func (s *Scheduler) Admit(ctx context.Context, cost github.Cost) (func(), error) {
+	demand := map[string]int{
+		github.ResourceCore:    cost.Core,
+		github.ResourceSearch:  cost.Search,
+		github.ResourceGraphQL: cost.GraphQL,
+	}
+
+	for {
+		quotas := make(map[string]github.Quota, len(demand))
+		for resource, n := range demand {
+			if n <= 0 {
+				continue
+			}
+			q, err := s.ghClient.Quota(ctx, resource)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				// Without quota information the request is admitted; the
+				// transport still rotates tokens on rate limit errors.
+				s.logger.Warnf("Could not read the %s quota, admitting job: %v", resource, err)
+				continue
+			}
+			quotas[resource] = q
+		}
+
+		// The same capped demand is checked, reserved and released
+		need := capDemand(demand, quotas)
+
+		s.mu.Lock()
+		resource, wait := s.shortfallLocked(need, quotas)
+		if resource == "" {
+			for r, n := range need {
+				s.reserved[r] += n
+			}
+			s.mu.Unlock()
+			return func() { s.release(need) }, nil
+		}
+		released := s.released
+		q := quotas[resource]
+		available := q.Remaining - s.reserved[resource]
+		s.mu.Unlock()
+
+		if q.Reset.IsZero() {
+			github.ReportProgress(ctx, github.ProgressQuota, "waiting for %d %s requests, %d available",
+				need[resource], resource, available)
+		} else {
+			github.ReportProgress(ctx, github.ProgressQuota, "waiting for %d %s requests, %d available until %s",
+				need[resource], resource, available, q.Reset.Format(time.RFC3339))
+		}
+		s.logger.Debugf("Job waiting %s for %d %s requests (%d available)", wait, need[resource], resource, available)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-released:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// capDemand caps the demand for every resource with a known quota to the limit of one
+// quota window, so that larger jobs can still run after a reset
+func capDemand(demand map[string]int, quotas map[string]github.Quota) map[string]int {
+	capped := make(map[string]int, len(demand))
+	for resource, n := range demand {
+		if q, ok := quotas[resource]; ok && n > q.Limit {
+			n = q.Limit
+		}
+		capped[resource] = n
+	}
+	return capped
+}
+
+// shortfallLocked returns the first resource whose quota does not cover demand and how
+// long to wait before checking again, or an empty resource when the demand fits
+func (s *Scheduler) shortfallLocked(demand map[string]int, quotas map[string]github.Quota) (string, time.Duration) {
+	for resource, q := range quotas {
+		if q.Remaining-s.reserved[resource] >= demand[resource] {
+			continue
+		}
+
+		wait := quotaPollInterval
+		if until := time.Until(q.Reset); until > 0 {
+			wait = until + time.Second
+		}
+		return resource, wait
+	}
+	return "", 0
+}
+
+// release returns a reservation and wakes the waiting jobs
+func (s *Scheduler) release(demand map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for r, n := range demand {
+		s.reserved[r] -= n
+	}
+	close(s.released)
+	s.released = make(chan struct{})
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewScheduler(client, logger)
+}
+
+// Demand above a whole quota window is capped to the limit, and the capped amount is
+// what gets reserved and released
+func TestSchedulerReservesCappedDemand(t *testing.T) {
+	s := newTestScheduler(t)
+	release, err := s.Admit(context.Background(), github.Cost{Core: 3 * githubtest.RateLimit, Search: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.reserved[github.ResourceCore]; got != githubtest.RateLimit {
+		t.Errorf("reserved core = %d, want the limit %d", got, githubtest.RateLimit)
+	}
+	if got := s.reserved[github.ResourceSearch]; got != 10 {
+		t.Errorf("reserved search = %d, want 10", got)
+	}
+
+	// The whole window is reserved, so another job waits
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Admit(ctx, github.Cost{Core: 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second job admitted with err %v, want it to wait", err)
+	}
+
+	release()
+	for resource, n := range s.reserved {
+		if n != 0 {
+			t.Errorf("%s reservation = %d after release, want 0", resource, n)
+		}
+	}
+}
+
+func TestSchedulerAdmitsAfterRelease(t *testing.T) {
+	s := newTestScheduler(t)
+	release, err := s.Admit(context.Background(), github.Cost{Core: githubtest.RateLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		release, err := s.Admit(context.Background(), github.Cost{Core: 100})
+		if err == nil {
+			release()
+		}
+		admitted <- err
+	}()
+
+	select {
+	case err := <-admitted:
+		t.Fatalf("second job admitted before the release: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second job still waiting after the release")
+	}
+}
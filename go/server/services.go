@@ -1,44 +1,115 @@
 package server
 
 import (
-	"runtime"
+	"context"
+	"fmt"
+	"strings"
 
 	"github-extractor/github"
 	"github-extractor/models"
+	"github-extractor/source"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Service handles business logic for repository extraction
 type Service struct {
-	ghClient *github.Client
-	jobQueue chan RepositoryRequest
-	workers  int
-	logger   *logrus.Logger
+	sources    *source.Registry
+	schedulers map[string]*Scheduler // Quota schedulers of the GitHub hosts, by host
+	jobQueue   chan RepositoryRequest
+	workers    int
+	logger     *logrus.Logger
+}
+
+// EligibilityParams holds the thresholds passed to CheckRepoEligibility
+type EligibilityParams struct {
+	MinCommits int
+	Days       int
+	MinActive  int
 }
 
 // RepositoryRequest represents a single repository extraction request
 type RepositoryRequest struct {
-	Owner      string
-	Repo       string
-	ResultChan chan models.RepositoryInfo
+	// Ctx is passed to every GitHub call of the request; cancelling it stops them
+	Ctx context.Context
+	// Host selects the source the repository is extracted from; empty means source.DefaultHost
+	Host  string
+	Owner string
+	Repo  string
+	// Eligibility, when set, makes the worker run the eligibility checks first
+	// and skip the extraction for repositories that do not pass them.
+	Eligibility *EligibilityParams
+	// OnStage, when set, is called as the worker enters each stage
+	OnStage    func(stage string)
+	ResultChan chan RepositoryResult
+
+	stage string // Stage the worker runs: StageEligibility or StageExtraction
 }
 
-// NewService creates a new service with a worker pool
-func NewService(ghClient *github.Client, logger *logrus.Logger) *Service {
-	numWorkers := runtime.NumCPU()
+// RepositoryResult is the outcome of a RepositoryRequest
+type RepositoryResult struct {
+	Info     models.RepositoryInfo
+	Eligible bool
+	Reason   string // Why the repository is not eligible
+	Err      error  // Set when the eligibility checks failed or the request was cancelled
+}
+
+// NewService creates a new service extracting from the given sources with a pool of
+// numWorkers workers. Extractions are I/O-bound, so how many GitHub extractions run at
+// once is ultimately decided by the scheduler based on the remaining quota; the pool
+// only caps the concurrency. Requests wait for the scheduler before they take a worker,
+// so a GitHub host short of quota never holds workers other hosts could use. The
+// default host must be served by a GitHub client.
+func NewService(sources *source.Registry, numWorkers int, logger *logrus.Logger) (*Service, error) {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	def, err := sources.Get(source.DefaultHost)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := def.(*github.Client); !ok {
+		return nil, fmt.Errorf("source for %s is not a GitHub client", source.DefaultHost)
+	}
+
 	service := &Service{
-		ghClient: ghClient,
-		jobQueue: make(chan RepositoryRequest, 100), // Buffer for incoming requests
-		workers:  numWorkers,
-		logger:   logger,
+		sources:    sources,
+		schedulers: make(map[string]*Scheduler),
+		jobQueue:   make(chan RepositoryRequest, 100), // Buffer for incoming requests
+		workers:    numWorkers,
+		logger:     logger,
+	}
+	for _, host := range sources.Hosts() {
+		src, _ := sources.Get(host)
+		// Replayed extractions spend no quota
+		if gh, ok := src.(*github.Client); ok && !gh.Replaying() {
+			service.schedulers[host] = NewScheduler(gh, logger)
+		}
 	}
 
 	// Start worker pool
 	service.startWorkers()
 
-	logger.Infof("Service initialized with %d workers", numWorkers)
-	return service
+	logger.Infof("Service initialized with %d workers for %s", numWorkers, strings.Join(sources.Hosts(), ", "))
+	return service, nil
+}
+
+// Source returns the source serving host; an empty host selects source.DefaultHost
+func (s *Service) Source(host string) (source.RepositorySource, error) {
+	return s.sources.Get(host)
+}
+
+// GitHubClient returns the GitHub or GitHub Enterprise client serving host
+func (s *Service) GitHubClient(host string) (*github.Client, error) {
+	src, err := s.sources.Get(host)
+	if err != nil {
+		return nil, err
+	}
+	gh, ok := src.(*github.Client)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a GitHub host", src.Host())
+	}
+	return gh, nil
 }
 
 // startWorkers initializes the worker pool
@@ -48,27 +119,99 @@ func (s *Service) startWorkers() {
 	}
 }
 
-// worker processes repository extraction requests
+// worker runs the eligibility checks or the extraction of repository requests
 func (s *Service) worker(id int) {
 	for req := range s.jobQueue {
-		s.logger.Debugf("[Worker %d] Processing %s/%s", id, req.Owner, req.Repo)
-		info := s.ghClient.GetRepositoryInfo(req.Owner, req.Repo)
-		req.ResultChan <- info
+		req.ResultChan <- s.runRequest(id, req)
 	}
 }
 
-// ProcessRepository submits a repository for processing and waits for the result
-func (s *Service) ProcessRepository(owner, repo string) models.RepositoryInfo {
-	resultChan := make(chan models.RepositoryInfo, 1)
+// runRequest runs the stage of a request on a worker: the eligibility checks, which
+// report an eligible repository without extracting it, or the extraction
+func (s *Service) runRequest(id int, req RepositoryRequest) RepositoryResult {
+	src, err := s.sources.Get(req.Host)
+	if err != nil {
+		return RepositoryResult{Err: err}
+	}
+	if err := enterStage(req, req.stage); err != nil {
+		return RepositoryResult{Err: err}
+	}
+
+	if req.stage == StageEligibility {
+		p := req.Eligibility
+		s.logger.Debugf("[Worker %d] Checking eligibility of %s/%s", id, req.Owner, req.Repo)
+		ok, reason, err := src.CheckRepoEligibility(req.Ctx, req.Owner, req.Repo, p.MinCommits, p.Days, p.MinActive)
+		if err != nil {
+			return RepositoryResult{Err: fmt.Errorf("error checking repository eligibility: %w", err)}
+		}
+		return RepositoryResult{Eligible: ok, Reason: reason}
+	}
 
-	request := RepositoryRequest{
-		Owner:      owner,
-		Repo:       repo,
-		ResultChan: resultChan,
+	s.logger.Debugf("[Worker %d] Processing %s/%s on %s", id, req.Owner, req.Repo, src.Host())
+	return RepositoryResult{
+		Info:     src.GetRepositoryInfo(req.Ctx, req.Owner, req.Repo),
+		Eligible: true,
 	}
+}
+
+// enterStage reports that req enters stage, unless it has been cancelled
+func enterStage(req RepositoryRequest, stage string) error {
+	if err := req.Ctx.Err(); err != nil {
+		return err
+	}
+	if req.OnStage != nil {
+		req.OnStage(stage)
+	}
+	return nil
+}
+
+// submit runs the eligibility checks (if requested) and the extraction of a repository,
+// each on a worker, and waits for the result. In between, GitHub extractions wait
+// without holding a worker until the scheduler of their host has admitted their
+// estimated cost; other hosts do not report a quota and are extracted right away.
+func (s *Service) submit(request RepositoryRequest) RepositoryResult {
+	src, err := s.sources.Get(request.Host)
+	if err != nil {
+		return RepositoryResult{Err: err}
+	}
+
+	if request.Eligibility != nil {
+		res := s.dispatch(request, StageEligibility)
+		if res.Err != nil || !res.Eligible {
+			return res
+		}
+	}
+
+	if scheduler, ok := s.schedulers[source.NormalizeHost(src.Host())]; ok {
+		if err := enterStage(request, StageScheduling); err != nil {
+			return RepositoryResult{Err: err}
+		}
+		cost := scheduler.Estimate(request.Ctx, request.Owner, request.Repo)
+		s.logger.Debugf("%s/%s estimated at %d core, %d search and %d GraphQL requests", request.Owner, request.Repo, cost.Core, cost.Search, cost.GraphQL)
+		release, err := scheduler.Admit(request.Ctx, cost)
+		if err != nil {
+			return RepositoryResult{Err: err}
+		}
+		defer release()
+	}
+
+	return s.dispatch(request, StageExtraction)
+}
+
+// dispatch queues a stage of a request for the workers and waits for its result. A
+// request cancelled while waiting for a queue slot gives up without ever reaching a
+// worker.
+func (s *Service) dispatch(request RepositoryRequest, stage string) RepositoryResult {
+	resultChan := make(chan RepositoryResult, 1)
+	request.ResultChan = resultChan
+	request.stage = stage
 
 	// Submit job to queue
-	s.jobQueue <- request
+	select {
+	case s.jobQueue <- request:
+	case <-request.Ctx.Done():
+		return RepositoryResult{Err: request.Ctx.Err()}
+	}
 
 	// Wait for result
 	result := <-resultChan
@@ -77,6 +220,27 @@ func (s *Service) ProcessRepository(owner, repo string) models.RepositoryInfo {
 	return result
 }
 
+// ProcessRepository submits a repository for processing and waits for the result
+func (s *Service) ProcessRepository(ctx context.Context, host, owner, repo string) models.RepositoryInfo {
+	return s.submit(RepositoryRequest{
+		Ctx:   ctx,
+		Host:  host,
+		Owner: owner,
+		Repo:  repo,
+	}).Info
+}
+
+// CheckAndProcessRepository submits a repository that is extracted only if it passes the eligibility checks
+func (s *Service) CheckAndProcessRepository(ctx context.Context, host, owner, repo string, params EligibilityParams) RepositoryResult {
+	return s.submit(RepositoryRequest{
+		Ctx:         ctx,
+		Host:        host,
+		Owner:       owner,
+		Repo:        repo,
+		Eligibility: &params,
+	})
+}
+
 // GetWorkerCount returns the number of active workers
 func (s *Service) GetWorkerCount() int {
 	return s.workers
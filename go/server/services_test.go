@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github-extractor/github"
+	"github-extractor/github/githubtest"
+	"github-extractor/models"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+// staticSource serves the same repository for every request and reports no quota
+type staticSource struct {
+	source.RepositorySource
+	host string
+}
+
+func (s staticSource) Host() string { return s.host }
+
+func (s staticSource) GetRepositoryInfo(ctx context.Context, owner, repo string) models.RepositoryInfo {
+	return models.RepositoryInfo{Owner: owner, Repo: repo, Commits: 1}
+}
+
+// A GitHub request waiting for quota holds no worker, so other hosts still get extracted
+func TestServiceAdmitsOutsideThePool(t *testing.T) {
+	fake := githubtest.NewServer()
+	t.Cleanup(fake.Close)
+	fake.AddRepository(gadget(time.Now()))
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client, err := github.NewClient(fake.Options(), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := NewService(source.NewRegistry(client, staticSource{host: "example.org"}), 1, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reserve the whole core quota so the next GitHub extraction waits
+	release, err := service.schedulers[source.DefaultHost].Admit(context.Background(), github.Cost{Core: githubtest.RateLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waiting := make(chan RepositoryResult, 1)
+	go func() {
+		waiting <- service.submit(RepositoryRequest{Ctx: ctx, Owner: "acme", Repo: "gadget"})
+	}()
+
+	done := make(chan models.RepositoryInfo, 1)
+	go func() {
+		done <- service.ProcessRepository(context.Background(), "example.org", "acme", "gadget")
+	}()
+	select {
+	case info := <-done:
+		if info.Commits != 1 {
+			t.Errorf("info = %+v, want the static repository", info)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("example.org extraction still waiting behind the GitHub one")
+	}
+
+	select {
+	case res := <-waiting:
+		t.Fatalf("GitHub extraction finished without quota: %+v", res)
+	default:
+	}
+	cancel()
+	if res := <-waiting; res.Err == nil {
+		t.Errorf("cancelled GitHub extraction finished without error")
+	}
+}
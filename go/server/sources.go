@@ -0,0 +1,95 @@
+package server
+
+import (
+	"github-extractor/config"
+	"github-extractor/gitea"
+	"github-extractor/github"
+	"github-extractor/gitlab"
+	"github-extractor/grpcclient"
+	"github-extractor/metrics"
+	"github-extractor/source"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewSources creates the clients of every configured host: github.com, GitLab, Gitea
+// and, when configured, a GitHub Enterprise Server instance
+func NewSources(cfg *config.Config, logger *logrus.Logger) (*source.Registry, error) {
+	ghClient, err := github.NewClient(github.Options{
+		Tokens:   cfg.GitHubTokens,
+		App:      appCredentials(cfg.GitHubApp),
+		CacheDir: cfg.CacheDir,
+		API:      cfg.GitHubAPI,
+		CloneDir: cfg.CloneDir,
+		CABundle: cfg.GitHubCABundle,
+		Proxy:    cfg.GitHubProxy,
+		Record:   cfg.RecordDir,
+		Replay:   cfg.ReplayDir,
+		ReplayAt: cfg.ReplayAt,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	gitlabClient, err := gitlab.NewClient(cfg.GitLabURL, cfg.GitLabToken, logger)
+	if err != nil {
+		return nil, err
+	}
+	giteaClient, err := gitea.NewClient(cfg.GiteaURL, cfg.GiteaToken, logger)
+	if err != nil {
+		return nil, err
+	}
+	sources := source.NewRegistry(ghClient, gitlabClient, giteaClient)
+
+	// GitHub Enterprise Server repositories are selected with the instance host
+	if ghe := cfg.Enterprise; ghe != nil {
+		gheClient, err := github.NewClient(github.Options{
+			Tokens:     ghe.Tokens,
+			App:        appCredentials(ghe.App),
+			CacheDir:   cfg.CacheDir,
+			API:        cfg.GitHubAPI,
+			CloneDir:   cfg.CloneDir,
+			BaseURL:    ghe.URL,
+			UploadURL:  ghe.UploadURL,
+			GraphQLURL: ghe.GraphQLURL,
+			CABundle:   ghe.CABundle,
+			Proxy:      ghe.Proxy,
+			Record:     cfg.RecordDir,
+			Replay:     cfg.ReplayDir,
+			ReplayAt:   cfg.ReplayAt,
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+		sources.Register(gheClient)
+	}
+
+	return sources, nil
+}
+
+// NewProcessor computes metrics in process or through the gRPC processor service, as
+// configured. The returned function releases the processor.
+func NewProcessor(cfg *config.Config, logger *logrus.Logger) (Processor, func(), error) {
+	if cfg.Metrics == "inprocess" {
+		logger.WithField("datasets", cfg.MetricsDatasets).Info("Computing metrics in process")
+		return metrics.NewCalculator(cfg.MetricsDatasets, logger), func() {}, nil
+	}
+
+	processorClient, err := grpcclient.NewProcessorClient(cfg.GRPCAddress, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return processorClient, func() { processorClient.Close() }, nil
+}
+
+// appCredentials converts the configured GitHub App, if any, for the GitHub client
+func appCredentials(app *config.GitHubApp) *github.AppCredentials {
+	if app == nil {
+		return nil
+	}
+	return &github.AppCredentials{
+		AppID:          app.ID,
+		PrivateKey:     app.PrivateKey,
+		InstallationID: app.InstallationID,
+	}
+}
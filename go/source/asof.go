@@ -0,0 +1,240 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github-extractor/models"
+)
+
+type asOfKey struct{}
+
+// WithAsOf returns a copy of ctx that makes extractions describe the repository as it was
+// at t: commits, pull requests, milestones, contributor activity and weekly statistics
+// after t are left out, and recency windows end at t. A zero t returns ctx unchanged.
+func WithAsOf(ctx context.Context, t time.Time) context.Context {
+	if t.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, asOfKey{}, t.UTC())
+}
+
+// ParseAsOf parses a snapshot date given as an RFC 3339 timestamp or a YYYY-MM-DD date,
+// which stands for midnight UTC
+func ParseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("as_of must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
+
+// AsOfFrom returns the snapshot date attached to ctx with WithAsOf, or the zero time when
+// the extraction describes the repository as it is now
+func AsOfFrom(ctx context.Context) time.Time {
+	t, _ := ctx.Value(asOfKey{}).(time.Time)
+	return t
+}
+
+// Now returns the time recency windows end at: the snapshot date attached to ctx, or the
+// current time
+func Now(ctx context.Context) time.Time {
+	if t := AsOfFrom(ctx); !t.IsZero() {
+		return t
+	}
+	return time.Now()
+}
+
+// MarkAsOf records the snapshot date attached to ctx in info, once its creation date is
+// known. It returns an error when the snapshot date is before the repository was created.
+func MarkAsOf(ctx context.Context, info *models.RepositoryInfo) error {
+	t := AsOfFrom(ctx)
+	if t.IsZero() {
+		return nil
+	}
+	if !info.CreatedAt.IsZero() && t.Before(info.CreatedAt) {
+		return fmt.Errorf("the repository was created on %s, after as_of %s", info.CreatedAt.UTC().Format(time.RFC3339), t.Format(time.RFC3339))
+	}
+	info.AsOf = &t
+	return nil
+}
+
+// StatsAsOf keeps the weeks of stats that ended by t, recomputing the commit totals and
+// first and last commit weeks. Contributors without commits by then are dropped. Weeks
+// are the finest granularity and the week t falls in would also count commits made
+// after t, so it is left out: the statistics stop at the last full week before t and
+// miss the commits made earlier in the week of t. A zero t returns stats unchanged.
+func StatsAsOf(stats []models.ContributorStats, t time.Time) []models.ContributorStats {
+	if t.IsZero() {
+		return stats
+	}
+	const week = 7 * 24 * 60 * 60
+
+	snapshot := make([]models.ContributorStats, 0, len(stats))
+	for _, s := range stats {
+		kept := models.ContributorStats{Author: s.Author, Weeks: []models.Week{}}
+		for _, w := range s.Weeks {
+			if w.WeekTimestamp+week > t.Unix() || w.Commits == 0 {
+				continue
+			}
+			kept.Weeks = append(kept.Weeks, w)
+			kept.Total += w.Commits
+
+			start := time.Unix(w.WeekTimestamp, 0).UTC()
+			if kept.FirstCommit.IsZero() || start.Before(kept.FirstCommit) {
+				kept.FirstCommit = start
+			}
+			if start.After(kept.LastCommit) {
+				kept.LastCommit = start
+			}
+		}
+		if kept.Total > 0 {
+			snapshot = append(snapshot, kept)
+		}
+	}
+	return snapshot
+}
+
+// ClosedBy reports whether a pull request was closed or merged at or before t; every
+// pull request is when t is zero
+func ClosedBy(pr models.PullRequestInfo, t time.Time) bool {
+	if t.IsZero() {
+		return true
+	}
+	closed := pr.ClosedAt
+	if closed == nil {
+		closed = pr.MergedAt
+	}
+	return closed != nil && !closed.After(t)
+}
+
+// MilestonesAsOf keeps the milestones created by t, reopening those closed after t. A zero
+// t returns milestones unchanged.
+func MilestonesAsOf(milestones []models.MilestoneInfo, t time.Time) []models.MilestoneInfo {
+	if t.IsZero() {
+		return milestones
+	}
+	kept := make([]models.MilestoneInfo, 0, len(milestones))
+	for _, m := range milestones {
+		if m.CreatedAt.After(t) {
+			continue
+		}
+		if m.ClosedAt != nil && m.ClosedAt.After(t) {
+			m.ClosedAt = nil
+		}
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// SetMilestones records the milestones of a repository in info
+func SetMilestones(info *models.RepositoryInfo, milestones []models.MilestoneInfo) {
+	info.MilestoneDates = milestones
+	info.Milestones = len(milestones)
+	info.HasMilestones = len(milestones) > 0
+}
+
+// ContributorsFromStats ranks the authors of stats by commits, most active first, for
+// hosts whose contributor list cannot be limited to a date. It returns them with the
+// total and non-anonymous counts Contributors reports; stats only hold authors with an
+// identity, so both counts are the number of authors.
+func ContributorsFromStats(stats []models.ContributorStats) ([]Contributor, int, int) {
+	sorted := append([]models.ContributorStats(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Total != sorted[j].Total {
+			return sorted[i].Total > sorted[j].Total
+		}
+		return sorted[i].Author < sorted[j].Author
+	})
+
+	contributors := make([]Contributor, 0, len(sorted))
+	for _, s := range sorted {
+		contributors = append(contributors, Contributor{Login: s.Author, Contributions: s.Total})
+	}
+	return contributors, len(contributors), len(contributors)
+}
+
+// InfoAsOf cuts an extraction down to what it held at t, so the metrics can be computed
+// at several dates from a single extraction. The weekly statistics stop at the last full
+// week before t, as StatsAsOf does, and pull requests at t. The contributors and follow
+// graph lose the contributors without commits in the kept weeks; contributors the
+// statistics do not cover are kept. Milestones are recounted from those created by t, when
+// the extraction holds their dates. Engagement, which has no history, is left out.
+// Repository metadata and community files, which have no history either, are kept as
+// extracted.
+func InfoAsOf(info models.RepositoryInfo, t time.Time) models.RepositoryInfo {
+	snapshot := info
+	snapshot.AsOf = &t
+	snapshot.ContributorStats = StatsAsOf(info.ContributorStats, t)
+	snapshot.Engagement = SummarizeEngagement(nil)
+	if info.MilestoneDates != nil {
+		SetMilestones(&snapshot, MilestonesAsOf(info.MilestoneDates, t))
+	}
+
+	snapshot.PullRequests = make([]models.PullRequestInfo, 0, len(info.PullRequests))
+	for _, pr := range info.PullRequests {
+		if ClosedBy(pr, t) {
+			snapshot.PullRequests = append(snapshot.PullRequests, pr)
+		}
+	}
+
+	// Authors of the full statistics, and whether they had committed by t
+	active := make(map[string]bool, len(info.ContributorStats))
+	for _, s := range info.ContributorStats {
+		active[strings.ToLower(s.Author)] = false
+	}
+	snapshot.Commits = 0
+	for _, s := range snapshot.ContributorStats {
+		active[strings.ToLower(s.Author)] = true
+		snapshot.Commits += s.Total
+	}
+	snapshot.TotalContributorsCount = len(snapshot.ContributorStats)
+	snapshot.NonAnonymousContributorsCount = len(snapshot.ContributorStats)
+	joined := func(identities ...string) bool {
+		for _, id := range identities {
+			if ok, known := active[strings.ToLower(id)]; known && id != "" {
+				return ok
+			}
+		}
+		return true
+	}
+
+	snapshot.Selection.Logins = []string{}
+	for _, login := range info.Selection.Logins {
+		if joined(login) {
+			snapshot.Selection.Logins = append(snapshot.Selection.Logins, login)
+		}
+	}
+	snapshot.SelectedContributorsCount = len(snapshot.Selection.Logins)
+
+	snapshot.FollowGraph = models.FollowGraph{Members: []string{}, Edges: []models.FollowEdge{}}
+	members := make(map[string]bool, len(info.FollowGraph.Members))
+	for _, m := range info.FollowGraph.Members {
+		if joined(m) {
+			snapshot.FollowGraph.Members = append(snapshot.FollowGraph.Members, m)
+			members[strings.ToLower(m)] = true
+		}
+	}
+	for _, e := range info.FollowGraph.Edges {
+		if members[strings.ToLower(e.Follower)] && members[strings.ToLower(e.Followed)] {
+			snapshot.FollowGraph.Edges = append(snapshot.FollowGraph.Edges, e)
+		}
+	}
+
+	// Hosts that identify authors by email match profiles by their email instead
+	snapshot.Contributors = nil
+	for _, d := range info.Contributors {
+		if joined(d.Login, d.Email) {
+			snapshot.Contributors = append(snapshot.Contributors, d)
+		}
+	}
+	followers, following := CountFollows(snapshot.FollowGraph)
+	ApplyFollowCounts(snapshot.Contributors, followers, following)
+	snapshot.ContributorsWithLocationCount = len(snapshot.Contributors)
+	return snapshot
+}
@@ -0,0 +1,250 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/models"
+)
+
+// sunday starts the first week of the test statistics, as GitHub's weeks do
+var sunday = time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+// week returns the start of the i-th week after sunday
+func week(i int) time.Time {
+	return sunday.AddDate(0, 0, 7*i)
+}
+
+// weeks returns the statistics of an author with the given commits in consecutive weeks
+// starting at sunday
+func weeks(author string, commits ...int) models.ContributorStats {
+	s := models.ContributorStats{Author: author}
+	for i, n := range commits {
+		s.Weeks = append(s.Weeks, models.Week{WeekTimestamp: week(i).Unix(), Commits: n})
+		if n > 0 {
+			if s.FirstCommit.IsZero() {
+				s.FirstCommit = week(i)
+			}
+			s.LastCommit = week(i)
+		}
+		s.Total += n
+	}
+	return s
+}
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-05T10:30:00Z", time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC), false},
+		{"2024-03-05T12:30:00+02:00", time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC), false},
+		{"05/03/2024", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAsOf(tt.value)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseAsOf(%q) = %s, %v; want %s, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestStatsAsOf(t *testing.T) {
+	stats := []models.ContributorStats{
+		weeks("alice", 2, 0, 3, 1),
+		weeks("bob", 0, 0, 0, 4),
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string // Author, total and first and last weeks of every contributor kept
+	}{
+		{
+			name: "zero keeps everything",
+			want: "alice:6:0-3 bob:4:3-3",
+		},
+		{
+			name: "end of a week",
+			at:   week(3),
+			want: "alice:5:0-2",
+		},
+		{
+			name: "week of t left out",
+			at:   week(3).Add(4 * 24 * time.Hour),
+			want: "alice:5:0-2",
+		},
+		{
+			name: "one second before the week ends",
+			at:   week(4).Add(-time.Second),
+			want: "alice:5:0-2",
+		},
+		{
+			name: "after the last week",
+			at:   week(4),
+			want: "alice:6:0-3 bob:4:3-3",
+		},
+		{
+			name: "empty weeks do not set the first commit",
+			at:   week(2),
+			want: "alice:2:0-0",
+		},
+		{
+			name: "before the first full week",
+			at:   week(1).Add(-time.Second),
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range StatsAsOf(stats, tt.at) {
+				first := int(s.FirstCommit.Sub(sunday) / (7 * 24 * time.Hour))
+				last := int(s.LastCommit.Sub(sunday) / (7 * 24 * time.Hour))
+				got = append(got, fmt.Sprintf("%s:%d:%d-%d", s.Author, s.Total, first, last))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosedBy(t *testing.T) {
+	at := week(2)
+	before, after := at.Add(-time.Hour), at.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		pr   models.PullRequestInfo
+		at   time.Time
+		want bool
+	}{
+		{"closed before", models.PullRequestInfo{ClosedAt: &before}, at, true},
+		{"closed at t", models.PullRequestInfo{ClosedAt: &at}, at, true},
+		{"closed after", models.PullRequestInfo{ClosedAt: &after}, at, false},
+		{"merged without a close date", models.PullRequestInfo{MergedAt: &before}, at, true},
+		{"close date wins over the merge date", models.PullRequestInfo{ClosedAt: &after, MergedAt: &before}, at, false},
+		{"open", models.PullRequestInfo{}, at, false},
+		{"zero t", models.PullRequestInfo{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		if got := ClosedBy(tt.pr, tt.at); got != tt.want {
+			t.Errorf("%s: ClosedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarkAsOf(t *testing.T) {
+	created := week(0)
+	info := models.RepositoryInfo{CreatedAt: created}
+	if err := MarkAsOf(context.Background(), &info); err != nil || info.AsOf != nil {
+		t.Errorf("without a date: as_of %v, error %v", info.AsOf, err)
+	}
+	if err := MarkAsOf(WithAsOf(context.Background(), week(2)), &info); err != nil || info.AsOf == nil || !info.AsOf.Equal(week(2)) {
+		t.Errorf("after creation: as_of %v, error %v", info.AsOf, err)
+	}
+	if err := MarkAsOf(WithAsOf(context.Background(), created.Add(-time.Hour)), &info); err == nil {
+		t.Error("date before the creation accepted")
+	}
+}
+
+func TestInfoAsOf(t *testing.T) {
+	closed := func(t time.Time) *time.Time { return &t }
+	info := models.RepositoryInfo{
+		Commits: 14,
+		ContributorStats: []models.ContributorStats{
+			weeks("alice", 2, 0, 3, 1),
+			weeks("bob", 0, 0, 0, 4),
+			weeks("carol@example.com", 0, 4),
+		},
+		PullRequests: []models.PullRequestInfo{
+			{Number: 1, ClosedAt: closed(week(1))},
+			{Number: 2, ClosedAt: closed(week(3).Add(time.Hour))},
+			{Number: 3, MergedAt: closed(week(2))},
+		},
+		Milestones:    3,
+		HasMilestones: true,
+		MilestoneDates: []models.MilestoneInfo{
+			{CreatedAt: week(1), ClosedAt: closed(week(4))},
+			{CreatedAt: week(2)},
+			{CreatedAt: week(4)},
+		},
+		TotalContributorsCount: 3,
+		Engagement:             models.Engagement{Threads: 5, Contributors: []models.ContributorEngagement{{Login: "alice"}}},
+		Selection:              models.ContributorSelection{Logins: []string{"alice", "Bob", "dave"}},
+		Contributors: []models.ContributorDetail{
+			{Login: "alice", Followers: 9},
+			{Login: "bob"},
+			{Login: "carol", Email: "Carol@example.com"},
+			{Login: "dave"}, // Not in the statistics
+		},
+		FollowGraph: models.FollowGraph{
+			Members: []string{"alice", "bob", "carol", "dave"},
+			Edges: []models.FollowEdge{
+				{Follower: "bob", Followed: "alice"},
+				{Follower: "dave", Followed: "alice"},
+				{Follower: "carol", Followed: "alice"},
+			},
+		},
+	}
+
+	at := week(3)
+	snapshot := InfoAsOf(info, at)
+
+	if snapshot.AsOf == nil || !snapshot.AsOf.Equal(at) {
+		t.Errorf("as_of = %v, want %s", snapshot.AsOf, at)
+	}
+	if snapshot.Commits != 9 || snapshot.TotalContributorsCount != 2 || snapshot.NonAnonymousContributorsCount != 2 {
+		t.Errorf("commits %d, contributors %d/%d; want 9 commits of 2 contributors",
+			snapshot.Commits, snapshot.TotalContributorsCount, snapshot.NonAnonymousContributorsCount)
+	}
+
+	if snapshot.Engagement.Threads != 0 || len(snapshot.Engagement.Contributors) != 0 {
+		t.Errorf("engagement = %+v, want it left out", snapshot.Engagement)
+	}
+	if snapshot.Milestones != 2 || !snapshot.HasMilestones || snapshot.MilestoneDates[0].ClosedAt != nil {
+		t.Errorf("milestones %d %+v, want the first two, still open", snapshot.Milestones, snapshot.MilestoneDates)
+	}
+	if early := InfoAsOf(info, week(1).Add(-time.Hour)); early.Milestones != 0 || early.HasMilestones {
+		t.Errorf("milestones before the first = %d, want none", early.Milestones)
+	}
+	if undated := InfoAsOf(models.RepositoryInfo{Milestones: 3, HasMilestones: true}, at); undated.Milestones != 3 {
+		t.Errorf("milestones without dates = %d, want the extracted 3", undated.Milestones)
+	}
+
+	var prs []string
+	for _, pr := range snapshot.PullRequests {
+		prs = append(prs, fmt.Sprint(pr.Number))
+	}
+	if got := strings.Join(prs, " "); got != "1 3" {
+		t.Errorf("pull requests %s, want 1 3", got)
+	}
+
+	if got := strings.Join(snapshot.Selection.Logins, " "); got != "alice dave" || snapshot.SelectedContributorsCount != 2 {
+		t.Errorf("selection %s (%d), want alice and dave, who the statistics do not cover", got, snapshot.SelectedContributorsCount)
+	}
+	if got := strings.Join(snapshot.FollowGraph.Members, " "); got != "alice carol dave" {
+		t.Errorf("follow graph members %s, want alice carol dave", got)
+	}
+
+	var contributors []string
+	for _, c := range snapshot.Contributors {
+		contributors = append(contributors, fmt.Sprintf("%s:%d", c.Login, c.Followers))
+	}
+	if got := strings.Join(contributors, " "); got != "alice:2 carol:0 dave:0" {
+		t.Errorf("contributors %s, want alice:2 carol:0 dave:0 with recounted followers", got)
+	}
+
+	// The extraction itself is left untouched
+	if len(info.Selection.Logins) != 3 || info.Contributors[0].Followers != 9 || info.AsOf != nil || info.MilestoneDates[0].ClosedAt == nil {
+		t.Error("InfoAsOf modified the extraction")
+	}
+}
@@ -0,0 +1,131 @@
+package source
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github-extractor/models"
+)
+
+// Commit is a commit as listed by a host API, used by hosts without a contributor
+// statistics endpoint to derive contributors and weekly activity themselves
+type Commit struct {
+	Login     string // Account of the author, empty when the email is not linked to one
+	Email     string
+	Name      string
+	Date      time.Time
+	Additions int
+	Deletions int
+}
+
+// Identity returns the key a commit is attributed to: the author login when known,
+// otherwise the lowercased email, otherwise the author name
+func (c Commit) Identity() string {
+	switch {
+	case c.Login != "":
+		return c.Login
+	case c.Email != "":
+		return strings.ToLower(c.Email)
+	default:
+		return c.Name
+	}
+}
+
+// CommitSummary is the contributor data derived from a list of commits
+type CommitSummary struct {
+	Contributors []Contributor // Identities ordered by commit count, most active first
+	NonAnonymous int           // Contributors with a linked account
+	Stats        []models.ContributorStats
+}
+
+// SummarizeCommits groups commits by author into the same weekly statistics GitHub's
+// stats/contributors endpoint reports: weeks start on Sunday (UTC), only weeks with
+// commits are listed, and the first and last commit dates are week starts.
+func SummarizeCommits(commits []Commit) CommitSummary {
+	type author struct {
+		stat   models.ContributorStats
+		weeks  map[int64]*models.Week
+		linked bool
+	}
+	authors := make(map[string]*author)
+
+	for _, c := range commits {
+		id := c.Identity()
+		if id == "" {
+			continue
+		}
+		a, ok := authors[id]
+		if !ok {
+			a = &author{stat: models.ContributorStats{Author: id}, weeks: make(map[int64]*models.Week)}
+			authors[id] = a
+		}
+		a.linked = a.linked || c.Login != ""
+		a.stat.Total++
+
+		start := WeekStart(c.Date).Unix()
+		w, ok := a.weeks[start]
+		if !ok {
+			w = &models.Week{WeekTimestamp: start}
+			a.weeks[start] = w
+		}
+		w.Commits++
+		w.Additions += c.Additions
+		w.Deletions += c.Deletions
+	}
+
+	summary := CommitSummary{Stats: make([]models.ContributorStats, 0, len(authors))}
+	for _, a := range authors {
+		weeks := make([]models.Week, 0, len(a.weeks))
+		for _, w := range a.weeks {
+			weeks = append(weeks, *w)
+		}
+		sort.Slice(weeks, func(i, j int) bool { return weeks[i].WeekTimestamp < weeks[j].WeekTimestamp })
+
+		a.stat.Weeks = weeks
+		a.stat.FirstCommit = time.Unix(weeks[0].WeekTimestamp, 0).UTC()
+		a.stat.LastCommit = time.Unix(weeks[len(weeks)-1].WeekTimestamp, 0).UTC()
+		summary.Stats = append(summary.Stats, a.stat)
+		if a.linked {
+			summary.NonAnonymous++
+		}
+	}
+
+	// Most active first, ties broken by name so the order is stable
+	sort.Slice(summary.Stats, func(i, j int) bool {
+		if summary.Stats[i].Total != summary.Stats[j].Total {
+			return summary.Stats[i].Total > summary.Stats[j].Total
+		}
+		return summary.Stats[i].Author < summary.Stats[j].Author
+	})
+	for _, s := range summary.Stats {
+		summary.Contributors = append(summary.Contributors, Contributor{Login: s.Author, Contributions: s.Total})
+	}
+
+	return summary
+}
+
+// RecentAuthors returns the distinct identities that committed after since, in order of
+// first appearance
+func RecentAuthors(commits []Commit, since time.Time) []string {
+	seen := make(map[string]struct{})
+	var recent []string
+	for _, c := range commits {
+		id := c.Identity()
+		if id == "" || c.Date.Before(since) {
+			continue
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			recent = append(recent, id)
+		}
+	}
+	return recent
+}
+
+// WeekStart returns the start of the week (Sunday 00:00 UTC) containing t
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -int(day.Weekday()))
+}
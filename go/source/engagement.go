@@ -0,0 +1,149 @@
+package source
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github-extractor/models"
+)
+
+// MaxThreads is the number of most recently updated issues and pull requests, and
+// separately of discussions, an extraction inspects for engagement
+const MaxThreads = 100
+
+type engagementKey struct{}
+
+// WithEngagement returns a copy of ctx that makes extractions read the recent threads
+// engagement and structure are computed from. Threads cost up to three requests each,
+// so extractions leave them out unless asked.
+func WithEngagement(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, engagementKey{}, enabled)
+}
+
+// EngagementFrom reports whether extractions read threads: engagement was requested
+// with WithEngagement and no snapshot date is attached to ctx, as threads are only
+// available as they are now
+func EngagementFrom(ctx context.Context) bool {
+	enabled, _ := ctx.Value(engagementKey{}).(bool)
+	return enabled && AsOfFrom(ctx).IsZero()
+}
+
+// MaxResponses is how many comments, review comments and reviews of each thread are
+// read, each kind on its own
+const MaxResponses = 100
+
+// Kinds of Response
+const (
+	ResponseComment           = "comment"            // Comment on an issue or pull request conversation
+	ResponseReviewComment     = "review_comment"     // Inline comment on a pull request diff
+	ResponseReview            = "review"             // Pull request review
+	ResponseDiscussionComment = "discussion_comment" // Comment or reply in a discussion
+)
+
+// Thread is an issue, pull request or discussion with the responses it received
+type Thread struct {
+	Author    string
+	Reactions int // Reactions on the opening post
+	Responses []Response
+}
+
+// Response is a comment or review posted in a thread
+type Response struct {
+	Kind      string
+	Author    string
+	Reactions int
+}
+
+// isBot reports whether login is a bot account, whose automated comments would
+// inflate engagement
+func isBot(login string) bool {
+	return strings.HasSuffix(strings.ToLower(login), "[bot]")
+}
+
+// SummarizeEngagement counts the activity of every participant of threads and the
+// collaboration edges from each responder to the authors of the threads they responded
+// to. Logins are compared case-insensitively. Deleted accounts and bots are not
+// participants, and responses to one's own thread add no edge.
+func SummarizeEngagement(threads []Thread) models.Engagement {
+	participants := make(map[string]*models.ContributorEngagement)
+	participant := func(login string) *models.ContributorEngagement {
+		if login == "" || isBot(login) {
+			return nil
+		}
+		key := strings.ToLower(login)
+		p, ok := participants[key]
+		if !ok {
+			p = &models.ContributorEngagement{Login: login}
+			participants[key] = p
+		}
+		return p
+	}
+
+	type edge struct{ from, to string }
+	weights := make(map[edge]int)
+
+	e := models.Engagement{Threads: len(threads)}
+	for _, t := range threads {
+		author := participant(t.Author)
+		if author != nil {
+			author.Threads++
+			author.ReactionsReceived += t.Reactions
+		}
+
+		responded := false
+		for _, r := range t.Responses {
+			p := participant(r.Author)
+			if p == nil {
+				continue
+			}
+			switch r.Kind {
+			case ResponseComment:
+				p.Comments++
+			case ResponseReviewComment:
+				p.ReviewComments++
+			case ResponseReview:
+				p.Reviews++
+			case ResponseDiscussionComment:
+				p.DiscussionComments++
+			}
+			p.ReactionsReceived += r.Reactions
+
+			if p == author {
+				continue
+			}
+			responded = true
+			if author != nil {
+				weights[edge{strings.ToLower(p.Login), strings.ToLower(author.Login)}]++
+			}
+		}
+		if responded {
+			e.RespondedThreads++
+		}
+	}
+
+	e.Contributors = make([]models.ContributorEngagement, 0, len(participants))
+	for _, p := range participants {
+		e.Contributors = append(e.Contributors, *p)
+	}
+	sort.Slice(e.Contributors, func(i, j int) bool {
+		return strings.ToLower(e.Contributors[i].Login) < strings.ToLower(e.Contributors[j].Login)
+	})
+
+	e.Collaboration = make([]models.CollaborationEdge, 0, len(weights))
+	for ed, w := range weights {
+		e.Collaboration = append(e.Collaboration, models.CollaborationEdge{
+			From:   participants[ed.from].Login,
+			To:     participants[ed.to].Login,
+			Weight: w,
+		})
+	}
+	sort.Slice(e.Collaboration, func(i, j int) bool {
+		a, b := e.Collaboration[i], e.Collaboration[j]
+		if fa, fb := strings.ToLower(a.From), strings.ToLower(b.From); fa != fb {
+			return fa < fb
+		}
+		return strings.ToLower(a.To) < strings.ToLower(b.To)
+	})
+	return e
+}
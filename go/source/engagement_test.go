@@ -0,0 +1,154 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github-extractor/models"
+)
+
+func TestEngagementFrom(t *testing.T) {
+	background := context.Background()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"not requested", background, false},
+		{"requested", WithEngagement(background, true), true},
+		{"turned off", WithEngagement(WithEngagement(background, true), false), false},
+		{"snapshot", WithEngagement(WithAsOf(background, time.Now().Add(-time.Hour)), true), false},
+	}
+	for _, tt := range tests {
+		if got := EngagementFrom(tt.ctx); got != tt.want {
+			t.Errorf("%s: EngagementFrom = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// edges formats collaboration edges as from>to:weight
+func edges(e models.Engagement) string {
+	var out []string
+	for _, ed := range e.Collaboration {
+		out = append(out, fmt.Sprintf("%s>%s:%d", ed.From, ed.To, ed.Weight))
+	}
+	return strings.Join(out, " ")
+}
+
+func TestSummarizeEngagement(t *testing.T) {
+	tests := []struct {
+		name      string
+		threads   []Thread
+		responded int
+		edges     string
+		logins    string
+	}{
+		{
+			name:    "no replies",
+			threads: []Thread{{Author: "alice"}, {Author: "bob"}},
+			logins:  "alice bob",
+		},
+		{
+			name: "replies to one's own thread",
+			threads: []Thread{{Author: "alice", Responses: []Response{
+				{Kind: ResponseComment, Author: "alice"},
+				{Kind: ResponseComment, Author: "ALICE"},
+			}}},
+			logins: "alice",
+		},
+		{
+			name: "bot and deleted responders",
+			threads: []Thread{{Author: "alice", Responses: []Response{
+				{Kind: ResponseComment, Author: "dependabot[bot]"},
+				{Kind: ResponseReview, Author: "Renovate[Bot]"},
+				{Kind: ResponseComment, Author: ""},
+			}}},
+			logins: "alice",
+		},
+		{
+			name: "threads opened by a bot or a deleted account",
+			threads: []Thread{
+				{Author: "dependabot[bot]", Responses: []Response{{Kind: ResponseReview, Author: "alice"}}},
+				{Author: "", Responses: []Response{{Kind: ResponseComment, Author: "bob"}}},
+			},
+			responded: 2,
+			logins:    "alice bob",
+		},
+		{
+			name: "responses are counted per responder and author",
+			threads: []Thread{
+				{Author: "alice", Responses: []Response{
+					{Kind: ResponseComment, Author: "bob"},
+					{Kind: ResponseReviewComment, Author: "Bob"},
+					{Kind: ResponseComment, Author: "carol"},
+					{Kind: ResponseComment, Author: "alice"},
+				}},
+				{Author: "Bob", Responses: []Response{{Kind: ResponseDiscussionComment, Author: "Alice"}}},
+				{Author: "carol"},
+			},
+			responded: 2,
+			edges:     "alice>bob:1 bob>alice:2 carol>alice:1",
+			logins:    "alice bob carol",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := SummarizeEngagement(tt.threads)
+			if e.Threads != len(tt.threads) || e.RespondedThreads != tt.responded {
+				t.Errorf("%d threads with %d responded, want %d with %d", e.Threads, e.RespondedThreads, len(tt.threads), tt.responded)
+			}
+			if got := edges(e); got != tt.edges {
+				t.Errorf("edges = %q, want %q", got, tt.edges)
+			}
+			var logins []string
+			for _, c := range e.Contributors {
+				logins = append(logins, c.Login)
+			}
+			if got := strings.Join(logins, " "); got != tt.logins {
+				t.Errorf("participants = %q, want %q", got, tt.logins)
+			}
+		})
+	}
+}
+
+// Each kind of response and the reactions on threads and responses are counted for
+// the participant who posted them, under the first spelling of their login
+func TestSummarizeEngagementCounts(t *testing.T) {
+	e := SummarizeEngagement([]Thread{
+		{Author: "alice", Reactions: 3, Responses: []Response{
+			{Kind: ResponseComment, Author: "bob", Reactions: 2},
+			{Kind: ResponseReview, Author: "BOB"},
+			{Kind: ResponseReviewComment, Author: "bob", Reactions: 1},
+			{Kind: ResponseComment, Author: "alice", Reactions: 1},
+		}},
+		{Author: "Bob", Responses: []Response{{Kind: ResponseDiscussionComment, Author: "alice"}}},
+	})
+
+	want := []models.ContributorEngagement{
+		{Login: "alice", Threads: 1, Comments: 1, DiscussionComments: 1, ReactionsReceived: 4},
+		{Login: "bob", Threads: 1, Comments: 1, ReviewComments: 1, Reviews: 1, ReactionsReceived: 3},
+	}
+	if len(e.Contributors) != len(want) {
+		t.Fatalf("contributors = %+v, want %+v", e.Contributors, want)
+	}
+	for i := range want {
+		if e.Contributors[i] != want[i] {
+			t.Errorf("contributor %d = %+v, want %+v", i, e.Contributors[i], want[i])
+		}
+	}
+}
+
+// Without threads the lists are empty rather than null in JSON
+func TestSummarizeEngagementEmpty(t *testing.T) {
+	data, err := json.Marshal(SummarizeEngagement(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"threads":0,"responded_threads":0,"contributors":[],"collaboration":[]}`; string(data) != want {
+		t.Errorf("engagement = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,220 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github-extractor/models"
+)
+
+// MaxPullRequests is the number of most recent closed pull requests an extraction inspects
+const MaxPullRequests = 1000
+
+// RecentDays is the window in which contributors count as recent and are selected by
+// DefaultSelection
+const RecentDays = 90
+
+// FillCommunity completes info, whose repository fields the caller has already set, with
+// the contributor, pull request and follow graph data every source provides. Contributors
+// are selected with the policy attached to ctx (DefaultSelection when none is), and only
+// selected contributors with a location are kept.
+// Engagement is summarized from up to MaxThreads recent threads when EngagementFrom(ctx)
+// allows it. The first failure is recorded in info.Error.
+func FillCommunity(ctx context.Context, src RepositorySource, info *models.RepositoryInfo) {
+	owner, repo := info.Owner, info.Repo
+	policy := SelectionFrom(ctx)
+	info.Selection = models.ContributorSelection{Policy: policy, Logins: []string{}}
+
+	var wg sync.WaitGroup
+	var contributorErr, recentErr, statsErr, prErr, threadErr error
+	var contributors []Contributor
+	var recent []string
+	var total, nonAnon int
+	var stats []models.ContributorStats
+	var prs []models.PullRequestInfo
+	var threads []Thread
+
+	wg.Add(5)
+	go func() {
+		defer wg.Done()
+		contributors, total, nonAnon, contributorErr = src.Contributors(ctx, owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		if policy.Days > 0 {
+			recent, recentErr = src.RecentContributors(ctx, owner, repo, policy.Days)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		stats, statsErr = src.ContributorStats(ctx, owner, repo)
+	}()
+	go func() {
+		defer wg.Done()
+		prs, prErr = src.PullRequests(ctx, owner, repo, MaxPullRequests)
+	}()
+	go func() {
+		defer wg.Done()
+		if EngagementFrom(ctx) {
+			threads, threadErr = src.Threads(ctx, owner, repo, MaxThreads)
+		}
+	}()
+	wg.Wait()
+
+	setError := func(format string, args ...interface{}) {
+		if info.Error == "" {
+			info.Error = fmt.Sprintf(format, args...)
+		}
+	}
+
+	if statsErr != nil || stats == nil {
+		info.ContributorStats = []models.ContributorStats{}
+	} else {
+		info.ContributorStats = stats
+	}
+	if prErr == nil {
+		info.PullRequests = prs
+	}
+	if threadErr != nil {
+		threads = nil
+	}
+	info.Engagement = SummarizeEngagement(threads)
+
+	if contributorErr != nil {
+		setError("Failed to fetch contributors: %v", contributorErr)
+		return
+	}
+	info.TotalContributorsCount = total
+	info.NonAnonymousContributorsCount = nonAnon
+
+	if recentErr != nil {
+		setError("Failed to fetch recent contributors: %v", recentErr)
+		recent = nil
+	}
+	selected := SelectContributors(policy, contributors, total, recent)
+	info.SelectedContributorsCount = len(selected)
+	info.Selection.Logins = selected
+
+	details, err := src.ContributorDetails(ctx, selected)
+	if err != nil {
+		setError("Failed to fetch contributor details: %v", err)
+		info.Contributors = nil
+		info.ContributorsWithLocationCount = 0
+		return
+	}
+
+	// Follow edges are looked up by account login, which for some hosts differs from
+	// the identity contributors were selected by. Several identities (such as two
+	// emails) can resolve to the same account, which is kept once.
+	var logins []string
+	seen := make(map[string]struct{}, len(details))
+	unique := details[:0]
+	for _, d := range details {
+		if d.Error == "" && d.Login != "" {
+			key := strings.ToLower(d.Login)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			logins = append(logins, d.Login)
+		}
+		unique = append(unique, d)
+	}
+	details = unique
+
+	// A partial follow graph still gives useful counts, so its error is not recorded
+	graph, _ := src.FollowGraph(ctx, logins)
+	followers, following := CountFollows(graph)
+	ApplyFollowCounts(details, followers, following)
+	info.FollowGraph = graph
+
+	info.Contributors = WithLocation(details)
+	info.ContributorsWithLocationCount = len(info.Contributors)
+}
+
+// ApplyFollowCounts sets the community follower and following counts of each contributor
+func ApplyFollowCounts(details []models.ContributorDetail, followers, following map[string]int) {
+	for i := range details {
+		key := strings.ToLower(details[i].Login)
+		details[i].Followers = followers[key]
+		details[i].Following = following[key]
+		if details[i].Following > 0 {
+			details[i].FollowerFollowingRatio = float64(details[i].Followers) / float64(details[i].Following)
+		} else {
+			details[i].FollowerFollowingRatio = 0
+		}
+	}
+}
+
+// WithLocation keeps the contributors that set a location on their profile
+func WithLocation(details []models.ContributorDetail) []models.ContributorDetail {
+	var withLocation []models.ContributorDetail
+	for _, d := range details {
+		if d.Location != "" {
+			withLocation = append(withLocation, d)
+		}
+	}
+	return withLocation
+}
+
+// FollowEdges turns each member's following list into the follow graph among members,
+// keeping only edges between members and spelling logins as in members. Members missing
+// from followingLists are counted as following nobody.
+func FollowEdges(members []string, followingLists map[string][]string) models.FollowGraph {
+	community := make(map[string]string, len(members))
+	for _, m := range members {
+		community[strings.ToLower(m)] = m
+	}
+
+	graph := models.FollowGraph{Members: append([]string{}, members...), Edges: []models.FollowEdge{}}
+	for member, list := range followingLists {
+		self := strings.ToLower(member)
+		follower, ok := community[self]
+		if !ok {
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, target := range list {
+			key := strings.ToLower(target)
+			if key == self {
+				continue
+			}
+			followed, ok := community[key]
+			if !ok {
+				continue
+			}
+			if _, dup := seen[key]; !dup {
+				seen[key] = struct{}{}
+				graph.Edges = append(graph.Edges, models.FollowEdge{Follower: follower, Followed: followed})
+			}
+		}
+	}
+
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Follower != graph.Edges[j].Follower {
+			return graph.Edges[i].Follower < graph.Edges[j].Follower
+		}
+		return graph.Edges[i].Followed < graph.Edges[j].Followed
+	})
+	return graph
+}
+
+// CountFollows returns, for each member of graph, how many other members follow it and
+// how many it follows. Both maps are keyed by lowercased login.
+func CountFollows(graph models.FollowGraph) (map[string]int, map[string]int) {
+	followers := make(map[string]int, len(graph.Members))
+	following := make(map[string]int, len(graph.Members))
+	for _, m := range graph.Members {
+		key := strings.ToLower(m)
+		followers[key] = 0
+		following[key] = 0
+	}
+	for _, e := range graph.Edges {
+		followers[strings.ToLower(e.Followed)]++
+		following[strings.ToLower(e.Follower)]++
+	}
+	return followers, following
+}
@@ -0,0 +1,146 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github-extractor/models"
+)
+
+// Contributor selection strategies
+const (
+	SelectAll        = "all"        // Every contributor
+	SelectTop        = "top"        // The N contributors with the most contributions
+	SelectSqrt       = "sqrt"       // The top ceil(sqrt(total)) contributors
+	SelectPercentile = "percentile" // The contributors whose contributions reach a percentile of all counts
+	SelectActive     = "active"     // Only the contributors who committed recently
+)
+
+// DefaultSelection is the top sqrt(total) contributors plus those active in the last
+// RecentDays days
+var DefaultSelection = models.SelectionPolicy{Strategy: SelectSqrt, Days: RecentDays}
+
+type selectionKey struct{}
+
+// WithSelection returns a copy of ctx that makes extractions select contributors with p
+func WithSelection(ctx context.Context, p models.SelectionPolicy) context.Context {
+	return context.WithValue(ctx, selectionKey{}, p)
+}
+
+// SelectionFrom returns the policy attached to ctx with WithSelection, or DefaultSelection
+func SelectionFrom(ctx context.Context) models.SelectionPolicy {
+	if p, ok := ctx.Value(selectionKey{}).(models.SelectionPolicy); ok {
+		return p
+	}
+	return DefaultSelection
+}
+
+// ValidateSelection checks that p names a known strategy with the parameters it needs
+func ValidateSelection(p models.SelectionPolicy) error {
+	if p.Days < 0 {
+		return fmt.Errorf("selection days must not be negative")
+	}
+	switch p.Strategy {
+	case SelectAll, SelectSqrt:
+	case SelectTop:
+		if p.N <= 0 {
+			return fmt.Errorf("selection n must be greater than 0 for the top strategy")
+		}
+	case SelectPercentile:
+		if p.Percentile < 0 || p.Percentile >= 100 {
+			return fmt.Errorf("selection percentile must be in [0, 100)")
+		}
+	case SelectActive:
+		if p.Days == 0 {
+			return fmt.Errorf("selection days must be greater than 0 for the active strategy")
+		}
+	default:
+		return fmt.Errorf("unknown selection strategy %q, expected all, top, sqrt, percentile or active", p.Strategy)
+	}
+	return nil
+}
+
+// Contributor is a contributor and its number of contributions, commits on most hosts
+type Contributor struct {
+	Login         string
+	Contributions int
+}
+
+// SelectionLimit returns how many of the top contributors p keeps out of total, before
+// the recent ones are added. For the percentile strategy, which depends on the
+// contribution counts, it is the share of contributors above the percentile; ties at
+// the threshold can select more.
+func SelectionLimit(p models.SelectionPolicy, total int) int {
+	switch p.Strategy {
+	case SelectAll:
+		return total
+	case SelectTop:
+		return min(p.N, total)
+	case SelectPercentile:
+		return int(math.Ceil(float64(total) * (100 - p.Percentile) / 100))
+	case SelectActive:
+		return 0
+	default:
+		return int(math.Ceil(math.Sqrt(float64(total))))
+	}
+}
+
+// SelectContributors returns the top contributors p keeps, ranked against total
+// contributors, followed by the recent ones that are not already among them.
+// contributors are ordered by contributions, most first.
+func SelectContributors(p models.SelectionPolicy, contributors []Contributor, total int, recent []string) []string {
+	limit := SelectionLimit(p, total)
+	if p.Strategy == SelectPercentile {
+		limit = aboveThreshold(contributors, percentileThreshold(contributors, p.Percentile))
+	}
+	if limit > len(contributors) {
+		limit = len(contributors)
+	}
+
+	seen := make(map[string]struct{}, limit+len(recent))
+	selected := []string{}
+	add := func(u string) {
+		if _, ok := seen[u]; !ok {
+			seen[u] = struct{}{}
+			selected = append(selected, u)
+		}
+	}
+	for _, c := range contributors[:limit] {
+		add(c.Login)
+	}
+	for _, u := range recent {
+		add(u)
+	}
+	return selected
+}
+
+// percentileThreshold returns the nearest-rank percentile of the contribution counts:
+// the smallest count that at least percentile percent of the contributors have at most
+func percentileThreshold(contributors []Contributor, percentile float64) int {
+	if len(contributors) == 0 {
+		return 0
+	}
+	counts := make([]int, len(contributors))
+	for i, c := range contributors {
+		counts[i] = c.Contributions
+	}
+	sort.Ints(counts)
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(counts))))
+	if rank < 1 {
+		rank = 1
+	}
+	return counts[rank-1]
+}
+
+// aboveThreshold returns how many of the leading contributors have at least threshold
+// contributions
+func aboveThreshold(contributors []Contributor, threshold int) int {
+	n := 0
+	for n < len(contributors) && contributors[n].Contributions >= threshold {
+		n++
+	}
+	return n
+}
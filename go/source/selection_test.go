@@ -0,0 +1,166 @@
+package source
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github-extractor/models"
+)
+
+// ranked returns contributors c1, c2, ... with the given contribution counts
+func ranked(counts ...int) []Contributor {
+	contributors := make([]Contributor, len(counts))
+	for i, n := range counts {
+		contributors[i] = Contributor{Login: "c" + strconv.Itoa(i+1), Contributions: n}
+	}
+	return contributors
+}
+
+func TestSelectionLimit(t *testing.T) {
+	tests := []struct {
+		policy models.SelectionPolicy
+		total  int
+		want   int
+	}{
+		{models.SelectionPolicy{Strategy: SelectAll}, 42, 42},
+		{models.SelectionPolicy{Strategy: SelectTop, N: 10}, 42, 10},
+		{models.SelectionPolicy{Strategy: SelectTop, N: 100}, 42, 42},
+		{models.SelectionPolicy{Strategy: SelectSqrt}, 42, 7},
+		{models.SelectionPolicy{Strategy: SelectSqrt}, 49, 7},
+		{models.SelectionPolicy{Strategy: SelectSqrt}, 0, 0},
+		{models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 90}, 42, 5},
+		{models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 0}, 42, 42},
+		{models.SelectionPolicy{Strategy: SelectActive, Days: 30}, 42, 0},
+	}
+	for _, tt := range tests {
+		if got := SelectionLimit(tt.policy, tt.total); got != tt.want {
+			t.Errorf("SelectionLimit(%+v, %d) = %d, want %d", tt.policy, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestSelectContributors(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       models.SelectionPolicy
+		contributors []Contributor
+		total        int // Defaults to len(contributors)
+		recent       []string
+		want         string
+	}{
+		{
+			name:         "all",
+			policy:       models.SelectionPolicy{Strategy: SelectAll},
+			contributors: ranked(9, 5, 1),
+			want:         "c1 c2 c3",
+		},
+		{
+			name:         "top n",
+			policy:       models.SelectionPolicy{Strategy: SelectTop, N: 2},
+			contributors: ranked(9, 5, 1),
+			want:         "c1 c2",
+		},
+		{
+			name:         "sqrt of the total including anonymous contributors",
+			policy:       models.SelectionPolicy{Strategy: SelectSqrt},
+			contributors: ranked(9, 5, 4, 3, 2, 1),
+			total:        10,
+			want:         "c1 c2 c3 c4",
+		},
+		{
+			name:         "limit above the listed contributors",
+			policy:       models.SelectionPolicy{Strategy: SelectSqrt},
+			contributors: ranked(9),
+			total:        100,
+			want:         "c1",
+		},
+		{
+			name:         "percentile of the commit counts",
+			policy:       models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 75},
+			contributors: ranked(100, 50, 10, 9, 8, 7, 6, 5),
+			want:         "c1 c2 c3",
+		},
+		{
+			name:         "percentile keeps ties at the threshold",
+			policy:       models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 50},
+			contributors: ranked(10, 3, 3, 3, 3, 1),
+			want:         "c1 c2 c3 c4 c5",
+		},
+		{
+			name:         "percentile is not a share of the ranks",
+			policy:       models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 90},
+			contributors: ranked(100, 90, 80, 70, 60, 50, 40, 30, 20, 10),
+			want:         "c1 c2",
+		},
+		{
+			name:         "zero percentile keeps everyone",
+			policy:       models.SelectionPolicy{Strategy: SelectPercentile},
+			contributors: ranked(9, 5, 1),
+			want:         "c1 c2 c3",
+		},
+		{
+			name:         "recent contributors are added once",
+			policy:       models.SelectionPolicy{Strategy: SelectTop, N: 1, Days: 30},
+			contributors: ranked(9, 5, 1),
+			recent:       []string{"c3", "c1", "newcomer", "c3"},
+			want:         "c1 c3 newcomer",
+		},
+		{
+			name:         "active selects only the recent contributors",
+			policy:       models.SelectionPolicy{Strategy: SelectActive, Days: 30},
+			contributors: ranked(9, 5, 1),
+			recent:       []string{"c2"},
+			want:         "c2",
+		},
+		{
+			name:   "nothing to select",
+			policy: models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 50},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := tt.total
+			if total == 0 {
+				total = len(tt.contributors)
+			}
+			got := SelectContributors(tt.policy, tt.contributors, total, tt.recent)
+			if got == nil {
+				t.Fatal("selection is nil, want an empty list")
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("selected %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSelection(t *testing.T) {
+	tests := []struct {
+		policy  models.SelectionPolicy
+		wantErr string
+	}{
+		{DefaultSelection, ""},
+		{models.SelectionPolicy{Strategy: SelectAll}, ""},
+		{models.SelectionPolicy{Strategy: SelectTop}, "n must be greater than 0"},
+		{models.SelectionPolicy{Strategy: SelectPercentile, Percentile: 100}, "percentile must be in [0, 100)"},
+		{models.SelectionPolicy{Strategy: SelectPercentile, Percentile: -1}, "percentile must be in [0, 100)"},
+		{models.SelectionPolicy{Strategy: SelectActive}, "days must be greater than 0"},
+		{models.SelectionPolicy{Strategy: SelectSqrt, Days: -1}, "days must not be negative"},
+		{models.SelectionPolicy{Strategy: "random"}, "unknown selection strategy"},
+	}
+	for _, tt := range tests {
+		err := ValidateSelection(tt.policy)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateSelection(%+v) = %v, want nil", tt.policy, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateSelection(%+v) = %v, want an error containing %q", tt.policy, err, tt.wantErr)
+		}
+	}
+}
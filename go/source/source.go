@@ -0,0 +1,111 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github-extractor/models"
+)
+
+// DefaultHost is used when a request does not name a host
+const DefaultHost = "github.com"
+
+// ErrUnknownHost is returned for hosts no source is registered for
+var ErrUnknownHost = errors.New("unknown repository host")
+
+// RepositorySource extracts repository data from a code hosting service. Every
+// implementation fills models.RepositoryInfo the same way, so the metrics pipeline
+// does not depend on where a repository is hosted.
+type RepositorySource interface {
+	// Host is the hostname requests select the source with, e.g. "gitlab.com"
+	Host() string
+
+	// GetRepositoryInfo fetches everything the metrics need about a repository.
+	// Failures are reported in RepositoryInfo.Error.
+	GetRepositoryInfo(ctx context.Context, owner, repo string) models.RepositoryInfo
+
+	// CheckRepoEligibility checks that a repository has at least minCommits commits and
+	// minActive distinct authors in the last days days, returning the reason when not.
+	CheckRepoEligibility(ctx context.Context, owner, repo string, minCommits, days, minActive int) (bool, string, error)
+
+	// Contributors returns the contributors ordered by contributions, the total number
+	// of contributors and how many of them are linked to an account.
+	Contributors(ctx context.Context, owner, repo string) ([]Contributor, int, int, error)
+
+	// RecentContributors returns the logins that committed in the last days days
+	RecentContributors(ctx context.Context, owner, repo string, days int) ([]string, error)
+
+	// ContributorStats returns the commit totals and weekly activity of every contributor
+	ContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error)
+
+	// PullRequests returns up to max closed pull (or merge) requests, newest first
+	PullRequests(ctx context.Context, owner, repo string, max int) ([]models.PullRequestInfo, error)
+
+	// ContributorDetails fetches the public profile of each login
+	ContributorDetails(ctx context.Context, logins []string) ([]models.ContributorDetail, error)
+
+	// Threads returns up to max of the most recently updated issues and pull requests,
+	// and up to max discussions where the host has them, with their comments and reviews
+	Threads(ctx context.Context, owner, repo string, max int) ([]Thread, error)
+
+	// FollowGraph returns which of the logins follow which others. A partial graph is
+	// returned along with the error when some following lists could not be read.
+	FollowGraph(ctx context.Context, logins []string) (models.FollowGraph, error)
+}
+
+// Registry maps hosts to the source serving them
+type Registry struct {
+	sources map[string]RepositorySource
+}
+
+// NewRegistry creates a registry holding the given sources
+func NewRegistry(sources ...RepositorySource) *Registry {
+	r := &Registry{sources: make(map[string]RepositorySource)}
+	for _, s := range sources {
+		r.Register(s)
+	}
+	return r
+}
+
+// Register adds a source, replacing any source already registered for its host
+func (r *Registry) Register(s RepositorySource) {
+	r.sources[NormalizeHost(s.Host())] = s
+}
+
+// Get returns the source for host; an empty host selects DefaultHost
+func (r *Registry) Get(host string) (RepositorySource, error) {
+	if host == "" {
+		host = DefaultHost
+	}
+	s, ok := r.sources[NormalizeHost(host)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHost, host)
+	}
+	return s, nil
+}
+
+// Hosts returns the registered hosts in alphabetical order
+func (r *Registry) Hosts() []string {
+	hosts := make([]string, 0, len(r.sources))
+	for h := range r.sources {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// NormalizeHost lowercases a host and strips any scheme, path or trailing slash,
+// so "https://GitLab.com/" and "gitlab.com" select the same source
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+	"github-extractor/source"
+)
+
+// analysesBucket holds one nested bucket per repository, keyed by "host/owner/repo"
+var analysesBucket = []byte("analyses")
+
+// summariesBucket mirrors analysesBucket with the AnalysisSummary of every analysis, so
+// listing a repository's history does not decode the repository snapshots
+var summariesBucket = []byte("summaries")
+
+// ErrNotFound is returned when an analysis does not exist
+var ErrNotFound = errors.New("analysis not found")
+
+// Analysis is a stored repository snapshot together with the metrics computed from it
+type Analysis struct {
+	ID         string                    `json:"id"`
+	Host       string                    `json:"host,omitempty"` // Empty for github.com
+	Owner      string                    `json:"owner"`
+	Repo       string                    `json:"repo"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	Parameters json.RawMessage           `json:"parameters,omitempty" swaggertype:"object"` // Request parameters the analysis was run with
+	Repository models.RepositoryInfo     `json:"repository"`
+	Result     *grpcclient.ProcessResult `json:"result,omitempty"`
+}
+
+// AnalysisSummary is the listing view of an Analysis, without the repository snapshot
+type AnalysisSummary struct {
+	ID         string                    `json:"id"`
+	Host       string                    `json:"host,omitempty"`
+	Owner      string                    `json:"owner"`
+	Repo       string                    `json:"repo"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	Parameters json.RawMessage           `json:"parameters,omitempty" swaggertype:"object"`
+	Result     *grpcclient.ProcessResult `json:"result,omitempty"`
+}
+
+// Store persists analyses in an embedded BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (or creates) the store file at path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(analysesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(summariesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize store %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the store file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores an analysis, assigning its ID and creation time when unset
+func (s *Store) Save(a *Analysis) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	if a.ID == "" {
+		id, err := newID(a.CreatedAt)
+		if err != nil {
+			return err
+		}
+		a.ID = id
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("encode analysis: %w", err)
+	}
+	summary, err := json.Marshal(a.Summary())
+	if err != nil {
+		return fmt.Errorf("encode analysis summary: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key := repoKey(a.Host, a.Owner, a.Repo)
+		b, err := tx.Bucket(analysesBucket).CreateBucketIfNotExists(key)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(a.ID), data); err != nil {
+			return err
+		}
+		sb, err := tx.Bucket(summariesBucket).CreateBucketIfNotExists(key)
+		if err != nil {
+			return err
+		}
+		return sb.Put([]byte(a.ID), summary)
+	})
+}
+
+// Summary returns the listing view of the analysis
+func (a *Analysis) Summary() AnalysisSummary {
+	return AnalysisSummary{
+		ID:         a.ID,
+		Host:       a.Host,
+		Owner:      a.Owner,
+		Repo:       a.Repo,
+		CreatedAt:  a.CreatedAt,
+		Parameters: a.Parameters,
+		Result:     a.Result,
+	}
+}
+
+// List returns the summaries of the analyses of a repository, newest first
+func (s *Store) List(host, owner, repo string) ([]AnalysisSummary, error) {
+	summaries := []AnalysisSummary{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(summariesBucket).Bucket(repoKey(host, owner, repo))
+		if b == nil {
+			return nil
+		}
+
+		// IDs start with the creation time, so walking backwards yields newest first
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var summary AnalysisSummary
+			if err := json.Unmarshal(v, &summary); err != nil {
+				return fmt.Errorf("decode analysis %s: %w", k, err)
+			}
+			summaries = append(summaries, summary)
+		}
+		return nil
+	})
+
+	return summaries, err
+}
+
+// Get returns a single analysis of a repository
+func (s *Store) Get(host, owner, repo, id string) (*Analysis, error) {
+	var a Analysis
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(analysesBucket).Bucket(repoKey(host, owner, repo))
+		if b == nil {
+			return ErrNotFound
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &a)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// Delete removes a single analysis of a repository
+func (s *Store) Delete(host, owner, repo, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key := repoKey(host, owner, repo)
+		b := tx.Bucket(analysesBucket).Bucket(key)
+		if b == nil || b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		if err := b.Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(summariesBucket).Bucket(key).Delete([]byte(id))
+	})
+}
+
+// repoKey returns the case-insensitive bucket key of a repository, where an empty host
+// is github.com
+func repoKey(host, owner, repo string) []byte {
+	if host == "" {
+		host = source.DefaultHost
+	}
+	return []byte(strings.ToLower(host + "/" + owner + "/" + repo))
+}
+
+// newID returns an identifier that sorts by creation time
+func newID(t time.Time) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate analysis id: %w", err)
+	}
+	return fmt.Sprintf("%016x%s", t.UnixNano(), hex.EncodeToString(b)), nil
+}
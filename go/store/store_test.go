@@ -0,0 +1,91 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github-extractor/grpcclient"
+	"github-extractor/models"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestListReadsSummaries(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "yoshi.db"))
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		a := &Analysis{
+			Owner:      "acme",
+			Repo:       "widget",
+			CreatedAt:  start.AddDate(0, 0, i),
+			Repository: models.RepositoryInfo{Owner: "acme", Repo: "widget", Commits: 100 * (i + 1)},
+			Result:     &grpcclient.ProcessResult{Formality: float64(i)},
+		}
+		if err := s.Save(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := s.List("", "Acme", "Widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || !list[0].CreatedAt.Equal(start.AddDate(0, 0, 2)) || list[0].Result.Formality != 2 {
+		t.Fatalf("list = %+v, want 3 analyses newest first", list)
+	}
+
+	// The summaries are stored apart: listing never reads the snapshots
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(analysesBucket).Bucket(repoKey("", "acme", "widget")).Put([]byte(list[1].ID), []byte("not json"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List("", "acme", "widget"); err != nil {
+		t.Errorf("list decoded a snapshot: %v", err)
+	}
+
+	if err := s.Delete("", "acme", "widget", list[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List("", "acme", "widget"); len(list) != 2 {
+		t.Errorf("%d summaries after a deletion, want 2", len(list))
+	}
+}
+
+// An empty host is github.com, and each other host keeps its own history
+func TestListByHost(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), "yoshi.db"))
+	for _, host := range []string{"", "gitlab.com"} {
+		if err := s.Save(&Analysis{Host: host, Owner: "acme", Repo: "widget"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct{ host, want string }{
+		{"", ""},
+		{"github.com", ""},
+		{"GitLab.com", "gitlab.com"},
+	}
+	for _, tt := range tests {
+		list, err := s.List(tt.host, "acme", "widget")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].Host != tt.want {
+			t.Errorf("host %q: list = %+v, want the analysis saved with host %q", tt.host, list, tt.want)
+		}
+	}
+}
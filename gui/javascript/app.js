@@ -6,6 +6,7 @@ const repoInput = document.getElementById("repo");
 const submitBtn = document.getElementById("submit-btn");
 const btnText = submitBtn.querySelector(".btn-text");
 const spinner = submitBtn.querySelector(".spinner");
+const progressText = document.getElementById("progress-text");
 const resultsCard = document.getElementById("results-card");
 const errorCard = document.getElementById("error-card");
 const errorMessage = document.getElementById("error-message");
@@ -123,7 +124,7 @@ form.addEventListener("submit", async (e) => {
     const minActive = parseNullableInt(minActiveInput.value);
 
     try {
-      res = await fetch(`${API_BASE}/process`, {
+      res = await fetch(`${API_BASE}/jobs`, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify({
@@ -138,10 +139,17 @@ form.addEventListener("submit", async (e) => {
       throw new Error("Unable to reach the server. Is the backend running?");
     }
 
-    const data = await res.json();
+    const job = await res.json();
+
+    if (!res.ok || job.error) {
+      throw new Error(job.error || `Request failed (${res.status})`);
+    }
 
-    if (!res.ok || data.error) {
-      throw new Error(data.error || `Request failed (${res.status})`);
+    const finished = await waitForJob(job.id);
+    const data = finished.result || {};
+
+    if (finished.status !== "completed" || data.error) {
+      throw new Error(finished.error || data.error || `Job ${finished.status}`);
     }
 
     playSound(soundHome);
@@ -164,6 +172,30 @@ function setLoading(loading) {
   submitBtn.disabled = loading;
   btnText.textContent = loading ? "Processing\u2026" : "Process";
   spinner.classList.toggle("hidden", !loading);
+  progressText.textContent = "";
+  progressText.classList.toggle("hidden", !loading);
+}
+
+// Follows the job progress stream and resolves with the finished job.
+function waitForJob(id) {
+  return new Promise((resolve, reject) => {
+    const source = new EventSource(`${API_BASE}/jobs/${id}/events`);
+
+    source.addEventListener("progress", (e) => {
+      const event = JSON.parse(e.data);
+      progressText.textContent = event.message;
+    });
+
+    source.addEventListener("done", (e) => {
+      source.close();
+      resolve(JSON.parse(e.data));
+    });
+
+    source.onerror = () => {
+      source.close();
+      reject(new Error("Lost connection to the server while processing."));
+    };
+  });
 }
 
 function showResults(data) {
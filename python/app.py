@@ -18,7 +18,15 @@ sys.path.insert(0, os.path.join(os.path.dirname(__file__), 'generated'))
 
 import processor_pb2
 import processor_pb2_grpc
-from calculators import CohesionCalculator, FormalityCalculator, GeodispersionCalculator, LongevityCalculator
+from calculators import (
+    CohesionCalculator,
+    EngagementCalculator,
+    FormalityCalculator,
+    GeodispersionCalculator,
+    LongevityCalculator,
+    StructureCalculator,
+)
+from repository import repository_data
 
 # Logger will be configured in main
 logger = logging.getLogger(__name__)
@@ -60,110 +68,65 @@ class ProcessorServicer(processor_pb2_grpc.ProcessorServiceServicer):
             repo = request.repository
             logger.info(f"Repository: {repo.owner}/{repo.repo}")
             
-            # Convert contributors from proto to dict list
-            contributors_data = []
-            for contributor in repo.contributors:
-                contributors_data.append({
-                    "login": contributor.login,
-                    "id": contributor.id,
-                    "node_id": contributor.node_id,
-                    "avatar_url": contributor.avatar_url,
-                    "html_url": contributor.html_url,
-                    "type": contributor.type,
-                    "name": contributor.name,
-                    "company": contributor.company,
-                    "blog": contributor.blog,
-                    "location": contributor.location,
-                    "email": contributor.email,
-                    "bio": contributor.bio,
-                    "created_at": contributor.created_at,
-                    "updated_at": contributor.updated_at,
-                    "followers": contributor.followers,
-                    "following": contributor.following,
-                    "follower_following_ratio": contributor.follower_following_ratio,
-                })
-            
-            # Convert contributor_stats from proto to dict list
-            contributor_stats_data = []
-            for stat in repo.contributor_stats:
-                # Convert weeks data
-                weeks_data = []
-                for week in stat.weeks:
-                    weeks_data.append({
-                        "week": week.week,
-                        "additions": week.additions,
-                        "deletions": week.deletions,
-                        "commits": week.commits,
-                    })
-                
-                contributor_stats_data.append({
-                    "author": stat.author,
-                    "total": stat.total,
-                    "weeks": weeks_data,
-                    "first_commit": stat.first_commit,
-                    "last_commit": stat.last_commit,
-                })
-            
-            # Convert pull requests from proto to dict list
-            pull_requests_data = []
-            for pr in repo.pull_requests:
-                pull_requests_data.append({
-                    "number": pr.number,
-                    "status": pr.status,
-                    "merged_at": pr.merged_at,
-                })
-            
-            # Convert proto message to dictionary for calculators
-            repo_data = {
-                "repository": {
-                    "owner": repo.owner,
-                    "repo": repo.repo,
-                    "description": repo.description,
-                    "has_code_of_conduct": repo.has_code_of_conduct,
-                    "has_readme": repo.has_readme,
-                    "has_description": repo.has_description,
-                    "has_contributing_guidelines": repo.has_contributing_guidelines,
-                    "has_license": repo.has_license,
-                    "has_security_policy": repo.has_security_policy,
-                    "has_issues_template": repo.has_issues_template,
-                    "has_pull_request_template": repo.has_pull_request_template,
-                    "has_wiki_page": repo.has_wiki_page,
-                    "has_milestones": repo.has_milestones,
-                    "contributors": contributors_data,
-                    "contributor_stats": contributor_stats_data,
-                    "pull_requests": pull_requests_data,
-                }
-            }
-            
+            repo_data = repository_data(repo)
+
             # Compute formality metric
-            formality_score = FormalityCalculator.compute(repo_data)
+            formality = FormalityCalculator.breakdown(repo_data)
+            formality_score = formality.pop("formality")
             logger.info(f"Computed formality score: {formality_score}")
             
             # Compute geodispersion metric
-            geodispersion_score = GeodispersionCalculator.compute(repo_data)
+            geodispersion = GeodispersionCalculator.breakdown(repo_data)
+            geodispersion_score = geodispersion.pop("geodispersion")
             logger.info(f"Computed geodispersion score: {geodispersion_score}")
             
             # Compute longevity metric
-            longevity_score = LongevityCalculator.compute(repo_data)
+            longevity = LongevityCalculator.breakdown(repo_data)
+            longevity_score = longevity.pop("longevity")
             logger.info(f"Computed longevity score: {longevity_score}")
 
             # Compute cohesion metric
-            cohesion_score = CohesionCalculator.compute(repo_data)
+            cohesion = CohesionCalculator.breakdown(repo_data)
+            cohesion_score = cohesion.pop("cohesion")
             logger.info(f"Computed cohesion score: {cohesion_score}")
+
+            # Compute engagement and structure metrics
+            engagement = EngagementCalculator.breakdown(repo_data)
+            engagement_score = engagement.pop("engagement")
+            logger.info(f"Computed engagement score: {engagement_score}")
+
+            structure = StructureCalculator.breakdown(repo_data)
+            structure_score = structure.pop("structure")
+            logger.info(f"Computed structure score: {structure_score}")
             
-            # Return response with all metrics
+            # Return response with all metrics and their sub-components
+            geodispersion["matched_locations"] = [
+                processor_pb2.MatchedLocation(**loc) for loc in geodispersion["matched_locations"]
+            ]
             return processor_pb2.ProcessResponse(
                 formality=formality_score,
                 geodispersion=geodispersion_score,
                 longevity=longevity_score,
-                cohesion=cohesion_score
+                cohesion=cohesion_score,
+                engagement=engagement_score,
+                structure=structure_score,
+                breakdown=processor_pb2.MetricBreakdown(
+                    formality=processor_pb2.FormalityBreakdown(**formality),
+                    geodispersion=processor_pb2.GeodispersionBreakdown(**geodispersion),
+                    longevity=processor_pb2.LongevityBreakdown(**longevity),
+                    cohesion=processor_pb2.CohesionBreakdown(**cohesion),
+                    engagement=processor_pb2.EngagementBreakdown(**engagement),
+                    structure=processor_pb2.StructureBreakdown(**structure),
+                ),
             )
             
         except Exception as e:
             logger.error(f"Processing error: {str(e)}", exc_info=True)
             context.set_code(grpc.StatusCode.INTERNAL)
             context.set_details(f"Processing error: {str(e)}")
-            return processor_pb2.ProcessResponse(formality=0.0, geodispersion=0.0, longevity=0.0, cohesion=0.0)
+            return processor_pb2.ProcessResponse(
+                formality=0.0, geodispersion=0.0, longevity=0.0, cohesion=0.0, engagement=0.0, structure=0.0
+            )
 
 
 def serve(log_level=logging.INFO):
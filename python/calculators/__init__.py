@@ -6,5 +6,10 @@ from .formality_calculator import FormalityCalculator
 from .longevity_calculator import LongevityCalculator
 from .geodispersion_calculator import GeodispersionCalculator
 from .cohesion_calculator import CohesionCalculator
+from .engagement_calculator import EngagementCalculator
+from .structure_calculator import StructureCalculator
 
-__all__ = ['FormalityCalculator', 'LongevityCalculator', 'GeodispersionCalculator', 'CohesionCalculator']
+__all__ = [
+    'FormalityCalculator', 'LongevityCalculator', 'GeodispersionCalculator', 'CohesionCalculator',
+    'EngagementCalculator', 'StructureCalculator',
+]
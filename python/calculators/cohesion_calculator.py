@@ -40,12 +40,34 @@ class CohesionCalculator:
         Returns:
             float: Cohesion score in [0, 1].
         """
+        return cls.breakdown(repo_data)["cohesion"]
+
+    @classmethod
+    def breakdown(cls, repo_data: Dict) -> Dict:
+        """
+        Compute cohesion together with the degree sums it was estimated from.
+
+        Args:
+            repo_data: Dictionary containing repository information.
+
+        Returns:
+            dict: cohesion, contributors, following, followers, estimated_edges
+            and possible_edges.
+        """
         repo = repo_data.get("repository", repo_data)
         contributors: List[Dict] = repo.get("contributors", [])
 
         n = len(contributors)
+        result = {
+            "cohesion": 0.0,
+            "contributors": n,
+            "following": 0,
+            "followers": 0,
+            "estimated_edges": 0.0,
+            "possible_edges": 0,
+        }
         if n < 2:
-            return 0.0
+            return result
 
         max_degree = n - 1
         total_following = 0
@@ -66,8 +88,15 @@ class CohesionCalculator:
         estimated_edges = (total_following + total_followers) / 2.0
         possible_edges = n * (n - 1)
 
+        result.update(
+            following=total_following,
+            followers=total_followers,
+            estimated_edges=estimated_edges,
+            possible_edges=possible_edges,
+        )
         if possible_edges <= 0:
-            return 0.0
+            return result
 
         cohesion = estimated_edges / possible_edges
-        return max(0.0, min(1.0, cohesion))
+        result["cohesion"] = max(0.0, min(1.0, cohesion))
+        return result
@@ -0,0 +1,86 @@
+"""
+Engagement Calculator
+
+Computes engagement, an attribute of the original YOSHI model, from the
+discussion activity on the most recently updated issues, pull requests and
+discussions extracted by the Go service.
+
+Engagement is the mean of four shares in [0, 1]:
+- response rate = responded threads / threads
+- participation = responders / participants, where responders replied to a
+  thread opened by someone else
+- intensity = responses / (responses + threads), 0.5 at one response per thread
+- appreciation = reactions / (reactions + posts), 0.5 at one reaction per post
+"""
+
+from typing import Dict, List
+
+
+class EngagementCalculator:
+    """Calculator for computing engagement from discussion activity."""
+
+    @classmethod
+    def compute(cls, repo_data: Dict) -> float:
+        """
+        Compute engagement from the discussion activity of the repository.
+
+        Args:
+            repo_data: Dictionary containing repository information.
+
+        Returns:
+            float: Engagement score in [0, 1].
+        """
+        return cls.breakdown(repo_data)["engagement"]
+
+    @classmethod
+    def breakdown(cls, repo_data: Dict) -> Dict:
+        """
+        Compute engagement together with its four parts and their counts.
+
+        Args:
+            repo_data: Dictionary containing repository information.
+
+        Returns:
+            dict: engagement, the four shares and the counts behind them.
+        """
+        repo = repo_data.get("repository", repo_data)
+        engagement = repo.get("engagement") or {}
+        contributors: List[Dict] = engagement.get("contributors", [])
+        collaboration: List[Dict] = engagement.get("collaboration", [])
+
+        threads = engagement.get("threads", 0)
+        responded_threads = engagement.get("responded_threads", 0)
+        responders = {edge["from"].lower() for edge in collaboration}
+        responses = sum(edge["weight"] for edge in collaboration)
+
+        reactions = 0
+        posts = 0
+        for c in contributors:
+            reactions += c.get("reactions_received", 0)
+            posts += c.get("threads", 0) + c.get("comments", 0) + c.get("review_comments", 0) + c.get("discussion_comments", 0)
+
+        result = {
+            "threads": threads,
+            "responded_threads": responded_threads,
+            "response_rate": 0.0,
+            "participants": len(contributors),
+            "responders": len(responders),
+            "participation": 0.0,
+            "responses": responses,
+            "intensity": 0.0,
+            "reactions": reactions,
+            "posts": posts,
+            "appreciation": 0.0,
+        }
+        if threads > 0:
+            result["response_rate"] = responded_threads / threads
+            result["intensity"] = responses / (responses + threads)
+        if contributors:
+            result["participation"] = len(responders) / len(contributors)
+        if reactions + posts > 0:
+            result["appreciation"] = reactions / (reactions + posts)
+
+        result["engagement"] = (
+            result["response_rate"] + result["participation"] + result["intensity"] + result["appreciation"]
+        ) / 4
+        return result
@@ -23,6 +23,19 @@ class FormalityCalculator:
         Returns:
             float: Normalized formality score between 0 and 1
         """
+        return FormalityCalculator.breakdown(repo_data)["formality"]
+
+    @staticmethod
+    def breakdown(repo_data):
+        """
+        Compute the formality score together with the flags it was computed from.
+
+        Args:
+            repo_data: Dictionary containing repository information
+
+        Returns:
+            dict: formality, present and missing flags, score and max_score
+        """
         # Extract repository object if nested
         if "repository" in repo_data:
             repo = repo_data["repository"]
@@ -30,9 +43,17 @@ class FormalityCalculator:
             repo = repo_data
             
         score = 0
+        present, missing = [], []
         for key, weight in FormalityCalculator.WEIGHTS.items():
             score += int(bool(repo.get(key, False))) * weight
+            (present if repo.get(key, False) else missing).append(key)
         
         # Normalize by sum of weights (should be 10.0)
         max_score = sum(FormalityCalculator.WEIGHTS.values())
-        return score / max_score if max_score else 0
\ No newline at end of file
+        return {
+            "formality": score / max_score if max_score else 0,
+            "present": present,
+            "missing": missing,
+            "score": score,
+            "max_score": max_score,
+        }
\ No newline at end of file
@@ -522,6 +522,20 @@ class GeodispersionCalculator:
         Returns:
             float: Geodispersion score
         """
+        return cls.breakdown(repo_data)["geodispersion"]
+
+    @classmethod
+    def breakdown(cls, repo_data: Dict) -> Dict:
+        """
+        Compute the geodispersion score together with the matched locations and
+        the distance statistics it was computed from.
+
+        Args:
+            repo_data: Dictionary containing repository information with contributors
+
+        Returns:
+            dict: geodispersion, matched_locations and the distance statistics
+        """
         # Extract repository object if nested
         if "repository" in repo_data:
             repo = repo_data["repository"]
@@ -532,9 +546,22 @@ class GeodispersionCalculator:
         
         logger.debug(f"Starting geodispersion computation for {len(contributors)} contributors")
         
+        result = {
+            "geodispersion": 0.0,
+            "contributors": len(contributors),
+            "matched_locations": [],
+            "unmatched": 0,
+            "geographic_pairs": 0,
+            "geographic_std_dev_km": 0.0,
+            "geographic": 0.0,
+            "cultural_pairs": 0,
+            "cultural_std_dev": 0.0,
+            "cultural": 0.0,
+        }
+        
         if not contributors:
             logger.debug("No contributors found, returning 0.0")
-            return 0.0
+            return result
         
         # Match contributor locations
         matched_locations = []
@@ -552,6 +579,13 @@ class GeodispersionCalculator:
             match = cls._match_location(location_str)
             if match:
                 matched_locations.append(match)
+                result["matched_locations"].append({
+                    "login": login,
+                    "location": location_str,
+                    "country": match["country"],
+                    "latitude": match["latitude"],
+                    "longitude": match["longitude"],
+                })
                 logger.debug(
                     f"  ✓ {login}: '{location_str}' → {match['country'].upper()} "
                     f"(lat: {match['latitude']:.4f}, lon: {match['longitude']:.4f})"
@@ -561,11 +595,12 @@ class GeodispersionCalculator:
                 discarded_count += 1
         
         logger.debug(f"Location matching complete: {len(matched_locations)} matched, {discarded_count} discarded")
+        result["unmatched"] = discarded_count
         
         # Need at least 2 locations to calculate distances
         if len(matched_locations) < 2:
             logger.debug(f"Insufficient matched locations ({len(matched_locations)}), need at least 2. Returning 0.0")
-            return 0.0
+            return result
         
         # Calculate geographic distances
         logger.debug("\nCalculating geographic distances...")
@@ -579,6 +614,7 @@ class GeodispersionCalculator:
         # Normalize geographic std dev to [0, 1]
         geo_std_normalized = geo_std / cls._MAX_GEO_DISTANCE_KM
         logger.debug(f"  Geographic std dev (normalized): {geo_std_normalized:.4f}")
+        result.update(geographic_pairs=len(geo_distances), geographic_std_dev_km=geo_std, geographic=geo_std_normalized)
 
         # Calculate cultural distances
         logger.debug("\nCalculating cultural distances (Hofstede)...")
@@ -592,6 +628,7 @@ class GeodispersionCalculator:
             # Normalize cultural std dev to [0, 1]
             cultural_std_normalized = cultural_std / cls._MAX_CULTURAL_DISTANCE
             logger.debug(f"  Cultural std dev (normalized): {cultural_std_normalized:.4f}")
+            result.update(cultural_pairs=len(cultural_distances), cultural_std_dev=cultural_std)
         else:
             logger.debug("  No cultural distances computed (missing Hofstede data for countries)")
             cultural_std_normalized = 0.0
@@ -600,4 +637,5 @@ class GeodispersionCalculator:
         geodispersion = geo_std_normalized + cultural_std_normalized
         logger.debug(f"\nFinal geodispersion score: {geo_std_normalized:.4f} + {cultural_std_normalized:.4f} = {geodispersion:.4f}")
 
-        return geodispersion
+        result.update(geodispersion=geodispersion, cultural=cultural_std_normalized)
+        return result
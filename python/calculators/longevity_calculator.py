@@ -9,7 +9,7 @@ Computes longevity score based on four dimensions:
 """
 
 import logging
-from datetime import datetime, timedelta
+from datetime import datetime, timedelta, timezone
 from typing import Dict, List
 from collections import defaultdict
 
@@ -36,12 +36,13 @@ class LongevityCalculator:
             return None
     
     @staticmethod
-    def _compute_pr_acceptance_rate(pull_requests: List[Dict]) -> float:
+    def _compute_pr_acceptance_rate(pull_requests: List[Dict], details: Dict) -> float:
         """
         Compute PR Acceptance Rate: A_pr = Merged PRs / Closed PRs
         
         Args:
             pull_requests: List of pull request dicts with 'status' and 'merged_at' fields
+            details: Dict receiving merged_prs and closed_prs
             
         Returns:
             float: PR acceptance rate (0.0 to 1.0)
@@ -66,6 +67,7 @@ class LongevityCalculator:
                 if pr.get("merged_at") is not None:
                     merged_count += 1
         
+        details.update(merged_prs=merged_count, closed_prs=closed_count)
         logger.debug(f"  Total PRs: {len(pull_requests)}")
         logger.debug(f"  Merged PRs: {merged_count}")
         logger.debug(f"  Closed PRs (including merged): {closed_count}")
@@ -81,7 +83,7 @@ class LongevityCalculator:
         return acceptance_rate
     
     @staticmethod
-    def _compute_development_distribution(contributor_stats: List[Dict]) -> float:
+    def _compute_development_distribution(contributor_stats: List[Dict], details: Dict) -> float:
         """
         Compute Development Distribution via Gini Coefficient: D_dist = 1 - G
         
@@ -91,6 +93,7 @@ class LongevityCalculator:
         
         Args:
             contributor_stats: List of contributor stat dicts with 'author' and 'total' fields
+            details: Dict receiving committers, commits and gini
             
         Returns:
             float: Development distribution score (0.0 to 1.0), higher is better
@@ -104,6 +107,7 @@ class LongevityCalculator:
         counts.sort()
         
         n = len(counts)
+        details.update(committers=n, commits=sum(counts))
         
         if n == 0:
             logger.debug("  No contributors found, returning 0.0")
@@ -125,6 +129,7 @@ class LongevityCalculator:
         denominator = n * sum(counts)
         
         gini = numerator / denominator
+        details["gini"] = gini
         
         # D_dist = 1 - G (higher is better)
         d_dist = 1 - gini
@@ -135,7 +140,7 @@ class LongevityCalculator:
         return d_dist
     
     @staticmethod
-    def _compute_contributor_retention(contributor_stats: List[Dict]) -> float:
+    def _compute_contributor_retention(contributor_stats: List[Dict], details: Dict) -> float:
         """
         Compute Contributor Retention: C_ret = Contributors with tenure > 365 days / Total contributors
         
@@ -143,6 +148,7 @@ class LongevityCalculator:
         
         Args:
             contributor_stats: List of contributor stat dicts with 'first_commit' and 'last_commit' fields
+            details: Dict receiving long_term_contributors and contributors
             
         Returns:
             float: Contributor retention rate (0.0 to 1.0)
@@ -174,6 +180,7 @@ class LongevityCalculator:
             if tenure_days > 365:
                 long_term_contributors += 1
         
+        details.update(long_term_contributors=long_term_contributors, contributors=total_contributors)
         logger.debug(f"  Total contributors: {total_contributors}")
         logger.debug(f"  Contributors with tenure > 365 days: {long_term_contributors}")
         
@@ -187,7 +194,7 @@ class LongevityCalculator:
         return retention_rate
     
     @staticmethod
-    def _compute_technical_pulse(contributor_stats: List[Dict]) -> float:
+    def _compute_technical_pulse(contributor_stats: List[Dict], details: Dict) -> float:
         """
         Compute Technical Pulse: P_tech = Active weeks in last year / 52
         
@@ -195,6 +202,7 @@ class LongevityCalculator:
         
         Args:
             contributor_stats: List of contributor stat dicts with 'weeks' field
+            details: Dict receiving reference_date, active_weeks and commits_last_year
             
         Returns:
             float: Technical pulse score (0.0 to 1.0)
@@ -218,6 +226,7 @@ class LongevityCalculator:
         
         # Use the most recent commit date as the reference point
         reference_date = max(all_last_commits)
+        details["reference_date"] = reference_date.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
         one_year_ago = reference_date - timedelta(days=365)
         one_year_ago_timestamp = int(one_year_ago.timestamp())
         
@@ -239,14 +248,15 @@ class LongevityCalculator:
                 
                 # Check if this week is within the last year
                 if week_timestamp >= one_year_ago_timestamp and week_commits > 0:
-                    # Convert timestamp to datetime for week calculation
-                    week_date = datetime.fromtimestamp(week_timestamp)
+                    # Bucket by UTC ISO week, whatever the server's time zone
+                    week_date = datetime.fromtimestamp(week_timestamp, tz=timezone.utc)
                     iso_year, iso_week, _ = week_date.isocalendar()
                     week_key = (iso_year, iso_week)
                     active_weeks.add(week_key)
                     commits_in_period += week_commits
         
         active_week_count = len(active_weeks)
+        details.update(active_weeks=active_week_count, commits_last_year=commits_in_period)
         
         logger.debug(f"  Commits in last year: {commits_in_period}")
         logger.debug(f"  Active weeks in last year: {active_week_count}/52")
@@ -276,6 +286,20 @@ class LongevityCalculator:
         Returns:
             float: Longevity score (0.0 to 1.0)
         """
+        return cls.breakdown(repo_data)["longevity"]
+
+    @classmethod
+    def breakdown(cls, repo_data: Dict) -> Dict:
+        """
+        Compute the longevity score together with its four dimensions and the
+        counts each one was computed from.
+
+        Args:
+            repo_data: Dictionary containing repository information with commits and PRs
+
+        Returns:
+            dict: longevity, the dimensions and their inputs
+        """
         # Extract repository object if nested
         if "repository" in repo_data:
             repo = repo_data["repository"]
@@ -292,18 +316,31 @@ class LongevityCalculator:
         
         logger.debug(f"Input data: {len(contributor_stats)} contributors, {len(pull_requests)} pull requests")
         
+        details = {
+            "merged_prs": 0,
+            "closed_prs": 0,
+            "gini": 0.0,
+            "committers": 0,
+            "commits": 0,
+            "long_term_contributors": 0,
+            "contributors": 0,
+            "active_weeks": 0,
+            "commits_last_year": 0,
+            "reference_date": "",
+        }
+        
         # Compute each dimension
         logger.debug("\n1. Computing PR Acceptance Rate (A_pr)...")
-        a_pr = cls._compute_pr_acceptance_rate(pull_requests)
+        a_pr = cls._compute_pr_acceptance_rate(pull_requests, details)
         
         logger.debug("\n2. Computing Development Distribution (D_dist)...")
-        d_dist = cls._compute_development_distribution(contributor_stats)
+        d_dist = cls._compute_development_distribution(contributor_stats, details)
         
         logger.debug("\n3. Computing Contributor Retention (C_ret)...")
-        c_ret = cls._compute_contributor_retention(contributor_stats)
+        c_ret = cls._compute_contributor_retention(contributor_stats, details)
         
         logger.debug("\n4. Computing Technical Pulse (P_tech)...")
-        p_tech = cls._compute_technical_pulse(contributor_stats)
+        p_tech = cls._compute_technical_pulse(contributor_stats, details)
         
         # Calculate final longevity score
         longevity = (0.30 * d_dist) + (0.30 * c_ret) + (0.25 * a_pr) + (0.15 * p_tech)
@@ -318,4 +355,11 @@ class LongevityCalculator:
         logger.debug(f"  Final Longevity Score:             {longevity:.4f}")
         logger.debug("=" * 60)
         
-        return longevity
+        details.update(
+            longevity=longevity,
+            pr_acceptance_rate=a_pr,
+            development_distribution=d_dist,
+            contributor_retention=c_ret,
+            technical_pulse=p_tech,
+        )
+        return details
@@ -0,0 +1,103 @@
+"""
+Structure Calculator
+
+Computes structure, an attribute of the original YOSHI model, from the
+collaboration graph extracted by the Go service. Participants of recent
+issues, pull requests and discussions are nodes; two participants are linked
+when either responded to a thread opened by the other.
+
+Structure is the mean of two shares in [0, 1]:
+- connectedness = size of the largest connected component / participants
+- clustering = 3 * triangles / connected triples (global transitivity)
+"""
+
+from typing import Dict, List, Set
+
+
+class StructureCalculator:
+    """Calculator for computing structure from the collaboration graph."""
+
+    @classmethod
+    def compute(cls, repo_data: Dict) -> float:
+        """
+        Compute structure from the collaboration graph of the repository.
+
+        Args:
+            repo_data: Dictionary containing repository information.
+
+        Returns:
+            float: Structure score in [0, 1].
+        """
+        return cls.breakdown(repo_data)["structure"]
+
+    @classmethod
+    def breakdown(cls, repo_data: Dict) -> Dict:
+        """
+        Compute structure together with the graph counts it was computed from.
+
+        Args:
+            repo_data: Dictionary containing repository information.
+
+        Returns:
+            dict: structure, connectedness, clustering and the graph counts.
+        """
+        repo = repo_data.get("repository", repo_data)
+        engagement = repo.get("engagement") or {}
+        contributors: List[Dict] = engagement.get("contributors", [])
+
+        adjacency: Dict[str, Set[str]] = {c["login"].lower(): set() for c in contributors}
+        edges = 0
+        for edge in engagement.get("collaboration", []):
+            a, b = edge["from"].lower(), edge["to"].lower()
+            if a == b or a not in adjacency or b not in adjacency:
+                continue
+            if b not in adjacency[a]:
+                adjacency[a].add(b)
+                adjacency[b].add(a)
+                edges += 1
+
+        # Connected components by depth-first search
+        largest = 0
+        visited: Set[str] = set()
+        for start in adjacency:
+            if start in visited:
+                continue
+            size = 0
+            stack = [start]
+            visited.add(start)
+            while stack:
+                node = stack.pop()
+                size += 1
+                for neighbor in adjacency[node]:
+                    if neighbor not in visited:
+                        visited.add(neighbor)
+                        stack.append(neighbor)
+            largest = max(largest, size)
+
+        # Each triangle is counted once, from its lexically smallest node
+        triangles = 0
+        triples = 0
+        for node, neighbors in adjacency.items():
+            degree = len(neighbors)
+            triples += degree * (degree - 1) // 2
+            for u in neighbors:
+                if u <= node:
+                    continue
+                for w in adjacency[u]:
+                    if w > u and w in neighbors:
+                        triangles += 1
+
+        participants = len(contributors)
+        connectedness = largest / participants if participants >= 2 else 0.0
+        clustering = 3 * triangles / triples if triples > 0 else 0.0
+
+        return {
+            "structure": (connectedness + clustering) / 2,
+            "participants": participants,
+            "edges": edges,
+            "largest_component": largest,
+            "connectedness": connectedness,
+            "triangles": triangles,
+            "connected_triples": triples,
+            "clustering": clustering,
+        }
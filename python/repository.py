@@ -0,0 +1,120 @@
+"""
+Conversion of the Repository proto message into the repository data the calculators read.
+"""
+
+from typing import Dict
+
+
+def repository_data(repo) -> Dict:
+    """
+    Convert a Repository proto message into the dictionary the calculators take.
+
+    Only the attributes of the message are read, so any object carrying the same
+    fields works too.
+
+    Args:
+        repo: Repository message
+
+    Returns:
+        dict: {"repository": {...}} with the fields the calculators use
+    """
+    # Convert contributors from proto to dict list
+    contributors_data = []
+    for contributor in repo.contributors:
+        contributors_data.append({
+            "login": contributor.login,
+            "id": contributor.id,
+            "node_id": contributor.node_id,
+            "avatar_url": contributor.avatar_url,
+            "html_url": contributor.html_url,
+            "type": contributor.type,
+            "name": contributor.name,
+            "company": contributor.company,
+            "blog": contributor.blog,
+            "location": contributor.location,
+            "email": contributor.email,
+            "bio": contributor.bio,
+            "created_at": contributor.created_at,
+            "updated_at": contributor.updated_at,
+            "followers": contributor.followers,
+            "following": contributor.following,
+            "follower_following_ratio": contributor.follower_following_ratio,
+        })
+
+    # Convert contributor_stats from proto to dict list
+    contributor_stats_data = []
+    for stat in repo.contributor_stats:
+        # Convert weeks data
+        weeks_data = []
+        for week in stat.weeks:
+            weeks_data.append({
+                "week": week.week,
+                "additions": week.additions,
+                "deletions": week.deletions,
+                "commits": week.commits,
+            })
+
+        contributor_stats_data.append({
+            "author": stat.author,
+            "total": stat.total,
+            "weeks": weeks_data,
+            "first_commit": stat.first_commit,
+            "last_commit": stat.last_commit,
+        })
+
+    # Convert pull requests from proto to dict list
+    pull_requests_data = []
+    for pr in repo.pull_requests:
+        pull_requests_data.append({
+            "number": pr.number,
+            "status": pr.status,
+            # proto3 strings cannot be null; unmerged PRs arrive with an empty merged_at
+            "merged_at": pr.merged_at or None,
+        })
+
+    # Convert engagement from proto to dict
+    engagement_data = {
+        "threads": repo.engagement.threads,
+        "responded_threads": repo.engagement.responded_threads,
+        "contributors": [
+            {
+                "login": c.login,
+                "threads": c.threads,
+                "comments": c.comments,
+                "review_comments": c.review_comments,
+                "reviews": c.reviews,
+                "discussion_comments": c.discussion_comments,
+                "reactions_received": c.reactions_received,
+            }
+            for c in repo.engagement.contributors
+        ],
+        "collaboration": [
+            # "from" is a Python keyword, so the field is read with getattr
+            {"from": getattr(e, "from"), "to": e.to, "weight": e.weight}
+            for e in repo.engagement.collaboration
+        ],
+    }
+
+    # Convert proto message to dictionary for calculators
+    repo_data = {
+        "repository": {
+            "owner": repo.owner,
+            "repo": repo.repo,
+            "description": repo.description,
+            "has_code_of_conduct": repo.has_code_of_conduct,
+            "has_readme": repo.has_readme,
+            "has_description": repo.has_description,
+            "has_contributing_guidelines": repo.has_contributing_guidelines,
+            "has_license": repo.has_license,
+            "has_security_policy": repo.has_security_policy,
+            "has_issues_template": repo.has_issues_template,
+            "has_pull_request_template": repo.has_pull_request_template,
+            "has_wiki_page": repo.has_wiki_page,
+            "has_milestones": repo.has_milestones,
+            "contributors": contributors_data,
+            "contributor_stats": contributor_stats_data,
+            "pull_requests": pull_requests_data,
+            "engagement": engagement_data,
+        }
+    }
+    return repo_data